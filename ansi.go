@@ -0,0 +1,180 @@
+package main
+
+import (
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// ansiSpan is the style of the runes in [start, end) of a line set by ANSI escape sequences.
+type ansiSpan struct {
+	start, end int
+	style      tcell.Style
+}
+
+// isLogFile reports whether the file is a log, whose ANSI colors are interpreted.
+func isLogFile(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), ".log")
+}
+
+// applySGR returns the style changed by the parameters of a "select graphic rendition" sequence.
+func applySGR(style tcell.Style, params string) tcell.Style {
+	baseFg, baseBg, _ := styleBase.Decompose()
+	var codes []int
+	for _, p := range strings.Split(params, ";") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			n = 0 // empty means reset
+		}
+		codes = append(codes, n)
+	}
+	for i := 0; i < len(codes); i++ {
+		switch n := codes[i]; {
+		case n == 0:
+			style = styleBase
+		case n == 1:
+			style = style.Bold(true)
+		case n == 2:
+			style = style.Dim(true)
+		case n == 3:
+			style = style.Italic(true)
+		case n == 4:
+			style = style.Underline(true)
+		case n == 7:
+			style = style.Reverse(true)
+		case n == 22:
+			style = style.Bold(false).Dim(false)
+		case n == 23:
+			style = style.Italic(false)
+		case n == 24:
+			style = style.Underline(false)
+		case n == 27:
+			style = style.Reverse(false)
+		case 30 <= n && n <= 37:
+			style = style.Foreground(tcell.PaletteColor(n - 30))
+		case 90 <= n && n <= 97:
+			style = style.Foreground(tcell.PaletteColor(n - 90 + 8))
+		case n == 39:
+			style = style.Foreground(baseFg)
+		case 40 <= n && n <= 47:
+			style = style.Background(tcell.PaletteColor(n - 40))
+		case 100 <= n && n <= 107:
+			style = style.Background(tcell.PaletteColor(n - 100 + 8))
+		case n == 49:
+			style = style.Background(baseBg)
+		case n == 38 || n == 48:
+			// 256 colors "5;n" or true colors "2;r;g;b"
+			var color tcell.Color
+			switch {
+			case i+2 < len(codes) && codes[i+1] == 5:
+				color = tcell.PaletteColor(codes[i+2])
+				i += 2
+			case i+4 < len(codes) && codes[i+1] == 2:
+				color = tcell.NewRGBColor(int32(codes[i+2]), int32(codes[i+3]), int32(codes[i+4]))
+				i += 4
+			default:
+				return style
+			}
+			if n == 38 {
+				style = style.Foreground(color)
+			} else {
+				style = style.Background(color)
+			}
+		}
+	}
+	return style
+}
+
+// parseANSI removes the escape sequences from the line and returns the spans of the styles
+// set by them, starting in the style. It returns the style at the end of the line.
+func parseANSI(line []rune, style tcell.Style) ([]rune, []ansiSpan, tcell.Style) {
+	var text []rune
+	var spans []ansiSpan
+	start := 0
+	flush := func() {
+		if len(text) > start && style != styleBase {
+			spans = append(spans, ansiSpan{start: start, end: len(text), style: style})
+		}
+		start = len(text)
+	}
+	for i := 0; i < len(line); i++ {
+		if line[i] != '\x1b' {
+			text = append(text, line[i])
+			continue
+		}
+		if i+1 >= len(line) || line[i+1] != '[' {
+			// not a control sequence, drop the escape
+			continue
+		}
+		// parameters until the final byte in @ to ~
+		j := i + 2
+		for j < len(line) && (line[j] < '@' || line[j] > '~') {
+			j++
+		}
+		if j == len(line) {
+			break
+		}
+		if line[j] == 'm' {
+			flush()
+			style = applySGR(style, string(line[i+2:j]))
+		}
+		i = j
+	}
+	flush()
+	return text, spans, style
+}
+
+// interpretANSI removes the ANSI escape sequences from the lines of the tab from the row on,
+// keeping the styles they set to draw. The tab has no styles if no line has escapes.
+func (t *Tab) interpretANSI(row int) {
+	if row < len(t.ansi) {
+		t.ansi = t.ansi[:row]
+	}
+	style := styleBase
+	i := 0
+	for e := t.lines.Front(); e != nil; e = e.Next() {
+		if i < row {
+			i++
+			continue
+		}
+		line := e.Value
+		if t.ansi == nil && !slices.Contains(line, '\x1b') {
+			i++
+			continue
+		}
+		if t.ansi == nil {
+			t.ansi = make([][]ansiSpan, i)
+		}
+		for len(t.ansi) < i {
+			t.ansi = append(t.ansi, nil)
+		}
+		var spans []ansiSpan
+		e.Value, spans, style = parseANSI(line, style)
+		t.ansi = append(t.ansi, spans)
+		i++
+	}
+}
+
+// overlayANSI styles the runes of the line by the ANSI escape sequences it had.
+func (a *App) overlayANSI(texts []textStyle, row int, line []rune) []textStyle {
+	if row >= len(a.s.ansi) || len(a.s.ansi[row]) == 0 {
+		return texts
+	}
+	var runes []textStyle
+	for _, ts := range texts {
+		for _, r := range ts.text {
+			runes = append(runes, textStyle{text: []rune{r}, style: ts.style})
+		}
+	}
+	for _, span := range a.s.ansi[row] {
+		start := max(columnToVisual(line, span.start)-a.s.left, 0)
+		end := min(columnToVisual(line, span.end)-a.s.left, len(runes))
+		for i := start; i < end; i++ {
+			runes[i].style = span.style
+		}
+	}
+	return runes
+}
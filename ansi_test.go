@@ -0,0 +1,43 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestParseANSI(t *testing.T) {
+	red := styleBase.Foreground(tcell.PaletteColor(1))
+	tests := []struct {
+		line  string
+		text  string
+		spans []ansiSpan
+	}{
+		{"plain", "plain", nil},
+		{"\x1b[31mFAIL\x1b[0m ok", "FAIL ok", []ansiSpan{{0, 4, red}}},
+		{"\x1b[1;32mok\x1b[m", "ok", []ansiSpan{{0, 2, styleBase.Bold(true).Foreground(tcell.PaletteColor(2))}}},
+		{"a\x1b[38;2;1;2;3mb", "ab", []ansiSpan{{1, 2, styleBase.Foreground(tcell.NewRGBColor(1, 2, 3))}}},
+		{"\x1b[2Kclear\x1b[48;5;208m", "clear", nil},
+	}
+	for _, tt := range tests {
+		text, spans, _ := parseANSI([]rune(tt.line), styleBase)
+		if string(text) != tt.text || !reflect.DeepEqual(spans, tt.spans) {
+			t.Errorf("parseANSI(%q) = %q, %v, want %q, %v", tt.line, string(text), spans, tt.text, tt.spans)
+		}
+	}
+}
+
+func TestInterpretANSI(t *testing.T) {
+	var tab Tab
+	tab.setText("plain\n\x1b[31mred\nstill red\x1b[0m\nplain")
+	tab.interpretANSI(0)
+	red := styleBase.Foreground(tcell.PaletteColor(1))
+	want := [][]ansiSpan{nil, {{0, 3, red}}, {{0, 9, red}}, nil}
+	if got := tab.text(); got != "plain\nred\nstill red\nplain" {
+		t.Errorf("text = %q", got)
+	}
+	if !reflect.DeepEqual(tab.ansi, want) {
+		t.Errorf("ansi = %v, want %v", tab.ansi, want)
+	}
+}
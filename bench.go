@@ -0,0 +1,96 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// runBench runs the benchmarks matching the pattern in the package of current file in the background,
+// the output goes to the "bench" tab. The CPU and memory profiles are kept for >pprof.
+func (a *App) runBench(pattern string) {
+	if !strings.HasSuffix(a.s.filename, ".go") {
+		a.showMessage("Not a Go file")
+		return
+	}
+	dir, err := filepath.Abs(filepath.Dir(a.s.filename))
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	profiles, err := os.MkdirTemp("", "tino-bench")
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	pattern = cmp.Or(pattern, ".")
+	args := []string{"test", "-run", "^$", "-bench", pattern, "-benchmem",
+		"-cpuprofile", filepath.Join(profiles, "cpu.out"),
+		"-memprofile", filepath.Join(profiles, "mem.out"),
+		// the test binary symbolizes the profiles, kept out of the package directory
+		"-o", filepath.Join(profiles, "bench.test"),
+	}
+	t := a.s.tasks.start("bench "+pattern, 0)
+	a.showMessage("Running go " + strings.Join(args[:6], " "))
+	go func() {
+		defer t.finish()
+		cmd := exec.CommandContext(t.ctx, "go", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		canceled := t.ctx.Err() != nil
+		postFunc(func() {
+			output := fmt.Sprintf("$ go %s\n%s", strings.Join(args[:6], " "), out)
+			switch {
+			case canceled:
+				os.RemoveAll(profiles)
+				a.updateBuffer("bench", output+"canceled\n", false)
+				a.showMessage("Benchmark canceled")
+			case err != nil:
+				os.RemoveAll(profiles)
+				a.updateBuffer("bench", output+err.Error()+"\n", true)
+				a.showError("Benchmark failed")
+			default:
+				if a.s.benchProfiles != "" {
+					os.RemoveAll(a.s.benchProfiles)
+				}
+				a.s.benchProfiles = profiles
+				a.updateBuffer("bench", output, true)
+				a.showMessage("Benchmark done, >pprof cpu|mem shows the profile")
+			}
+		})
+	}()
+}
+
+// showProfile shows the top entries of the CPU or memory profile of the last benchmark
+// reported by "go tool pprof -top" in the "pprof" tab.
+func (a *App) showProfile(kind string) {
+	kind = cmp.Or(kind, "cpu")
+	if kind != "cpu" && kind != "mem" {
+		a.showError("Usage: >pprof [cpu|mem]")
+		return
+	}
+	if a.s.benchProfiles == "" {
+		a.showMessage("No profile, run >bench first")
+		return
+	}
+	profile := filepath.Join(a.s.benchProfiles, kind+".out")
+	binary := filepath.Join(a.s.benchProfiles, "bench.test")
+	t := a.s.tasks.start("pprof "+kind, 0)
+	go func() {
+		defer t.finish()
+		out, err := exec.CommandContext(t.ctx, "go", "tool", "pprof", "-top", binary, profile).CombinedOutput()
+		if t.ctx.Err() != nil {
+			return
+		}
+		postFunc(func() {
+			if err != nil {
+				a.showError(fmt.Sprintf("pprof: %v: %s", err, strings.TrimSpace(string(out))))
+				return
+			}
+			a.updateBuffer("pprof", string(out), true)
+		})
+	}()
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// callSite is a call in a function, found by parsing the Go files of the package.
+// Calls are matched by name, so methods of the same name on different types are not told apart.
+type callSite struct {
+	caller Symbol // the function containing the call
+	callee string // the name of the called function or method
+	file   string
+	row    int
+	col    int
+	text   string // the trimmed line of the call
+}
+
+// fileCalls returns the calls in the functions of the Go source.
+func fileCalls(filename string, src []byte) ([]callSite, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	lines := bytes.Split(src, []byte("\n"))
+	var calls []callSite
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Body == nil {
+			continue
+		}
+		caller := Symbol{Name: fn.Name.Name, Kind: SymbolFunc, File: filename}
+		if fn.Recv != nil && len(fn.Recv.List) > 0 {
+			typ := fn.Recv.List[0].Type
+			if star, ok := typ.(*ast.StarExpr); ok {
+				typ = star.X
+			}
+			if ident, ok := typ.(*ast.Ident); ok {
+				caller.Receiver = ident.Name
+			}
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok {
+				return true
+			}
+			var name *ast.Ident
+			switch fun := call.Fun.(type) {
+			case *ast.Ident:
+				name = fun
+			case *ast.SelectorExpr:
+				name = fun.Sel
+			default:
+				return true
+			}
+			pos := fset.Position(name.Pos())
+			calls = append(calls, callSite{
+				caller: caller,
+				callee: name.Name,
+				file:   filename,
+				row:    pos.Line - 1,
+				col:    pos.Column - 1,
+				text:   string(bytes.TrimSpace(lines[pos.Line-1])),
+			})
+			return true
+		})
+	}
+	return calls, nil
+}
+
+// packageCalls returns the calls in the Go files of the dir, skipping those not parsed.
+func packageCalls(dir string) []callSite {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	var calls []callSite
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		c, err := fileCalls(file, src)
+		if err != nil {
+			continue
+		}
+		calls = append(calls, c...)
+	}
+	return calls
+}
+
+// callNode is an entry of the call hierarchy tree.
+type callNode struct {
+	site     callSite
+	depth    int
+	expanded bool
+}
+
+// callTree is the callers of a function, or its callees if outgoing, expanded level by level.
+type callTree struct {
+	name     string
+	outgoing bool
+	calls    []callSite
+	nodes    []callNode
+}
+
+// children returns the nodes under the function: the calls to it, or the calls in it if outgoing.
+func (t *callTree) children(name string, depth int) []callNode {
+	var nodes []callNode
+	for _, c := range t.calls {
+		if t.outgoing && c.caller.Name == name || !t.outgoing && c.callee == name {
+			nodes = append(nodes, callNode{site: c, depth: depth})
+		}
+	}
+	return nodes
+}
+
+// function returns the name of the function the node expands to.
+func (t *callTree) function(n callNode) string {
+	if t.outgoing {
+		return n.site.callee
+	}
+	return n.site.caller.Name
+}
+
+// toggle expands the node at i, or collapses it with its descendants.
+func (t *callTree) toggle(i int) {
+	n := &t.nodes[i]
+	if n.expanded {
+		end := i + 1
+		for end < len(t.nodes) && t.nodes[end].depth > n.depth {
+			end++
+		}
+		t.nodes = append(t.nodes[:i+1], t.nodes[end:]...)
+		n.expanded = false
+		return
+	}
+	n.expanded = true
+	children := t.children(t.function(*n), n.depth+1)
+	t.nodes = append(t.nodes[:i+1], append(children, t.nodes[i+1:]...)...)
+}
+
+// text returns a line of the header and a line of each node.
+func (t *callTree) text() string {
+	var b strings.Builder
+	if t.outgoing {
+		fmt.Fprintf(&b, "calls from %s, tab expands and enter goes to the call", t.name)
+	} else {
+		fmt.Fprintf(&b, "callers of %s, tab expands and enter goes to the call", t.name)
+	}
+	wd, _ := os.Getwd()
+	for _, n := range t.nodes {
+		marker := "▸"
+		if n.expanded {
+			marker = "▾"
+		}
+		label := symbolName(n.site.caller)
+		if t.outgoing {
+			label = n.site.callee
+		}
+		file := n.site.file
+		if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+			file = rel
+		}
+		fmt.Fprintf(&b, "\n%s%s %s  %s:%d  %s", strings.Repeat("  ", n.depth), marker, label, file, n.site.row+1, n.site.text)
+	}
+	return b.String()
+}
+
+// showCalls opens the call hierarchy of the function under the cursor in the "calls" tab.
+func (a *App) showCalls(outgoing bool) {
+	name := a.s.wordAtCursor()
+	if name == "" || !strings.HasSuffix(a.s.filename, ".go") {
+		a.showMessage("No function under the cursor")
+		return
+	}
+	dir, err := filepath.Abs(filepath.Dir(a.s.filename))
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	t := &callTree{name: name, outgoing: outgoing, calls: packageCalls(dir)}
+	t.nodes = t.children(name, 0)
+	if len(t.nodes) == 0 {
+		if outgoing {
+			a.showMessage("No call in " + name)
+		} else {
+			a.showMessage("No caller of " + name)
+		}
+		return
+	}
+	if i := a.s.findBuffer("calls"); i >= 0 {
+		a.s.closeTab(i)
+	}
+	a.openBuffer("calls", t.text())
+	a.jump(1, 0)
+	a.s.onKey = func(a *App, ev *tcell.EventKey) bool {
+		i := a.s.row - 1
+		if i < 0 || i >= len(t.nodes) {
+			return false
+		}
+		switch ev.Key() {
+		case tcell.KeyTab:
+			t.toggle(i)
+			a.s.setText(t.text())
+			a.jump(a.s.row, 0)
+			a.drawEditor()
+			return true
+		case tcell.KeyEnter:
+			// the visible calls become the quickfix list, f4 goes on to the next
+			a.s.quickfix = nil
+			for _, n := range t.nodes {
+				a.s.quickfix = append(a.s.quickfix, quickfixItem{file: n.site.file, row: n.site.row, col: n.site.col, text: n.site.text})
+			}
+			a.s.quickfixIdx = i - 1
+			a.cmdCh <- ">nexterror"
+			return true
+		}
+		return false
+	}
+}
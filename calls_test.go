@@ -0,0 +1,48 @@
+package main
+
+import "testing"
+
+func TestCallTree(t *testing.T) {
+	src := `package p
+
+func a() { b(); c() }
+
+func b() { c() }
+
+func (t *T) c() {}
+`
+	calls, err := fileCalls("p.go", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree := &callTree{name: "c", calls: calls}
+	tree.nodes = tree.children("c", 0)
+	want := "callers of c, tab expands and enter goes to the call\n" +
+		"▸ a  p.go:3  func a() { b(); c() }\n" +
+		"▸ b  p.go:5  func b() { c() }"
+	if got := tree.text(); got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+
+	tree.toggle(1) // the callers of b
+	want = "callers of c, tab expands and enter goes to the call\n" +
+		"▸ a  p.go:3  func a() { b(); c() }\n" +
+		"▾ b  p.go:5  func b() { c() }\n" +
+		"  ▸ a  p.go:3  func a() { b(); c() }"
+	if got := tree.text(); got != want {
+		t.Errorf("expanded text = %q, want %q", got, want)
+	}
+	if site := tree.nodes[2].site; site.row != 2 || site.col != 11 {
+		t.Errorf("call of b at %d:%d, want 2:11", site.row, site.col)
+	}
+	tree.toggle(1)
+	if len(tree.nodes) != 2 {
+		t.Errorf("collapsed to %d nodes, want 2", len(tree.nodes))
+	}
+
+	out := &callTree{name: "a", outgoing: true, calls: calls}
+	out.nodes = out.children("a", 0)
+	if len(out.nodes) != 2 || out.nodes[0].site.callee != "b" || out.nodes[1].site.callee != "c" {
+		t.Errorf("calls from a = %+v", out.nodes)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+)
+
+// typeMembers returns the names of the fields, including promoted ones, and the methods of the type.
+func typeMembers(t types.Type) []string {
+	var names []string
+	seen := make(map[types.Type]bool)
+	var fields func(t types.Type)
+	fields = func(t types.Type) {
+		if ptr, ok := t.Underlying().(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		s, ok := t.Underlying().(*types.Struct)
+		if !ok || seen[t] {
+			return
+		}
+		seen[t] = true
+		for i := range s.NumFields() {
+			f := s.Field(i)
+			names = append(names, f.Name())
+			if f.Embedded() {
+				fields(f.Type())
+			}
+		}
+	}
+	fields(t)
+	mset := types.NewMethodSet(t)
+	if _, ok := t.Underlying().(*types.Interface); !ok {
+		if _, ok := t.(*types.Pointer); !ok {
+			// methods of pointer receivers are callable on addressable values
+			mset = types.NewMethodSet(types.NewPointer(t))
+		}
+	}
+	for i := range mset.Len() {
+		names = append(names, mset.At(i).Obj().Name())
+	}
+	slices.Sort(names)
+	return slices.Compact(names)
+}
+
+// typeCompletions returns the members of the value before the dot at the offset of the Go source,
+// or the fields of the struct literal whose key is at the offset, which must be an identifier "_".
+// Only the source is type checked, so types of the other files and imports are unknown.
+// It reports false if the offset is neither.
+func typeCompletions(filename string, src []byte, offset int) ([]string, bool) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, false
+	}
+	pos := fset.File(f.Pos()).Pos(offset)
+	var sel *ast.SelectorExpr
+	var lit *ast.CompositeLit
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil || n.Pos() > pos || n.End() < pos {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			if n.Sel.Pos() == pos {
+				sel = n
+			}
+		case *ast.CompositeLit:
+			for _, elt := range n.Elts {
+				if kv, ok := elt.(*ast.KeyValueExpr); ok {
+					elt = kv.Key
+				}
+				if elt.Pos() == pos {
+					lit = n
+				}
+			}
+		}
+		return true
+	})
+	if sel == nil && lit == nil {
+		return nil, false
+	}
+	info := &types.Info{Types: make(map[ast.Expr]types.TypeAndValue)}
+	conf := types.Config{Importer: emptyImporter{}, FakeImportC: true, Error: func(error) {}}
+	conf.Check(f.Name.Name, fset, []*ast.File{f}, info)
+	if sel != nil {
+		tv, ok := info.Types[sel.X]
+		if !ok || tv.Type == nil || !tv.IsValue() && !tv.IsType() {
+			return nil, false // e.g. a package name
+		}
+		return typeMembers(tv.Type), true
+	}
+	tv, ok := info.Types[lit]
+	if !ok || tv.Type == nil {
+		return nil, false
+	}
+	t := tv.Type
+	if ptr, ok := t.Underlying().(*types.Pointer); ok {
+		t = ptr.Elem()
+	}
+	s, ok := t.Underlying().(*types.Struct)
+	if !ok {
+		return nil, false
+	}
+	// the keys not yet in the literal
+	used := make(map[string]bool)
+	for _, elt := range lit.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			if ident, ok := kv.Key.(*ast.Ident); ok {
+				used[ident.Name] = true
+			}
+		}
+	}
+	var names []string
+	for i := range s.NumFields() {
+		if name := s.Field(i).Name(); !used[name] {
+			names = append(names, name)
+		}
+	}
+	return names, true
+}
+
+// completions returns the members completing the word before the cursor from type information,
+// see typeCompletions. The word starts at the column start.
+func (st *State) completions(start int) ([]string, bool) {
+	if !strings.HasSuffix(st.filename, ".go") {
+		return nil, false
+	}
+	// replace the word with a blank identifier to parse an unfinished selector
+	var b strings.Builder
+	offset := 0
+	row := 0
+	for e := st.lines.Front(); e != nil; e = e.Next() {
+		line := e.Value
+		if row == st.row {
+			b.WriteString(string(line[:start]))
+			offset = b.Len()
+			b.WriteString("_")
+			b.WriteString(string(line[st.col:]))
+		} else {
+			b.WriteString(string(line))
+		}
+		b.WriteByte('\n')
+		row++
+	}
+	return typeCompletions(st.filename, []byte(b.String()), offset)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTypeCompletions(t *testing.T) {
+	src := `package p
+
+import "strings"
+
+type base struct{ id int }
+
+func (b *base) ID() int { return b.id }
+
+type Tab struct {
+	base
+	row, col int
+}
+
+func (t Tab) line() {}
+
+func f(t *Tab, b strings.Builder) {
+	t.|
+	_ = Tab{row: 1, |}
+	_ = strings.|
+	_ = b.|
+}
+`
+	tests := []struct {
+		want []string
+		ok   bool
+	}{
+		{[]string{"ID", "base", "col", "id", "line", "row"}, true},
+		{[]string{"base", "col"}, true},
+		{nil, false}, // members of imported packages are unknown
+		{nil, false}, // so are imported types
+	}
+	// the cursors are replaced with blank identifiers, see State.completions
+	parts := strings.Split(src, "|")
+	text := strings.Join(parts, "_")
+	for i := range len(parts) - 1 {
+		offset := len(strings.Join(parts[:i+1], "_"))
+		got, ok := typeCompletions("p.go", []byte(text), offset)
+		if !reflect.DeepEqual(got, tests[i].want) || ok != tests[i].ok {
+			t.Errorf("completion %d = %q, %v, want %q, %v", i, got, ok, tests[i].want, tests[i].ok)
+		}
+	}
+}
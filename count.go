@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// textStats are the counts of a text like wc.
+type textStats struct {
+	lines, words, chars, bytes int
+}
+
+// countText counts the lines, words, characters and bytes of the text,
+// the last line counts without a newline.
+func countText(text string) textStats {
+	lines := strings.Count(text, "\n")
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		lines++
+	}
+	return textStats{
+		lines: lines,
+		words: len(strings.Fields(text)),
+		chars: utf8.RuneCountInString(text),
+		bytes: len(text),
+	}
+}
+
+func (s textStats) String() string {
+	return fmt.Sprintf("%d lines, %d words, %d chars, %d bytes", s.lines, s.words, s.chars, s.bytes)
+}
+
+// selectionStats returns the counts of the selected text, false if nothing is selected
+// or the selection is out of the text, as it only draws the status bar.
+func (st *State) selectionStats() (textStats, bool) {
+	sel := st.selected()
+	if sel == nil {
+		return textStats{}, false
+	}
+	start, end := st.line(sel.startRow), st.line(sel.endRow)
+	if start == nil || end == nil || sel.startCol > len(start.Value) || sel.endCol > len(end.Value) {
+		return textStats{}, false
+	}
+	return countText(st.textIn(sel.startRow, sel.startCol, sel.endRow, sel.endCol)), true
+}
+
+// showCount shows the counts of the selection, or the tab if nothing is selected.
+func (a *App) showCount() {
+	if stats, ok := a.s.selectionStats(); ok {
+		a.showMessage("Selection: " + stats.String())
+		return
+	}
+	a.showMessage(countText(a.s.text()).String())
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCountText(t *testing.T) {
+	tests := []struct {
+		text string
+		want textStats
+	}{
+		{"", textStats{}},
+		{"hello world\n", textStats{1, 2, 12, 12}},
+		{"a\nb c", textStats{2, 3, 5, 5}},
+		{"héllo  \n\n", textStats{2, 1, 9, 10}},
+	}
+	for _, tt := range tests {
+		if got := countText(tt.text); got != tt.want {
+			t.Errorf("countText(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestSelectionStatsOutOfText(t *testing.T) {
+	st := &State{Tab: &Tab{}}
+	st.loadSource(strings.NewReader("one\ntwo"))
+	tests := []struct {
+		sel Selection
+		ok  bool
+	}{
+		{Selection{startRow: 0, startCol: 1, endRow: 1, endCol: 2}, true},
+		{Selection{startRow: 0, startCol: 1, endRow: 0, endCol: 9}, false},
+		{Selection{startRow: 0, startCol: 1, endRow: 5, endCol: 0}, false},
+		{Selection{startRow: 1, startCol: 5, endRow: 1, endCol: 1}, false},
+	}
+	for _, tt := range tests {
+		st.selection = &tt.sel
+		if _, ok := st.selectionStats(); ok != tt.ok {
+			t.Errorf("selectionStats of %v: ok = %v, want %v", tt.sel, ok, tt.ok)
+		}
+	}
+}
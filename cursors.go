@@ -0,0 +1,202 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+)
+
+// editorPosition returns the row and column of the text at the screen position in the editor.
+func (a *App) editorPosition(x, y int) (row, col int) {
+	if a.s.lines.Len() == 0 {
+		return 0, 0
+	}
+	row = min(y-a.editor[0].y+a.s.top, a.s.lines.Len()-1)
+	line := a.s.line(row).Value
+	screenCol := x - a.editor[0].x - a.s.lineNumLen() + a.s.left
+	return row, a.s.columnAt(line, screenCol)
+}
+
+// sortCursors sorts the multiple cursors in order and drops the duplicates.
+func sortCursors(cursors []Selection) []Selection {
+	slices.SortFunc(cursors, func(a, b Selection) int {
+		return cmp.Or(cmp.Compare(a.startRow, b.startRow), cmp.Compare(a.startCol, b.startCol))
+	})
+	return slices.CompactFunc(cursors, func(a, b Selection) bool {
+		return a.startRow == b.startRow && a.startCol == b.startCol
+	})
+}
+
+// cursorsOrCurrent returns the multiple cursors, or the cursor with its selection on a single line.
+func (st *State) cursorsOrCurrent() []Selection {
+	if len(st.cursors) > 0 {
+		return st.cursors
+	}
+	if sel := st.selected(); sel != nil && sel.startRow == sel.endRow {
+		return []Selection{*sel}
+	}
+	col := min(st.col, len(st.line(st.row).Value))
+	return []Selection{{startRow: st.row, startCol: col, endRow: st.row, endCol: col}}
+}
+
+// addCursorAt puts one more cursor at the screen position, as ctrl-click or alt-click do,
+// or removes the cursor there. The cursor and the selection become the first of them.
+func (a *App) addCursorAt(x, y int) {
+	a.s.focus = focusEditor
+	row, col := a.editorPosition(x, y)
+	col = min(col, len(a.s.line(row).Value))
+	cursors := a.s.cursorsOrCurrent()
+	if i := slices.IndexFunc(cursors, func(c Selection) bool { return c.startRow == row && c.startCol == col }); i >= 0 {
+		if len(cursors) > 1 {
+			a.s.cursors = slices.Delete(slices.Clone(cursors), i, i+1)
+		}
+	} else {
+		a.s.cursors = sortCursors(append(slices.Clone(cursors), Selection{startRow: row, startCol: col, endRow: row, endCol: col}))
+		a.jump(row, col)
+	}
+	a.s.selection = nil
+	a.drawEditor()
+	a.syncCursor()
+}
+
+// wordRange returns the range of the word around the cursor, empty if none.
+func (st *State) wordRange() (start, stop int) {
+	e := st.line(st.row)
+	if e == nil {
+		return 0, 0
+	}
+	line := e.Value
+	ft := fileTypeOf(st.filename)
+	start = min(st.col, len(line))
+	for start > 0 && ft.isWordChar(line[start-1]) {
+		start--
+	}
+	stop = min(st.col, len(line))
+	for stop < len(line) && ft.isWordChar(line[stop]) {
+		stop++
+	}
+	return start, stop
+}
+
+// selectNext selects the word under the cursor, or adds a cursor selecting the next
+// occurrence of the text selected by the last cursor, wrapping around.
+func (a *App) selectNext() {
+	a.s.focus = focusEditor
+	if len(a.s.cursors) == 0 {
+		c := a.s.cursorsOrCurrent()[0]
+		if c.startCol == c.endCol {
+			c.startCol, c.endCol = a.s.wordRange()
+		}
+		if c.startCol == c.endCol {
+			a.showMessage("No word under the cursor")
+			return
+		}
+		a.s.cursors = []Selection{c}
+		a.s.selection = nil
+		a.jump(c.endRow, c.endCol)
+		a.drawEditor()
+		return
+	}
+	last := a.s.cursors[len(a.s.cursors)-1]
+	text := a.s.line(last.startRow).Value[last.startCol:last.endCol]
+	if len(text) == 0 {
+		a.showMessage("No text selected by the cursors")
+		return
+	}
+	row, col := last.endRow, last.endCol
+	for {
+		var ok bool
+		row, col, ok = a.s.findFrom(row, col, text, true)
+		if !ok || slices.ContainsFunc(a.s.cursors, func(c Selection) bool { return c.startRow == row && c.startCol == col }) {
+			a.showMessage("No more occurrences")
+			return
+		}
+		if !a.s.hasOverlap(row, col, col+len(text)) {
+			break
+		}
+		col++
+	}
+	a.s.cursors = sortCursors(append(a.s.cursors, Selection{startRow: row, startCol: col, endRow: row, endCol: col + len(text)}))
+	a.jump(row, col+len(text))
+	a.drawEditor()
+}
+
+// hasOverlap reports whether the columns of the row overlap a cursor selection.
+func (st *State) hasOverlap(row, start, end int) bool {
+	return slices.ContainsFunc(st.cursors, func(c Selection) bool {
+		return c.startRow == row && c.startCol < end && start < c.endCol
+	})
+}
+
+// editAtCursors replaces the selected text of every cursor with the text of the cursor,
+// deleting the grapheme before a cursor selecting nothing instead if backspace is true.
+// The text may break lines, the following cursors move along.
+func (a *App) editAtCursors(text func(i int) string, backspace bool) {
+	// edit from the last to the first, so that the positions of preceding cursors stay valid
+	a.s.beginGroup()
+	defer a.s.endGroup()
+	cursors := a.s.cursors
+	for i := len(cursors) - 1; i >= 0; i-- {
+		c := &cursors[i]
+		e := a.s.line(c.startRow)
+		if e == nil || c.endRow != c.startRow || c.startCol > c.endCol || c.endCol > len(e.Value) {
+			continue
+		}
+		row, col, end := c.startRow, c.startCol, c.endCol
+		inserted := ""
+		if backspace && col == end {
+			if col == 0 {
+				continue // lines are not joined at multiple cursors
+			}
+			col = prevGrapheme(e.Value, col)
+		} else if !backspace {
+			inserted = text(i)
+		}
+		if col == end && inserted == "" {
+			continue
+		}
+		deleted := ""
+		if col != end {
+			deleted = a.s.deleteRange(row, col, row, end)
+		}
+		a.s.insertText([]rune(inserted), row, col)
+		change := Change{row: row, col: col, oldText: deleted, newText: inserted, kind: editReplace}
+		if deleted == "" {
+			change.kind = editInsert
+		} else if inserted == "" {
+			change.kind = editDelete
+		}
+		a.s.recordChange(change)
+
+		// collapse the cursor to the end of the edit, move the following cursors along
+		endRow, endCol := row, col
+		if inserted != "" {
+			endRow, endCol = a.s.row, a.s.col
+		}
+		for j := i + 1; j < len(cursors); j++ {
+			if cursors[j].startRow == row {
+				cursors[j].startCol += endCol - end
+				cursors[j].endCol += endCol - end
+			}
+			cursors[j].startRow += endRow - row
+			cursors[j].endRow += endRow - row
+		}
+		*c = Selection{startRow: endRow, startCol: endCol, endRow: endRow, endCol: endCol}
+	}
+	// recordChange drops the cursors as of other edits, these moved along
+	a.s.cursors = cursors
+	last := cursors[len(cursors)-1]
+	a.jump(last.endRow, last.endCol)
+	a.drawEditor()
+}
+
+// pasteAtCursors pastes the text at every cursor, a line for each if the text has as many
+// lines as the cursors, otherwise all of it.
+func (a *App) pasteAtCursors(text string) {
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	if len(lines) == len(a.s.cursors) {
+		a.editAtCursors(func(i int) string { return lines[i] }, false)
+		return
+	}
+	a.editAtCursors(func(int) string { return text }, false)
+}
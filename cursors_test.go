@@ -0,0 +1,56 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestSortCursors(t *testing.T) {
+	got := sortCursors([]Selection{
+		{startRow: 2, startCol: 1, endRow: 2, endCol: 1},
+		{startRow: 0, startCol: 5, endRow: 0, endCol: 8},
+		{startRow: 0, startCol: 1, endRow: 0, endCol: 1},
+		{startRow: 2, startCol: 1, endRow: 2, endCol: 1},
+	})
+	want := []Selection{
+		{startRow: 0, startCol: 1, endRow: 0, endCol: 1},
+		{startRow: 0, startCol: 5, endRow: 0, endCol: 8},
+		{startRow: 2, startCol: 1, endRow: 2, endCol: 1},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("sortCursors = %v, want %v", got, want)
+	}
+}
+
+func TestCursorsDroppedByOtherEdits(t *testing.T) {
+	a := newTestApp(t, "x\nax\nbbx\n")
+	a.paste("yyyy")
+	a.s.markSaved()
+	a.jump(0, 0)
+	if n := a.selectMatches([]rune("x")); n != 3 {
+		t.Fatalf("selectMatches = %d, want 3", n)
+	}
+	a.s.undo()
+	if a.s.cursors != nil {
+		t.Errorf("cursors = %v after undo, want none", a.s.cursors)
+	}
+
+	a.s.redo()
+	a.selectMatches([]rune("x"))
+	a.editCursors(tcell.NewEventKey(tcell.KeyRune, 'z', tcell.ModNone))
+	if got, want := a.s.text(), "yyyyz\naz\nbbz\n"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+	if len(a.s.cursors) != 3 {
+		t.Errorf("cursors = %v after typing at them, want 3", a.s.cursors)
+	}
+
+	// stale cursors are skipped instead of sliced
+	a.s.cursors = []Selection{{startRow: 1, startCol: 1, endRow: 1, endCol: 9}, {startRow: 2, startCol: 3, endRow: 2, endCol: 3}}
+	a.editCursors(tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone))
+	if got, want := a.s.text(), "yyyyz\naz\nbb\n"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+// dapMessage is a request, response or event of the Debug Adapter Protocol.
+type dapMessage struct {
+	Seq        int             `json:"seq"`
+	Type       string          `json:"type"`
+	Command    string          `json:"command,omitempty"`
+	Arguments  any             `json:"arguments,omitempty"`
+	RequestSeq int             `json:"request_seq,omitempty"`
+	Success    bool            `json:"success,omitempty"`
+	Message    string          `json:"message,omitempty"`
+	Event      string          `json:"event,omitempty"`
+	Body       json.RawMessage `json:"body,omitempty"`
+}
+
+// writeContent writes the JSON of the message with the Content-Length header,
+// as the Debug Adapter and the Language Server protocols frame messages.
+func writeContent(w io.Writer, m any) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "Content-Length: %d\r\n\r\n", len(data))
+	b.Write(data)
+	_, err = w.Write(b.Bytes())
+	return err
+}
+
+// readContent reads the content following its Content-Length header.
+func readContent(r *bufio.Reader) ([]byte, error) {
+	header, err := textproto.NewReader(r).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length: %w", err)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// writeDAPMessage writes the message with the Content-Length header.
+func writeDAPMessage(w io.Writer, m dapMessage) error {
+	return writeContent(w, m)
+}
+
+// readDAPMessage reads a message following its Content-Length header.
+func readDAPMessage(r *bufio.Reader) (dapMessage, error) {
+	data, err := readContent(r)
+	if err != nil {
+		return dapMessage{}, err
+	}
+	var m dapMessage
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+// dapClient talks to a debug adapter such as "dlv dap".
+type dapClient struct {
+	conn    io.ReadWriteCloser
+	mu      sync.Mutex // guards the fields below and writing
+	seq     int
+	pending map[int]chan dapMessage // response channels by request seq
+	closed  bool
+	// onEvent is called in the reading goroutine, it must not block on requests.
+	onEvent func(event string, body json.RawMessage)
+	done    chan struct{} // closed when the connection is lost
+}
+
+// newDAPClient starts reading the messages of the connection.
+func newDAPClient(conn io.ReadWriteCloser, onEvent func(event string, body json.RawMessage)) *dapClient {
+	c := &dapClient{
+		conn:    conn,
+		pending: make(map[int]chan dapMessage),
+		onEvent: onEvent,
+		done:    make(chan struct{}),
+	}
+	go c.read()
+	return c
+}
+
+func (c *dapClient) read() {
+	r := bufio.NewReader(c.conn)
+	for {
+		m, err := readDAPMessage(r)
+		if err != nil {
+			break
+		}
+		switch m.Type {
+		case "response":
+			c.mu.Lock()
+			ch := c.pending[m.RequestSeq]
+			delete(c.pending, m.RequestSeq)
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- m
+			}
+		case "event":
+			c.onEvent(m.Event, m.Body)
+		}
+	}
+	c.mu.Lock()
+	c.closed = true
+	for seq, ch := range c.pending {
+		close(ch)
+		delete(c.pending, seq)
+	}
+	c.mu.Unlock()
+	close(c.done)
+}
+
+// request sends the command and waits for the response, decoding its body into result if not nil.
+func (c *dapClient) request(command string, args any, result any) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return errors.New("debug adapter disconnected")
+	}
+	c.seq++
+	seq := c.seq
+	ch := make(chan dapMessage, 1)
+	c.pending[seq] = ch
+	err := writeDAPMessage(c.conn, dapMessage{Seq: seq, Type: "request", Command: command, Arguments: args})
+	if err != nil {
+		delete(c.pending, seq)
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	resp, ok := <-ch
+	if !ok {
+		return errors.New("debug adapter disconnected")
+	}
+	if !resp.Success {
+		return fmt.Errorf("%s: %s", command, resp.Message)
+	}
+	if result != nil && len(resp.Body) > 0 {
+		return json.Unmarshal(resp.Body, result)
+	}
+	return nil
+}
+
+func (c *dapClient) close() error {
+	return c.conn.Close()
+}
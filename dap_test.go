@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"testing"
+)
+
+func TestDAPClient(t *testing.T) {
+	client, server := net.Pipe()
+	events := make(chan string, 1)
+	c := newDAPClient(client, func(event string, body json.RawMessage) {
+		events <- event + " " + string(body)
+	})
+	defer c.close()
+
+	// a fake adapter answering a request, then sending an event
+	go func() {
+		r := bufio.NewReader(server)
+		req, err := readDAPMessage(r)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		writeDAPMessage(server, dapMessage{
+			Seq:        1,
+			Type:       "response",
+			Command:    req.Command,
+			RequestSeq: req.Seq,
+			Success:    true,
+			Body:       json.RawMessage(`{"threads":[{"id":1,"name":"main"}]}`),
+		})
+		writeDAPMessage(server, dapMessage{Seq: 2, Type: "event", Event: "stopped", Body: json.RawMessage(`{"threadId":1}`)})
+	}()
+
+	var result struct {
+		Threads []struct {
+			ID int `json:"id"`
+		} `json:"threads"`
+	}
+	if err := c.request("threads", nil, &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Threads) != 1 || result.Threads[0].ID != 1 {
+		t.Errorf("threads = %+v", result.Threads)
+	}
+	if got, want := <-events, `stopped {"threadId":1}`; got != want {
+		t.Errorf("event = %q, want %q", got, want)
+	}
+
+	server.Close()
+	<-c.done
+	if err := c.request("threads", nil, nil); err == nil {
+		t.Error("request after disconnected, want error")
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// dataError is a syntax error of JSON or YAML text at the row and the rune column.
+type dataError struct {
+	row, col int
+	msg      string
+}
+
+func (e *dataError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.row+1, e.col+1, e.msg)
+}
+
+// offsetPosition returns the row and the rune column of the byte offset in the text.
+func offsetPosition(text string, offset int) (int, int) {
+	offset = max(0, min(offset, len(text)))
+	before := text[:offset]
+	row := strings.Count(before, "\n")
+	return row, utf8.RuneCountInString(before[strings.LastIndex(before, "\n")+1:])
+}
+
+// formatJSON returns the JSON text indented by the indent unit, a tab if empty.
+func formatJSON(text, indent string) (string, error) {
+	var b bytes.Buffer
+	err := json.Indent(&b, []byte(text), "", cmp.Or(indent, "\t"))
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// the offset is after the offending byte
+		row, col := offsetPosition(text, int(syntaxErr.Offset)-1)
+		return "", &dataError{row: row, col: col, msg: syntaxErr.Error()}
+	}
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// yamlLevel is a block mapping or sequence of YAML at an indentation.
+type yamlLevel struct {
+	indent int
+	seq    bool
+	keys   map[string]bool // of a mapping
+	open   bool            // whether the last entry has its value on the next lines
+}
+
+// yamlContent returns the line without the comment, and the position of an unterminated quote if any.
+func yamlContent(line string) (string, int) {
+	var quote rune
+	quoteAt := -1
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			// an escaped '' closes and does not reopen since a quote starts only after a separator
+			if r == quote && !(quote == '"' && i > 0 && line[i-1] == '\\') {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			if i == 0 || strings.ContainsRune(" \t:[{,-", rune(line[i-1])) {
+				quote, quoteAt = r, i
+			}
+		case r == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return strings.TrimRight(line[:i], " \t"), -1
+		}
+	}
+	if quote != 0 {
+		return line, quoteAt
+	}
+	return strings.TrimRight(line, " \t"), -1
+}
+
+// yamlKey returns the key of the "key: value" content and whether the value is on the next lines.
+func yamlKey(content string) (key string, open bool, ok bool) {
+	if strings.HasPrefix(content, "[") || strings.HasPrefix(content, "{") {
+		return "", false, false
+	}
+	rest := content
+	if strings.HasPrefix(content, `"`) || strings.HasPrefix(content, "'") {
+		end := strings.IndexByte(content[1:], content[0])
+		if end < 0 {
+			return "", false, false
+		}
+		rest = content[end+2:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", false, false
+		}
+		key, rest = content[:end+2], rest[1:]
+	} else {
+		i := strings.Index(content, ": ")
+		if i < 0 {
+			if !strings.HasSuffix(content, ":") {
+				return "", false, false
+			}
+			i = len(content) - 1
+		}
+		key, rest = content[:i], content[i+1:]
+	}
+	value := strings.TrimSpace(rest)
+	// a block scalar like "|" or ">-" continues on the next lines too
+	return key, value == "" || strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"), true
+}
+
+// checkYAML returns the first error of the common YAML mistakes in the block style:
+// tabs in indentation, unterminated quotes, unbalanced brackets, inconsistent indentation,
+// a line in a mapping without a key and duplicate keys. It is not a full parser.
+func checkYAML(text string) *dataError {
+	var levels []*yamlLevel
+	var block int = -1    // the indentation of the key of a block scalar, -1 if not in one
+	var flow []*dataError // the open brackets of flow collections
+	for row, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+		if block >= 0 {
+			if strings.TrimSpace(line) == "" || indent > block {
+				continue
+			}
+			block = -1
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return &dataError{row: row, col: indent, msg: "tab in indentation"}
+		}
+		content, quoteAt := yamlContent(trimmed)
+		if quoteAt >= 0 {
+			return &dataError{row: row, col: indent + utf8.RuneCountInString(trimmed[:quoteAt]), msg: "unterminated quoted string"}
+		}
+		if content == "" {
+			continue
+		}
+		if content == "---" || content == "..." || strings.HasPrefix(content, "--- ") || strings.HasPrefix(content, "%") {
+			levels = levels[:0] // a new document or a directive
+			continue
+		}
+
+		// the brackets of flow collections, which may span lines
+		inFlow := len(flow) > 0
+		for i, r := range content {
+			switch r {
+			case '[', '{':
+				flow = append(flow, &dataError{row: row, col: indent + utf8.RuneCountInString(content[:i]), msg: "unclosed " + string(r)})
+			case ']', '}':
+				if len(flow) == 0 {
+					return &dataError{row: row, col: indent + utf8.RuneCountInString(content[:i]), msg: "unexpected " + string(r)}
+				}
+				flow = flow[:len(flow)-1]
+			}
+		}
+		if inFlow {
+			continue
+		}
+
+		// the nodes starting on the line, "- key: value" starts a sequence item and a mapping
+		for {
+			for len(levels) > 0 && levels[len(levels)-1].indent > indent {
+				levels = levels[:len(levels)-1]
+			}
+			seq := content == "-" || strings.HasPrefix(content, "- ")
+			var top *yamlLevel
+			if len(levels) > 0 {
+				top = levels[len(levels)-1]
+			}
+			switch {
+			case top == nil || indent > top.indent:
+				if top != nil && !top.open {
+					return &dataError{row: row, col: indent, msg: "unexpected indentation"}
+				}
+				if top != nil {
+					top.open = false
+				}
+				levels = append(levels, &yamlLevel{indent: indent, seq: seq, keys: make(map[string]bool)})
+			case top.seq != seq:
+				if seq && top.open {
+					// a sequence may be at the indentation of its key
+					top.open = false
+					levels = append(levels, &yamlLevel{indent: indent, seq: true})
+					break
+				}
+				if !seq && len(levels) > 1 && levels[len(levels)-2].indent == indent {
+					// the mapping after the sequence at the indentation of its key
+					levels = levels[:len(levels)-1]
+					continue
+				}
+				if seq {
+					return &dataError{row: row, col: indent, msg: "sequence item in a mapping"}
+				}
+				return &dataError{row: row, col: indent, msg: "mapping key in a sequence"}
+			}
+			level := levels[len(levels)-1]
+			if seq {
+				item := strings.TrimLeft(strings.TrimPrefix(content, "-"), " ")
+				level.open = item == ""
+				if item == "" {
+					break
+				}
+				if _, _, ok := yamlKey(item); !ok {
+					if strings.HasPrefix(item, "|") || strings.HasPrefix(item, ">") {
+						block = indent
+					}
+					break
+				}
+				// the mapping in the item
+				level.open = true
+				indent += len(content) - len(item)
+				content = item
+				continue
+			}
+			key, open, ok := yamlKey(content)
+			if !ok {
+				if len(levels) == 1 && len(level.keys) == 0 {
+					break // a scalar document
+				}
+				return &dataError{row: row, col: indent, msg: "missing ':' after the key"}
+			}
+			if level.keys[key] {
+				return &dataError{row: row, col: indent, msg: "duplicate key " + key}
+			}
+			level.keys[key] = true
+			level.open = open
+			if value := strings.TrimSpace(content[len(key)+1:]); strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+				block = indent
+			}
+			break
+		}
+	}
+	if len(flow) > 0 {
+		return flow[len(flow)-1]
+	}
+	return nil
+}
+
+// dataLines returns the first row and the lines of the selected lines, or of the whole tab.
+func (a *App) dataLines() (int, []string) {
+	start, end := 0, a.s.lines.Len()-1
+	if sel := a.s.selected(); sel != nil {
+		start, end = sel.startRow, sel.endRow
+		if sel.endCol == 0 && end > start {
+			end--
+		}
+	}
+	var lines []string
+	for e, row := a.s.line(start), start; e != nil && row <= end; e, row = e.Next(), row+1 {
+		lines = append(lines, string(e.Value))
+	}
+	return start, lines
+}
+
+// dataCommand formats or checks the JSON or YAML of the selected lines or the tab,
+// going to the first syntax error.
+func (a *App) dataCommand(lang, op string) {
+	a.s.focus = focusEditor
+	if lang == "json" && op == "fmt" && !a.editable() {
+		return
+	}
+	start, lines := a.dataLines()
+	text := strings.Join(lines, "\n")
+	var err error
+	switch {
+	case lang == "json" && (op == "fmt" || op == "check"):
+		var formatted string
+		formatted, err = formatJSON(text, a.s.indent)
+		if err == nil && op == "fmt" {
+			newLines := strings.Split(strings.TrimRight(formatted, " \t\r\n"), "\n")
+			if lines[len(lines)-1] == "" {
+				newLines = append(newLines, "") // keep the line break at the end
+			}
+			a.s.selection = nil
+			a.replaceLines(start, lines, newLines)
+			a.drawEditor()
+		}
+	case lang == "yaml" && op == "check":
+		if e := checkYAML(text); e != nil {
+			err = e
+		}
+	case lang == "json":
+		a.showError("Usage: >json fmt|check")
+		return
+	default:
+		a.showError("Usage: >yaml check")
+		return
+	}
+	var e *dataError
+	if errors.As(err, &e) {
+		a.jump(start+e.row, e.col)
+		a.showError(fmt.Sprintf("Line %d, column %d: %s", start+e.row+1, e.col+1, e.msg))
+		return
+	}
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	if op == "fmt" {
+		a.showMessage("Formatted")
+	} else {
+		a.showMessage("Valid " + strings.ToUpper(lang))
+	}
+}
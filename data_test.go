@@ -0,0 +1,69 @@
+package main
+
+import "testing"
+
+func TestFormatJSON(t *testing.T) {
+	got, err := formatJSON(`{"a":[1,2],"b":{}}`, "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {}\n}"
+	if got != want {
+		t.Errorf("formatJSON = %q, want %q", got, want)
+	}
+
+	_, err = formatJSON("{\n  \"a\": 1,\n  \"é\": tru\n}", "")
+	e, ok := err.(*dataError)
+	if !ok || e.row != 2 || e.col != 10 {
+		t.Errorf("formatJSON error = %v, want at line 3, column 11", err)
+	}
+}
+
+func TestCheckYAML(t *testing.T) {
+	valid := `# config
+name: tino
+version: "1.0" # quoted
+tags: [editor, "go: tool"]
+owner:
+  name: it's me
+  email: a@b.c
+items:
+- id: 1
+  desc: |
+    text: with colon
+    - not an item
+- id: 2
+  nested:
+    - x
+    - y
+empty:
+---
+other: {a: 1,
+  b: 2}
+`
+	if err := checkYAML(valid); err != nil {
+		t.Errorf("checkYAML(valid) = %v", err)
+	}
+	tests := []struct {
+		text     string
+		row, col int
+		msg      string
+	}{
+		{"a: 1\n\tb: 2", 1, 0, "tab in indentation"},
+		{"a: \"open\nb: 2", 0, 3, "unterminated quoted string"},
+		{"a: [1, 2\nb: 2", 0, 3, "unclosed ["},
+		{"a: 1]", 0, 4, "unexpected ]"},
+		{"a: 1\n  b: 2", 1, 2, "unexpected indentation"},
+		{"a:\n    b: 1\n  c: 2", 2, 2, "unexpected indentation"},
+		{"a: 1\nb", 1, 0, "missing ':' after the key"},
+		{"a: 1\na: 2", 1, 0, "duplicate key a"},
+		{"a: 1\n- b", 1, 0, "sequence item in a mapping"},
+		{"- a\nb: 1", 1, 0, "mapping key in a sequence"},
+	}
+	for _, tt := range tests {
+		err := checkYAML(tt.text)
+		if err == nil || err.row != tt.row || err.col != tt.col || err.msg != tt.msg {
+			t.Errorf("checkYAML(%q) = %v, want line %d, column %d: %s", tt.text, err, tt.row+1, tt.col+1, tt.msg)
+		}
+	}
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// breakpointOwner owns the breakpoint annotations in the gutter.
+const breakpointOwner = "breakpoint"
+
+// debugTitle is the title of the tab showing the variables and output of the debugged program.
+const debugTitle = "debug"
+
+var (
+	styleBreakpoint = styleBase.Foreground(tcell.ColorRed)
+	colorDebugLine  = tcell.ColorKhaki
+)
+
+// debugSession is a program debugged by Delve through the Debug Adapter Protocol.
+// Its fields are updated in the main goroutine.
+type debugSession struct {
+	client   *dapClient
+	cmd      *exec.Cmd
+	threadID int
+	stopFile string // absolute path of the file where the program stops
+	stopRow  int    // -1 if the program is running
+	reason   string // why the program stopped, such as breakpoint or step
+	vars     string // variables of the top frame
+	output   strings.Builder
+}
+
+// startDebug runs "dlv dap" and launches the program, mode is "main" to debug the
+// package in the working directory or "test" to debug the tests of current file's package.
+// It runs in the command loop.
+func (a *App) startDebug(mode string) {
+	if a.s.debug != nil {
+		a.showError("The program is being debugged, >debug stop to end it")
+		return
+	}
+	launch := map[string]any{"request": "launch", "mode": "debug", "program": "."}
+	if mode == "test" {
+		if a.s.filename == "" {
+			a.showError("Open a file of the package to test")
+			return
+		}
+		dir, err := filepath.Abs(filepath.Dir(a.s.filename))
+		if err != nil {
+			a.showError(err.Error())
+			return
+		}
+		launch["mode"], launch["program"] = "test", dir
+	}
+
+	t := a.s.tasks.start("starting debugger", 0)
+	defer t.finish()
+	s, err := a.launchDebug(t.ctx, launch)
+	if t.ctx.Err() != nil {
+		if s != nil {
+			s.stop()
+		}
+		a.showMessage("Debugging canceled")
+		return
+	}
+	if err != nil {
+		log.Print(err)
+		a.showError("Failed to debug: " + err.Error())
+		return
+	}
+	a.showMessage("Debugging, f5 continue, f6 next, f7 step in, f8 step out, f9 toggle breakpoint")
+}
+
+// launchDebug starts the debug adapter and launches the program with the breakpoints of the tabs.
+// Canceling the context kills the adapter while launching.
+func (a *App) launchDebug(ctx context.Context, launch map[string]any) (*debugSession, error) {
+	cmd := exec.Command("dlv", "dap", "--listen", "127.0.0.1:0")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	s := &debugSession{cmd: cmd, stopRow: -1}
+	stopKill := context.AfterFunc(ctx, s.kill)
+	defer stopKill()
+	// the first line is like "DAP server listening at: 127.0.0.1:38697"
+	r := bufio.NewReader(stdout)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		s.stop()
+		return nil, err
+	}
+	go io.Copy(io.Discard, r)
+	_, addr, ok := strings.Cut(strings.TrimSpace(line), "listening at: ")
+	if !ok {
+		s.stop()
+		return nil, fmt.Errorf("unexpected output of dlv: %s", line)
+	}
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		s.stop()
+		return nil, err
+	}
+
+	initialized := make(chan struct{})
+	s.client = newDAPClient(conn, func(event string, body json.RawMessage) {
+		if event == "initialized" {
+			close(initialized)
+			return
+		}
+		a.debugEvent(s, event, body)
+	})
+	go func() {
+		<-s.client.done
+		postFunc(func() { a.endDebug(s, "Debugger disconnected") })
+	}()
+	err = s.client.request("initialize", map[string]any{
+		"clientID":        "tino",
+		"adapterID":       "go",
+		"linesStartAt1":   true,
+		"columnsStartAt1": true,
+		"pathFormat":      "path",
+	}, nil)
+	if err == nil {
+		err = s.client.request("launch", launch, nil)
+	}
+	if err != nil {
+		s.stop()
+		return nil, err
+	}
+	select {
+	case <-initialized:
+	case <-s.client.done:
+		s.stop()
+		return nil, errors.New("debug adapter disconnected")
+	}
+
+	a.s.debug = s
+	for file, rows := range a.s.breakpoints() {
+		if err := s.setBreakpoints(file, rows); err != nil {
+			a.showError(err.Error())
+		}
+	}
+	if err := s.client.request("configurationDone", nil, nil); err != nil {
+		s.stop()
+		return nil, err
+	}
+	return s, nil
+}
+
+// debugKeys are the keys of debugging.
+var debugKeys = map[tcell.Key]string{
+	tcell.KeyF5: ">debug continue",
+	tcell.KeyF6: ">debug next",
+	tcell.KeyF7: ">debug step",
+	tcell.KeyF8: ">debug stepout",
+	tcell.KeyF9: ">breakpoint",
+}
+
+// postFunc runs the function in the main goroutine.
+func postFunc(f func()) {
+	screen.PostEvent(tcell.NewEventInterrupt(f))
+}
+
+// debugEvent handles the event of the debug adapter, it is called in the reading goroutine.
+func (a *App) debugEvent(s *debugSession, event string, body json.RawMessage) {
+	switch event {
+	case "stopped":
+		var stopped struct {
+			Reason   string `json:"reason"`
+			ThreadID int    `json:"threadId"`
+		}
+		json.Unmarshal(body, &stopped)
+		// requests wait for the responses read by the calling goroutine
+		go func() {
+			file, row, vars, err := s.inspect(stopped.ThreadID)
+			postFunc(func() {
+				if err != nil {
+					a.showError(err.Error())
+					return
+				}
+				s.threadID, s.reason = stopped.ThreadID, stopped.Reason
+				s.stopFile, s.stopRow, s.vars = file, row, vars
+				a.updateDebugTab(s)
+				go func() { a.cmdCh <- ">debug show" }()
+			})
+		}()
+	case "output":
+		var output struct {
+			Output string `json:"output"`
+		}
+		json.Unmarshal(body, &output)
+		postFunc(func() {
+			s.output.WriteString(output.Output)
+			a.updateDebugTab(s)
+		})
+	case "terminated":
+		postFunc(func() { a.endDebug(s, "The program exited") })
+	}
+}
+
+// inspect returns the location and variables of the top frame of the thread.
+func (s *debugSession) inspect(threadID int) (file string, row int, vars string, err error) {
+	var trace struct {
+		StackFrames []struct {
+			ID     int `json:"id"`
+			Line   int `json:"line"`
+			Source struct {
+				Path string `json:"path"`
+			} `json:"source"`
+		} `json:"stackFrames"`
+	}
+	err = s.client.request("stackTrace", map[string]any{"threadId": threadID, "levels": 1}, &trace)
+	if err != nil {
+		return "", 0, "", err
+	}
+	if len(trace.StackFrames) == 0 {
+		return "", 0, "", errors.New("no stack frame")
+	}
+	frame := trace.StackFrames[0]
+
+	var scopes struct {
+		Scopes []struct {
+			Name               string `json:"name"`
+			VariablesReference int    `json:"variablesReference"`
+			Expensive          bool   `json:"expensive"`
+		} `json:"scopes"`
+	}
+	if err := s.client.request("scopes", map[string]any{"frameId": frame.ID}, &scopes); err != nil {
+		return "", 0, "", err
+	}
+	var b strings.Builder
+	for _, scope := range scopes.Scopes {
+		if scope.Expensive {
+			continue
+		}
+		var variables struct {
+			Variables []struct {
+				Name  string `json:"name"`
+				Type  string `json:"type"`
+				Value string `json:"value"`
+			} `json:"variables"`
+		}
+		err := s.client.request("variables", map[string]any{"variablesReference": scope.VariablesReference}, &variables)
+		if err != nil {
+			return "", 0, "", err
+		}
+		fmt.Fprintf(&b, "%s:\n", scope.Name)
+		for _, v := range variables.Variables {
+			fmt.Fprintf(&b, "  %s %s = %s\n", v.Name, v.Type, v.Value)
+		}
+	}
+	return frame.Source.Path, frame.Line - 1, b.String(), nil
+}
+
+// setBreakpoints replaces the breakpoints of the file with the rows.
+func (s *debugSession) setBreakpoints(file string, rows []int) error {
+	bps := make([]map[string]int, len(rows))
+	for i, row := range rows {
+		bps[i] = map[string]int{"line": row + 1}
+	}
+	args := map[string]any{"source": map[string]string{"path": file}, "breakpoints": bps}
+	return s.client.request("setBreakpoints", args, nil)
+}
+
+// stop ends the debug adapter and the program.
+func (s *debugSession) stop() {
+	if s.client != nil {
+		s.client.request("disconnect", map[string]any{"terminateDebuggee": true}, nil)
+		s.client.close()
+	}
+	if s.cmd.Process != nil {
+		s.cmd.Process.Kill()
+		s.cmd.Wait()
+	}
+}
+
+// kill kills the debug adapter without waiting.
+func (s *debugSession) kill() {
+	if s.cmd.Process != nil {
+		s.cmd.Process.Kill()
+	}
+}
+
+// endDebug clears the session if it is still the current one.
+func (a *App) endDebug(s *debugSession, msg string) {
+	if a.s.debug != s {
+		return
+	}
+	a.s.debug = nil
+	s.stopRow = -1
+	a.updateDebugTab(s)
+	a.drawEditor()
+	a.showMessage(msg)
+	go s.stop()
+}
+
+// breakpoints returns the rows of breakpoints by absolute file path.
+func (st *State) breakpoints() map[string][]int {
+	bps := make(map[string][]int)
+	for _, t := range st.tabs {
+		if t.filename == "" || len(t.annotations[breakpointOwner]) == 0 {
+			continue
+		}
+		path, err := filepath.Abs(t.filename)
+		if err != nil {
+			continue
+		}
+		bps[path] = slices.Sorted(maps.Keys(t.annotations[breakpointOwner]))
+	}
+	return bps
+}
+
+// toggleBreakpoint sets or clears the breakpoint at the row of current tab,
+// updating the debug session if any.
+func (a *App) toggleBreakpoint(row int) {
+	if _, ok := a.s.annotations[breakpointOwner][row]; ok {
+		a.s.unannotate(breakpointOwner, row)
+	} else {
+		a.s.annotate(breakpointOwner, row, gutterMarker{char: '●', style: styleBreakpoint})
+	}
+	a.drawEditor()
+	s := a.s.debug
+	if s == nil || a.s.filename == "" {
+		return
+	}
+	path, err := filepath.Abs(a.s.filename)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	rows := slices.Sorted(maps.Keys(a.s.annotations[breakpointOwner]))
+	go func() {
+		if err := s.setBreakpoints(path, rows); err != nil {
+			postFunc(func() { a.showError(err.Error()) })
+		}
+	}()
+}
+
+// debugStep resumes the stopped program, command is continue, next, stepIn or stepOut.
+func (a *App) debugStep(command string) {
+	s := a.s.debug
+	if s == nil {
+		a.showError("Not debugging, >debug main or >debug test to start")
+		return
+	}
+	if s.stopRow < 0 {
+		a.showMessage("The program is running")
+		return
+	}
+	s.stopRow = -1
+	a.drawEditor()
+	if err := s.client.request(command, map[string]any{"threadId": s.threadID}, nil); err != nil {
+		a.showError(err.Error())
+	}
+}
+
+// showDebugStop goes to the line where the program stops.
+// It runs in the command loop since it may open a file.
+func (a *App) showDebugStop() {
+	s := a.s.debug
+	if s == nil || s.stopRow < 0 {
+		return
+	}
+	if !a.s.isFile(s.stopFile) {
+		if i := a.s.findFile(s.stopFile); i >= 0 {
+			a.s.switchTab(i)
+		} else {
+			a.handleCommand(">open " + s.stopFile)
+			if !a.s.isFile(s.stopFile) {
+				return
+			}
+		}
+		a.draw()
+	}
+	a.s.focus = focusEditor
+	a.jump(min(s.stopRow, a.s.lines.Len()-1), 0)
+	a.drawEditor()
+	a.showMessage(fmt.Sprintf("Stopped at %s:%d (%s)", filepath.Base(s.stopFile), s.stopRow+1, s.reason))
+	a.syncCursor()
+}
+
+// isDebugLine reports whether the program stops at the row of current tab.
+func (st *State) isDebugLine(row int) bool {
+	s := st.debug
+	return s != nil && s.stopRow == row && st.isFile(s.stopFile)
+}
+
+// debugText returns the stop location, variables and output of the session.
+func (s *debugSession) debugText() string {
+	var b strings.Builder
+	if s.stopRow >= 0 {
+		fmt.Fprintf(&b, "Stopped at %s:%d (%s)\n\n%s\n", s.stopFile, s.stopRow+1, s.reason, s.vars)
+	} else {
+		b.WriteString("Running\n\n")
+	}
+	b.WriteString("Output:\n")
+	b.WriteString(s.output.String())
+	return b.String()
+}
+
+// updateDebugTab refreshes the debug tab if it is open.
+func (a *App) updateDebugTab(s *debugSession) {
+	a.updateBuffer(debugTitle, s.debugText(), false)
+}
+
+// debugCommand runs the ">debug" command with the argument.
+func (a *App) debugCommand(arg string) {
+	switch arg {
+	case "", "main", "test":
+		a.startDebug(cmp.Or(arg, "main"))
+	case "stop":
+		if a.s.debug == nil {
+			a.showError("Not debugging")
+			return
+		}
+		a.endDebug(a.s.debug, "Debugging stopped")
+	case "continue", "next", "step", "stepout":
+		command := map[string]string{"continue": "continue", "next": "next", "step": "stepIn", "stepout": "stepOut"}[arg]
+		a.debugStep(command)
+	case "show":
+		a.showDebugStop()
+	case "vars":
+		if a.s.debug == nil {
+			a.showError("Not debugging")
+			return
+		}
+		a.updateBuffer(debugTitle, a.s.debug.debugText(), true)
+	default:
+		a.showError("Unknown debug command: " + arg)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// declSpan is the rows of a top-level declaration with its doc comment, the end included.
+type declSpan struct {
+	start, end int
+}
+
+// declSpans returns the rows of the top-level declarations of the Go source other than
+// the imports, which stay before the rest, in order.
+func declSpans(src string) ([]declSpan, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	var spans []declSpan
+	for _, d := range f.Decls {
+		start := d.Pos()
+		switch d := d.(type) {
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		}
+		spans = append(spans, declSpan{fset.Position(start).Line - 1, fset.Position(d.End()).Line - 1})
+	}
+	return spans, nil
+}
+
+// swapDecl returns the lines with the declaration at the row swapped with the previous
+// (dir < 0) or next (dir > 0) one, keeping the lines between them in place. It returns
+// the first row of the lines changed, the new lines from it and where the row of the
+// declaration goes.
+func swapDecl(lines []string, spans []declSpan, row, dir int) (first int, swapped []string, to int, err error) {
+	i := -1
+	for j, s := range spans {
+		if s.start <= row && row <= s.end {
+			i = j
+		}
+	}
+	if i < 0 {
+		return 0, nil, 0, errors.New("no declaration at the cursor")
+	}
+	j := i + dir
+	if j < 0 || j >= len(spans) {
+		return 0, nil, 0, errors.New("no declaration to move across")
+	}
+	a, b := spans[min(i, j)], spans[max(i, j)]
+	if a.end >= b.start {
+		return 0, nil, 0, errors.New("the declarations share a line")
+	}
+	swapped = append(swapped, lines[b.start:b.end+1]...)
+	swapped = append(swapped, lines[a.end+1:b.start]...)
+	swapped = append(swapped, lines[a.start:a.end+1]...)
+	if dir > 0 {
+		return a.start, swapped, row + b.end - a.end, nil
+	}
+	return a.start, swapped, row - b.start + a.start, nil
+}
+
+// moveDecl moves the top-level declaration at the cursor with its doc comment before
+// the previous (dir < 0) or after the next (dir > 0) declaration, as a single change.
+func (a *App) moveDecl(dir int) {
+	a.s.focus = focusEditor
+	if !strings.HasSuffix(a.s.filename, ".go") {
+		a.showMessage("Not a Go file")
+		return
+	}
+	if !a.editable() {
+		return
+	}
+	spans, err := declSpans(a.s.text())
+	if err != nil {
+		a.showError("Cannot parse the file: " + err.Error())
+		return
+	}
+	var lines []string
+	for e := a.s.lines.Front(); e != nil; e = e.Next() {
+		lines = append(lines, string(e.Value))
+	}
+	first, swapped, row, err := swapDecl(lines, spans, a.s.row, dir)
+	if err != nil {
+		a.showMessage("Cannot move the declaration: " + err.Error())
+		return
+	}
+	col := a.s.col
+	a.unselect()
+	a.replaceLines(first, lines[first:first+len(swapped)], swapped)
+	a.jump(row, min(col, len(a.s.line(row).Value)))
+	a.drawEditor()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSwapDecl(t *testing.T) {
+	src := `package p
+
+import "fmt"
+
+// A is a.
+func A() {
+	fmt.Println()
+}
+
+// floating comment
+
+var b = 1
+
+// C is c.
+type C int
+`
+	spans, err := declSpans(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []declSpan{{4, 7}, {11, 11}, {13, 14}}
+	if len(spans) != len(want) {
+		t.Fatalf("spans = %v, want %v", spans, want)
+	}
+	for i := range want {
+		if spans[i] != want[i] {
+			t.Errorf("span %d = %v, want %v", i, spans[i], want[i])
+		}
+	}
+
+	lines := strings.Split(src, "\n")
+	first, swapped, to, err := swapDecl(lines, spans, 6, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(append(append(lines[:first:first], swapped...), lines[first+len(swapped):]...), "\n")
+	wantSrc := `package p
+
+import "fmt"
+
+var b = 1
+
+// floating comment
+
+// A is a.
+func A() {
+	fmt.Println()
+}
+
+// C is c.
+type C int
+`
+	if got != wantSrc {
+		t.Errorf("moved down:\n%s\nwant:\n%s", got, wantSrc)
+	}
+	if to != 10 {
+		t.Errorf("row moved to %d, want 10", to)
+	}
+
+	first, swapped, to, err = swapDecl(lines, spans, 13, -1)
+	if err != nil || first != 11 || to != 11 || strings.Join(swapped, "\n") != "// C is c.\ntype C int\n\nvar b = 1" {
+		t.Errorf("moved up = %d, %q, %d, %v", first, swapped, to, err)
+	}
+
+	for _, tt := range []struct{ row, dir int }{{2, 1}, {9, 1}, {5, -1}, {14, 1}} {
+		if _, _, _, err := swapDecl(lines, spans, tt.row, tt.dir); err == nil {
+			t.Errorf("swapDecl at row %d by %d succeeds, want an error", tt.row, tt.dir)
+		}
+	}
+}
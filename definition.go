@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// qualifierAt returns the identifier before the dot before the start of the word in
+// the line, like "fmt" of "fmt.Println", empty if the word is not qualified.
+func qualifierAt(line []rune, start int) string {
+	if start < 2 || start > len(line) || line[start-1] != '.' {
+		return ""
+	}
+	i := start - 1
+	for i > 0 && isIdentRune(line[i-1]) {
+		i--
+	}
+	return string(line[i : start-1])
+}
+
+// importDir returns the directory of the package the Go source imports by the name,
+// either the name given to the import or the last element of its path.
+func importDir(filename string, src []byte, name string) (string, bool) {
+	// the imports parse even if the rest of the source has errors
+	f, _ := parser.ParseFile(token.NewFileSet(), filename, src, parser.ImportsOnly)
+	if f == nil {
+		return "", false
+	}
+	for _, spec := range f.Imports {
+		path, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		if spec.Name != nil && spec.Name.Name != name || spec.Name == nil && path[strings.LastIndexByte(path, '/')+1:] != name {
+			continue
+		}
+		ctxt := build.Default
+		ctxt.Dir = filepath.Dir(filename) // resolves in the module of the file
+		pkg, err := ctxt.Import(path, ctxt.Dir, build.FindOnly)
+		if err != nil || pkg.Dir == "" {
+			return "", false
+		}
+		return pkg.Dir, true
+	}
+	return "", false
+}
+
+// packageSymbols returns the symbols of the name in the Go files of the directory other
+// than the file skipped, test files only if tests is true. Symbols of the package
+// only leave out methods and fields, as for a name qualified by the package.
+func packageSymbols(dir, name, skip string, tests, packageOnly bool) []Symbol {
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	var found []Symbol
+	for _, path := range paths {
+		if path == skip || !tests && strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		index, err := ParseSymbol(path, nil)
+		if err != nil {
+			continue
+		}
+		for _, sym := range index[name] {
+			if sym.Kind == SymbolImport || packageOnly && sym.Receiver != "" {
+				continue
+			}
+			found = append(found, sym)
+		}
+	}
+	return found
+}
+
+// definitionElsewhere goes to the definition of the word under the cursor in the package
+// qualifying it, or if not defined in the file, in the other files of its package.
+// It reports false if it has nothing to go to.
+func (a *App) definitionElsewhere(word string) bool {
+	filename, err := filepath.Abs(a.s.filename)
+	if err != nil {
+		return false
+	}
+	start, _ := a.s.wordRange()
+	var found []Symbol
+	if q := qualifierAt(a.s.line(a.s.row).Value, start); q != "" {
+		if dir, ok := importDir(filename, []byte(a.s.text()), q); ok {
+			found = packageSymbols(dir, word, "", false, true)
+			if len(found) == 0 {
+				a.showMessage(fmt.Sprintf("No definition of %s.%s found", q, word))
+				return true
+			}
+		}
+	}
+	if found == nil {
+		if _, ok := a.s.symbols[word]; ok {
+			return false
+		}
+		found = packageSymbols(filepath.Dir(filename), word, filename, strings.HasSuffix(filename, "_test.go"), false)
+	}
+	switch len(found) {
+	case 0:
+		return false
+	case 1:
+		a.goToSymbolIn(found[0])
+	default:
+		items := make([]resultItem, len(found))
+		for i, sym := range found {
+			group := sym.File
+			if rel, err := filepath.Rel(filepath.Dir(filename), sym.File); err == nil {
+				group = rel
+			}
+			items[i] = resultItem{file: sym.File, group: group, row: sym.Line - 1, col: sym.Column - 1, text: string(sym.Kind) + " " + symbolName(sym)}
+		}
+		a.showResults("definitions", fmt.Sprintf("definitions of %s", word), items)
+	}
+	return true
+}
+
+// goToSymbolIn opens the file of the symbol and goes to it.
+func (a *App) goToSymbolIn(sym Symbol) {
+	a.recordPositon(a.s.row, a.s.col)
+	if !a.switchToFile(sym.File) {
+		return
+	}
+	a.s.focus = focusEditor
+	a.goToSymbol(sym)
+	a.draw()
+}
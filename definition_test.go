@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestQualifierAt(t *testing.T) {
+	tests := []struct {
+		line  string
+		start int
+		want  string
+	}{
+		{"fmt.Println(x)", 4, "fmt"},
+		{"\tx := strings.Cut(s)", 14, "strings"},
+		{"Println(x)", 0, ""},
+		{"a.b.c", 4, "b"},
+		{"f().x", 4, ""},
+	}
+	for _, tt := range tests {
+		if got := qualifierAt([]rune(tt.line), tt.start); got != tt.want {
+			t.Errorf("qualifierAt(%q, %d) = %q, want %q", tt.line, tt.start, got, tt.want)
+		}
+	}
+}
+
+func TestPackageSymbols(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod":       "module example.com/m\n\ngo 1.21\n",
+		"a.go":         "package m\n\nimport \"example.com/m/sub\"\n\nfunc A() { sub.B() }\n",
+		"b.go":         "package m\n\ntype T struct{ A int }\n\nfunc (T) A2() {}\n",
+		"b_test.go":    "package m\n\nfunc A() {}\n",
+		"sub/sub.go":   "package sub\n\n// B is b.\nfunc B() {}\n\ntype U struct{ B int }\n",
+		"sub/other.go": "package sub\n\nfunc (U) C() {}\n",
+	}
+	for name, text := range files {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got := packageSymbols(dir, "A", filepath.Join(dir, "a.go"), false, false)
+	if len(got) != 1 || got[0].Kind != SymbolField || got[0].File != filepath.Join(dir, "b.go") {
+		t.Errorf("symbols of A = %+v, want the field in b.go", got)
+	}
+	if got := packageSymbols(dir, "A", filepath.Join(dir, "a.go"), true, false); len(got) != 2 {
+		t.Errorf("symbols of A with tests = %+v, want 2", got)
+	}
+
+	src, _ := os.ReadFile(filepath.Join(dir, "a.go"))
+	sub, ok := importDir(filepath.Join(dir, "a.go"), src, "sub")
+	if !ok || sub != filepath.Join(dir, "sub") {
+		t.Fatalf("importDir of sub = %q, %v", sub, ok)
+	}
+	got = packageSymbols(sub, "B", "", false, true)
+	if len(got) != 1 || got[0].Kind != SymbolFunc || got[0].Line != 4 {
+		t.Errorf("symbols of sub.B = %+v, want the func at line 4", got)
+	}
+	if _, ok := importDir(filepath.Join(dir, "a.go"), src, "fmt"); ok {
+		t.Error("importDir of fmt not imported reports found")
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"slices"
+	"unicode"
+)
+
+const (
+	diffEqual = iota
+	diffDelete
+	diffInsert
+)
+
+// diffOp is an operation turning a into b, on a[i] for equal and delete, on b[j] for insert.
+type diffOp struct {
+	kind int
+	i, j int
+}
+
+// diff returns the shortest edit script from a to b by the Myers algorithm.
+func diff[T comparable](a, b []T) []diffOp {
+	n, m := len(a), len(b)
+	off := n + m + 1
+	v := make([]int, 2*off+1)
+	var trace [][]int
+	for d := 0; d <= n+m; d++ {
+		trace = append(trace, slices.Clone(v))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[off+k-1] < v[off+k+1] {
+				x = v[off+k+1] // down, insert
+			} else {
+				x = v[off+k-1] + 1 // right, delete
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return backtrackDiff(trace, off, n, m)
+			}
+		}
+	}
+	return nil
+}
+
+// backtrackDiff walks the furthest points of each round back from the end.
+func backtrackDiff(trace [][]int, off, x, y int) []diffOp {
+	var ops []diffOp
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+		prevK := k - 1
+		if k == -d || k != d && v[off+k-1] < v[off+k+1] {
+			prevK = k + 1
+		}
+		prevX := v[off+prevK]
+		prevY := prevX - prevK
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, diffOp{diffEqual, x, y})
+		}
+		if x == prevX {
+			y--
+			ops = append(ops, diffOp{diffInsert, x, y})
+		} else {
+			x--
+			ops = append(ops, diffOp{diffDelete, x, y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		ops = append(ops, diffOp{diffEqual, x, y})
+	}
+	slices.Reverse(ops)
+	return ops
+}
+
+// hunk is a run of differing lines, a[aStart:aEnd] replaced by b[bStart:bEnd].
+type hunk struct {
+	aStart, aEnd int
+	bStart, bEnd int
+}
+
+// diffHunks groups the operations into hunks.
+func diffHunks(ops []diffOp) []hunk {
+	var hunks []hunk
+	var h *hunk
+	for _, op := range ops {
+		if op.kind == diffEqual {
+			h = nil
+			continue
+		}
+		if h == nil {
+			hunks = append(hunks, hunk{aStart: op.i, aEnd: op.i, bStart: op.j, bEnd: op.j})
+			h = &hunks[len(hunks)-1]
+		}
+		if op.kind == diffDelete {
+			h.aEnd = op.i + 1
+		} else {
+			h.bEnd = op.j + 1
+		}
+	}
+	return hunks
+}
+
+// splitWords splits the line into words, runs of spaces and single punctuation runes.
+func splitWords(line []rune) []string {
+	var words []string
+	for i := 0; i < len(line); {
+		j := i + 1
+		switch {
+		case isIdentRune(line[i]):
+			for j < len(line) && isIdentRune(line[j]) {
+				j++
+			}
+		case unicode.IsSpace(line[i]):
+			for j < len(line) && unicode.IsSpace(line[j]) {
+				j++
+			}
+		}
+		words = append(words, string(line[i:j]))
+		i = j
+	}
+	return words
+}
+
+// wordChanges returns the rune ranges of the words of a deleted and b inserted
+// between the two versions of a line.
+func wordChanges(a, b []rune) (aRanges, bRanges [][2]int) {
+	aWords, bWords := splitWords(a), splitWords(b)
+	aCols, bCols := wordColumns(aWords), wordColumns(bWords)
+	for _, op := range diff(aWords, bWords) {
+		switch op.kind {
+		case diffDelete:
+			aRanges = appendRange(aRanges, aCols[op.i], aCols[op.i+1])
+		case diffInsert:
+			bRanges = appendRange(bRanges, bCols[op.j], bCols[op.j+1])
+		}
+	}
+	return aRanges, bRanges
+}
+
+// wordColumns returns the rune column where each word starts, and the end.
+func wordColumns(words []string) []int {
+	cols := make([]int, 0, len(words)+1)
+	col := 0
+	for _, w := range words {
+		cols = append(cols, col)
+		col += len([]rune(w))
+	}
+	return append(cols, col)
+}
+
+// appendRange appends [start, end), merging it into the last range if adjacent.
+func appendRange(ranges [][2]int, start, end int) [][2]int {
+	if n := len(ranges); n > 0 && ranges[n-1][1] == start {
+		ranges[n-1][1] = end
+		return ranges
+	}
+	return append(ranges, [2]int{start, end})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// applyDiff rebuilds b from a and the operations.
+func applyDiff(a, b []string, ops []diffOp) []string {
+	var out []string
+	for _, op := range ops {
+		switch op.kind {
+		case diffEqual:
+			out = append(out, a[op.i])
+		case diffInsert:
+			out = append(out, b[op.j])
+		}
+	}
+	return out
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		edits int
+	}{
+		{"", "", 0},
+		{"a b c", "a b c", 0},
+		{"", "a b", 2},
+		{"a b", "", 2},
+		{"a b c a b b a", "c b a b a c", 5},
+		{"x a y", "x b y", 2},
+	}
+	for _, tt := range tests {
+		a, b := strings.Fields(tt.a), strings.Fields(tt.b)
+		ops := diff(a, b)
+		edits := 0
+		for _, op := range ops {
+			if op.kind != diffEqual {
+				edits++
+			}
+		}
+		if got := applyDiff(a, b, ops); strings.Join(got, " ") != tt.b || edits != tt.edits {
+			t.Errorf("diff(%q, %q) gives %q in %d edits, want %d", tt.a, tt.b, got, edits, tt.edits)
+		}
+	}
+}
+
+func TestDiffHunks(t *testing.T) {
+	a := strings.Fields("1 2 3 4 5 6")
+	b := strings.Fields("1 x 3 4 6 7")
+	got := diffHunks(diff(a, b))
+	want := []hunk{{1, 2, 1, 2}, {4, 5, 4, 4}, {6, 6, 5, 6}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("diffHunks = %v, want %v", got, want)
+	}
+}
+
+func TestWordChanges(t *testing.T) {
+	aRanges, bRanges := wordChanges([]rune("x := foo(a, b)"), []rune("x := bar(a, c)"))
+	if !reflect.DeepEqual(aRanges, [][2]int{{5, 8}, {12, 13}}) || !reflect.DeepEqual(bRanges, [][2]int{{5, 8}, {12, 13}}) {
+		t.Errorf("wordChanges = %v, %v", aRanges, bRanges)
+	}
+}
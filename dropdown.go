@@ -0,0 +1,53 @@
+package main
+
+// maxDropdownRows is the most options listed at once in the dropdown above the status bar.
+const maxDropdownRows = 10
+
+var styleDropdown = styleBase.Reverse(true)
+
+// dropdownSize returns the rows of the dropdown listing the options above the status bar,
+// 0 if the options fit in the status bar or the console is not focused.
+func (a *App) dropdownSize() int {
+	if a.s.focus != focusConsole || len(a.s.options) == 0 {
+		return 0
+	}
+	if _, end := optionWindow(a.optionWidths(), 0, -1, a.optionsWidth()); end == len(a.s.options) {
+		return 0
+	}
+	return max(0, min(len(a.s.options), maxDropdownRows, len(a.editor)-1))
+}
+
+// drawDropdown draws the options from optionTop over the last rows of the editor.
+func (a *App) drawDropdown() {
+	first := len(a.editor) - a.dropdownRows
+	for r := range a.dropdownRows {
+		v := *a.editor[first+r]
+		v.style = styleDropdown
+		i := a.s.optionTop + r
+		if i >= len(a.s.options) {
+			v.draw(nil)
+			continue
+		}
+		v.drawTexts(append([]textStyle{{text: []rune{' '}}}, a.optionItem(i)...))
+	}
+}
+
+// dropdownAt returns the index of the option of the dropdown at the screen row, -1 if none.
+func (a *App) dropdownAt(y int) int {
+	if a.dropdownRows == 0 {
+		return -1
+	}
+	first := a.editor[len(a.editor)-a.dropdownRows].y
+	if i := a.s.optionTop + y - first; y >= first && y <= a.editor[len(a.editor)-1].y && i < len(a.s.options) {
+		return i
+	}
+	return -1
+}
+
+// closeDropdown redraws the editor under the dropdown once the console is left.
+func (a *App) closeDropdown() {
+	if a.dropdownRows > 0 {
+		a.dropdownRows = 0
+		a.drawEditor()
+	}
+}
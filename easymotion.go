@@ -0,0 +1,120 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// hintLetters are the letters of jump labels, easy-to-reach keys first.
+const hintLetters = "asdfghjklqwertyuiopzxcvbnm"
+
+// jumpHint labels a position the cursor can jump to.
+type jumpHint struct {
+	row   int
+	col   int
+	label string
+}
+
+var styleHint = styleBase.Foreground(tcell.ColorWhite).Background(tcell.ColorOrangeRed).Bold(true)
+
+// hintLabels returns n labels, of one letter if they are enough, otherwise of two letters.
+func hintLabels(n int) []string {
+	labels := make([]string, 0, n)
+	if n <= len(hintLetters) {
+		for _, r := range hintLetters[:n] {
+			labels = append(labels, string(r))
+		}
+		return labels
+	}
+	for _, r1 := range hintLetters {
+		for _, r2 := range hintLetters {
+			if len(labels) == n {
+				return labels
+			}
+			labels = append(labels, string(r1)+string(r2))
+		}
+	}
+	return labels
+}
+
+// startHints labels the starts of words visible in the editor,
+// the cursor jumps to the one whose label is typed.
+func (a *App) startHints() {
+	ft := fileTypeOf(a.s.filename)
+	width := a.editor[0].w - a.s.lineNumLen()
+	var hints []jumpHint
+	e := a.s.line(a.s.top)
+	for row := a.s.top; e != nil && row < a.s.top+len(a.editor); row++ {
+		line := e.Value
+		for col, r := range line {
+			if !ft.isWordChar(r) || (col > 0 && ft.isWordChar(line[col-1])) {
+				continue
+			}
+			if x := columnToVisual(line, col) - a.s.left; x < 0 || x >= width {
+				continue
+			}
+			hints = append(hints, jumpHint{row: row, col: col})
+		}
+		e = e.Next()
+	}
+	if len(hints) == 0 {
+		a.showMessage("No word to jump to")
+		return
+	}
+	hints = hints[:min(len(hints), len(hintLetters)*len(hintLetters))]
+	for i, label := range hintLabels(len(hints)) {
+		hints[i].label = label
+	}
+	a.s.hints = hints
+	a.s.hintTyped = ""
+	a.s.focus = focusEditor
+	a.drawEditor()
+	a.status.draw([]rune("Jump to label, esc to cancel"))
+}
+
+// hintEvent narrows the labels by the typed key, and jumps once a label is complete.
+func (a *App) hintEvent(ev *tcell.EventKey) {
+	defer func() {
+		a.drawEditor()
+		a.syncCursor()
+	}()
+	if ev.Key() != tcell.KeyRune {
+		a.s.hints = nil
+		return
+	}
+	a.s.hintTyped += string(ev.Rune())
+	var matched []jumpHint
+	for _, h := range a.s.hints {
+		if strings.HasPrefix(h.label, a.s.hintTyped) {
+			matched = append(matched, h)
+		}
+	}
+	switch {
+	case len(matched) == 0:
+		a.s.hints = nil
+		a.showMessage("No such label")
+	case len(matched) == 1 && matched[0].label == a.s.hintTyped:
+		a.s.hints = nil
+		a.recordPositon(a.s.row, a.s.col)
+		a.jump(matched[0].row, matched[0].col)
+	default:
+		a.s.hints = matched
+	}
+}
+
+// overlayHints draws the rest of untyped labels over the styled texts of the row.
+func (a *App) overlayHints(texts []textStyle, row int, line []rune) []textStyle {
+	for _, h := range a.s.hints {
+		if h.row != row {
+			continue
+		}
+		start := columnToVisual(line, h.col) - a.s.left
+		label := []rune(strings.TrimPrefix(h.label, a.s.hintTyped))
+		texts = highlightRange(texts, start, start+len(label), tcell.ColorOrangeRed)
+		for i, r := range label {
+			texts[start+i] = textStyle{text: []rune{r}, style: styleHint}
+		}
+	}
+	return texts
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// parseAddress parses a line address at the start of s, such as "10", ".", "$", "+5" or ".-2",
+// returning the row and the rest of s.
+func (st *State) parseAddress(s string) (int, string, error) {
+	row := st.row
+	switch {
+	case s == "":
+		return 0, s, errors.New("missing line number")
+	case s[0] == '.':
+		s = s[1:]
+	case s[0] == '$':
+		row = st.lines.Len() - 1
+		s = s[1:]
+	case s[0] >= '0' && s[0] <= '9':
+		i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+		if i < 0 {
+			i = len(s)
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, s, err
+		}
+		row = n - 1
+		s = s[i:]
+	case s[0] != '+' && s[0] != '-':
+		return 0, s, fmt.Errorf("invalid line number %q", s)
+	}
+	// offsets
+	for len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		sign := 1
+		if s[0] == '-' {
+			sign = -1
+		}
+		s = s[1:]
+		i := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+		if i < 0 {
+			i = len(s)
+		}
+		n := 1
+		if i > 0 {
+			n, _ = strconv.Atoi(s[:i])
+		}
+		row += sign * n
+		s = s[i:]
+	}
+	if row < 0 || row >= st.lines.Len() {
+		return 0, s, errors.New("line number out of range")
+	}
+	return row, s, nil
+}
+
+// parseGoto parses the position of ":" like "10", "10:5" as in compiler output, "+5" or "-3"
+// relative to the cursor, returning the row and column, -1 if not given to keep the column
+// of the cursor. It returns false if s is not a position.
+func (st *State) parseGoto(s string) (row, col int, ok bool) {
+	addr, colText, hasCol := strings.Cut(strings.TrimSuffix(s, ":"), ":")
+	if addr == "" || !strings.ContainsRune("0123456789+-", rune(addr[0])) {
+		return 0, 0, false
+	}
+	row, rest, err := st.parseAddress(addr)
+	if err != nil || rest != "" {
+		return 0, 0, false
+	}
+	if !hasCol {
+		return row, -1, true
+	}
+	n, err := strconv.Atoi(colText)
+	if err != nil || n < 1 {
+		return 0, 0, false
+	}
+	return row, min(n-1, len(st.line(row).Value)), true
+}
+
+// parseRange parses a line range like "10,20", ".,+5", "%" for all lines or a single address,
+// returning the rows and the rest of s.
+func (st *State) parseRange(s string) (start, end int, rest string, err error) {
+	if strings.HasPrefix(s, "%") {
+		return 0, st.lines.Len() - 1, s[1:], nil
+	}
+	start, s, err = st.parseAddress(s)
+	if err != nil {
+		return 0, 0, s, err
+	}
+	end = start
+	if strings.HasPrefix(s, ",") {
+		end, s, err = st.parseAddress(s[1:])
+		if err != nil {
+			return 0, 0, s, err
+		}
+	}
+	if end < start {
+		start, end = end, start
+	}
+	return start, end, s, nil
+}
+
+// rangeCommand runs the operation on the line range, like ":10,20 sort" or ":.,+5 del".
+// Without an operation, it goes to the start of the range.
+func (a *App) rangeCommand(cmd string) {
+	start, end, op, err := a.s.parseRange(cmd)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	op = strings.TrimSpace(op)
+	lines := strings.Split(a.s.textIn(start, 0, end, len(a.s.line(end).Value)), "\n")
+	switch op {
+	case "d", "del", "delete", "sort", "uniq":
+		if !a.editable() {
+			return
+		}
+	}
+	switch op {
+	case "":
+		a.recordPositon(a.s.row, a.s.col)
+		a.jump(start, 0)
+		return
+	case "d", "del", "delete":
+		startRow, startCol, endRow, endCol := start, 0, end+1, 0
+		if end == a.s.lines.Len()-1 {
+			// take the line break before
+			endRow, endCol = end, len(a.s.line(end).Value)
+			if start > 0 {
+				startRow, startCol = start-1, len(a.s.line(start-1).Value)
+			}
+		}
+		deleted := a.s.deleteRange(startRow, startCol, endRow, endCol)
+		a.s.recordChange(Change{row: startRow, col: startCol, oldText: deleted, kind: editDelete})
+		a.jump(min(start, a.s.lines.Len()-1), 0)
+		a.showMessage(fmt.Sprintf("%d lines deleted", len(lines)))
+	case "y", "yank":
+		text := strings.Join(lines, "\n") + "\n"
+		a.s.clipboard = text
+		a.s.vimLinewise = true
+		screen.SetClipboard([]byte(text))
+		a.showMessage(fmt.Sprintf("%d lines yanked", len(lines)))
+		return
+	case "sort":
+		sorted := slices.Clone(lines)
+		slices.Sort(sorted)
+		a.replaceLines(start, lines, sorted)
+	case "uniq":
+		a.replaceLines(start, lines, slices.Compact(slices.Clone(lines)))
+	default:
+		a.showError("Unknown range operation: " + op)
+		return
+	}
+	// the selection and the cursors no longer point at the text they selected
+	a.s.selection = nil
+	a.s.lineSelect = false
+	a.s.cursors = nil
+	a.drawEditor()
+}
+
+// replaceLines replaces the lines from the row with the new lines as a single change.
+func (a *App) replaceLines(row int, lines, newLines []string) {
+	oldText := strings.Join(lines, "\n")
+	newText := strings.Join(newLines, "\n")
+	if oldText == newText {
+		return
+	}
+	last := a.s.line(row + len(lines) - 1).Value
+	a.s.deleteRange(row, 0, row+len(lines)-1, len(last))
+	a.s.insertText([]rune(newText), row, 0)
+	a.s.recordChange(Change{row: row, col: 0, oldText: oldText, newText: newText, kind: editReplace})
+	a.jump(row, 0)
+}
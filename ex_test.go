@@ -0,0 +1,88 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestParseRange(t *testing.T) {
+	st := &State{Tab: &Tab{}}
+	st.loadSource(strings.NewReader("1\n2\n3\n4\n5\n6\n7\n8\n9"))
+	st.row = 4
+	tests := []struct {
+		s          string
+		start, end int
+		rest       string
+	}{
+		{"3", 2, 2, ""},
+		{"2,4 sort", 1, 3, " sort"},
+		{".,+2 del", 4, 6, " del"},
+		{"-1,.", 3, 4, ""},
+		{".-2,$", 2, 9, ""},
+		{"%y", 0, 9, "y"},
+		{"6,2", 1, 5, ""},
+	}
+	for _, tt := range tests {
+		start, end, rest, err := st.parseRange(tt.s)
+		if err != nil {
+			t.Errorf("parseRange(%q) error: %v", tt.s, err)
+			continue
+		}
+		if start != tt.start || end != tt.end || rest != tt.rest {
+			t.Errorf("parseRange(%q) = %d, %d, %q, want %d, %d, %q", tt.s, start, end, rest, tt.start, tt.end, tt.rest)
+		}
+	}
+
+	for _, s := range []string{"", "x", "0", "100", ".+10"} {
+		if _, _, _, err := st.parseRange(s); err == nil {
+			t.Errorf("parseRange(%q) expected error", s)
+		}
+	}
+}
+
+func TestParseGoto(t *testing.T) {
+	st := &State{Tab: &Tab{}}
+	st.loadSource(strings.NewReader("one\ntwo\nthree\nfour\nfive"))
+	st.row = 2
+	tests := []struct {
+		s        string
+		row, col int
+		ok       bool
+	}{
+		{"2", 1, -1, true},
+		{"2:3", 1, 2, true},
+		{"4:2:", 3, 1, true},
+		{"1:100", 0, 3, true},
+		{"+2", 4, -1, true},
+		{"-1", 1, -1, true},
+		{"-2:2", 0, 1, true},
+		{"9", 0, 0, false},
+		{"2:0", 0, 0, false},
+		{"2,3 sort", 0, 0, false},
+		{"$", 0, 0, false},
+	}
+	for _, tt := range tests {
+		row, col, ok := st.parseGoto(tt.s)
+		if ok != tt.ok || ok && (row != tt.row || col != tt.col) {
+			t.Errorf("parseGoto(%q) = %d, %d, %v, want %d, %d, %v", tt.s, row, col, ok, tt.row, tt.col, tt.ok)
+		}
+	}
+}
+
+func TestRangeCommandDropsSelection(t *testing.T) {
+	for _, cmd := range []string{"%del", "3,$ del", "% sort", "% uniq"} {
+		a := newTestApp(t, "b\nb\na\nx\nx\n")
+		a.jump(1, 0)
+		a.selectLine()
+		a.extendLines(3)
+		a.s.cursors = []Selection{{startRow: 3, startCol: 0, endRow: 3, endCol: 1}, {startRow: 4, startCol: 0, endRow: 4, endCol: 1}}
+		a.rangeCommand(cmd)
+		if a.s.selected() != nil || a.s.selectingLines() || a.s.cursors != nil {
+			t.Errorf("%s: selection %v and cursors %v left", cmd, a.s.selection, a.s.cursors)
+		}
+		a.syncCursor()
+		a.editorEvent(tcell.NewEventKey(tcell.KeyRune, 'y', tcell.ModNone))
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"cmp"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"slices"
+	"strings"
+)
+
+// enclosingRanges returns the byte ranges of the Go syntax enclosing the range of the source,
+// from the innermost, each larger than the one before, up to the whole source. The insides
+// of blocks, parentheses of calls, composite literals and strings count too, and
+// declarations with their doc comments.
+func enclosingRanges(src []byte, start, end int) [][2]int {
+	fset := token.NewFileSet()
+	f, _ := parser.ParseFile(fset, "", src, parser.ParseComments|parser.SkipObjectResolution)
+	if f == nil {
+		return nil
+	}
+	file := fset.File(f.Pos())
+	var ranges [][2]int
+	add := func(from, to token.Pos) {
+		if !from.IsValid() || !to.IsValid() || int(from) < file.Base() || int(to) > file.Base()+file.Size() {
+			return
+		}
+		s, e := file.Offset(from), file.Offset(to)
+		if s <= start && end <= e && (s < start || end < e) {
+			ranges = append(ranges, [2]int{s, e})
+		}
+	}
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil {
+			return false
+		}
+		if _, ok := n.(*ast.File); !ok {
+			add(n.Pos(), n.End())
+		}
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			add(n.Lbrace+1, n.Rbrace)
+		case *ast.CallExpr:
+			add(n.Lparen+1, n.Rparen)
+		case *ast.CompositeLit:
+			add(n.Lbrace+1, n.Rbrace)
+		case *ast.FieldList:
+			if n.Opening.IsValid() {
+				add(n.Opening+1, n.Closing)
+			}
+		case *ast.BasicLit:
+			if n.Kind == token.STRING {
+				add(n.Pos()+1, n.End()-1)
+			}
+		case *ast.FuncDecl:
+			if n.Doc != nil {
+				add(n.Doc.Pos(), n.End())
+			}
+		case *ast.GenDecl:
+			if n.Doc != nil {
+				add(n.Doc.Pos(), n.End())
+			}
+			if n.Lparen.IsValid() {
+				add(n.Lparen+1, n.Rparen)
+			}
+		}
+		return true
+	})
+	if start > 0 || end < len(src) {
+		ranges = append(ranges, [2]int{0, len(src)})
+	}
+	slices.SortFunc(ranges, func(a, b [2]int) int {
+		return cmp.Or(cmp.Compare(a[1]-a[0], b[1]-b[0]), cmp.Compare(a[0], b[0]))
+	})
+	return slices.Compact(ranges)
+}
+
+// byteOffset returns the offset in bytes of the position in the text of the tab.
+func (t *Tab) byteOffset(row, col int) int {
+	off := 0
+	i := 0
+	for e := t.lines.Front(); e != nil && i < row; e = e.Next() {
+		off += len(string(e.Value)) + 1
+		i++
+	}
+	if e := t.line(row); e != nil {
+		off += len(string(e.Value[:min(col, len(e.Value))]))
+	}
+	return off
+}
+
+// expandSelection selects the smallest Go syntax enclosing the selection, or the cursor,
+// such as the identifier, the expression, the statement, the block and the function.
+// The selections before are kept for shrinkSelection.
+func (a *App) expandSelection() {
+	a.s.focus = focusEditor
+	if !strings.HasSuffix(a.s.filename, ".go") {
+		a.showMessage("Not a Go file")
+		return
+	}
+	cur := Selection{startRow: a.s.row, startCol: a.s.col, endRow: a.s.row, endCol: a.s.col}
+	if sel := a.s.selected(); sel != nil {
+		cur = *sel
+	}
+	if n := len(a.s.expandStack); n == 0 || a.s.expandStack[n-1] != cur {
+		a.s.expandStack = []Selection{cur}
+	}
+	start, end := a.s.byteOffset(cur.startRow, cur.startCol), a.s.byteOffset(cur.endRow, cur.endCol)
+	text := a.s.text()
+	ranges := enclosingRanges([]byte(text), start, end)
+	if len(ranges) == 0 {
+		a.showMessage("No enclosing syntax")
+		return
+	}
+	var next Selection
+	next.startRow, next.startCol = offsetPosition(text, ranges[0][0])
+	next.endRow, next.endCol = offsetPosition(text, ranges[0][1])
+	a.s.expandStack = append(a.s.expandStack, next)
+	a.selectRange(next)
+}
+
+// shrinkSelection goes back to the selection before the last expandSelection.
+func (a *App) shrinkSelection() {
+	a.s.focus = focusEditor
+	n := len(a.s.expandStack)
+	sel := a.s.selected()
+	if n < 2 || sel == nil || *sel != a.s.expandStack[n-1] {
+		a.s.expandStack = nil
+		a.showMessage("Nothing to shrink, expand the selection first")
+		return
+	}
+	a.s.expandStack = a.s.expandStack[:n-1]
+	a.selectRange(a.s.expandStack[n-2])
+}
+
+// selectRange selects the range with the cursor at its end, or only moves the cursor if empty.
+func (a *App) selectRange(sel Selection) {
+	a.s.cursors = nil
+	a.jump(sel.endRow, sel.endCol)
+	a.s.selection = nil
+	if sel.startRow != sel.endRow || sel.startCol != sel.endCol {
+		a.s.selection = &sel
+	}
+	a.drawEditor()
+}
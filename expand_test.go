@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnclosingRanges(t *testing.T) {
+	src := `package p
+
+// f prints.
+func f(a, b int) {
+	println(a + b, "hi")
+}
+`
+	texts := func(start, end int) []string {
+		var got []string
+		for _, r := range enclosingRanges([]byte(src), start, end) {
+			got = append(got, src[r[0]:r[1]])
+		}
+		return got
+	}
+
+	at := strings.Index(src, "a + b")
+	got := texts(at, at)
+	want := []string{
+		"a",
+		"a + b",
+		`a + b, "hi"`,
+		`println(a + b, "hi")`,
+		"\n\t" + `println(a + b, "hi")` + "\n",
+		"{\n\t" + `println(a + b, "hi")` + "\n}",
+	}
+	if len(got) < len(want)+2 {
+		t.Fatalf("ranges = %q, want starting with %q", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("range %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+	if last := got[len(got)-1]; last != src {
+		t.Errorf("last range = %q, want the whole source", last)
+	}
+	if doc := got[len(got)-2]; !strings.HasPrefix(doc, "// f prints.") || !strings.HasSuffix(doc, "}") {
+		t.Errorf("range before the source = %q, want the function with its doc", doc)
+	}
+
+	at = strings.Index(src, "hi")
+	if got := texts(at, at+2); len(got) < 2 || got[0] != `"hi"` {
+		t.Errorf("ranges of the string inside = %q, want the quoted string first", got)
+	}
+	if got := texts(0, len(src)); len(got) != 0 {
+		t.Errorf("ranges of the whole source = %q, want none", got)
+	}
+}
+
+func TestByteOffset(t *testing.T) {
+	st := &State{Tab: &Tab{}}
+	st.loadSource(strings.NewReader("héllo\nwörld"))
+	text := st.text()
+	for _, p := range [][2]int{{0, 0}, {0, 2}, {1, 0}, {1, 3}, {1, 5}} {
+		off := st.byteOffset(p[0], p[1])
+		if row, col := offsetPosition(text, off); row != p[0] || col != p[1] {
+			t.Errorf("position of the offset %d of %v = %d:%d", off, p, row, col)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"strconv"
+)
+
+// evalExpr evaluates an arithmetic or bitwise expression of Go number literals,
+// such as "0x1F * 3" or "1<<10 | 0b11". Like Go, division of integers truncates.
+func evalExpr(s string) (constant.Value, error) {
+	e, err := parser.ParseExpr(s)
+	if err != nil {
+		return nil, errors.New("invalid expression")
+	}
+	return eval(e)
+}
+
+func eval(e ast.Expr) (constant.Value, error) {
+	switch e := e.(type) {
+	case *ast.BasicLit:
+		if e.Kind == token.INT || e.Kind == token.FLOAT || e.Kind == token.CHAR {
+			return constant.MakeFromLiteral(e.Value, e.Kind, 0), nil
+		}
+	case *ast.ParenExpr:
+		return eval(e.X)
+	case *ast.UnaryExpr:
+		x, err := eval(e.X)
+		if err != nil {
+			return nil, err
+		}
+		switch e.Op {
+		case token.ADD, token.SUB:
+			return constant.UnaryOp(e.Op, x, 0), nil
+		case token.XOR:
+			if x.Kind() == constant.Int {
+				return constant.UnaryOp(e.Op, x, 0), nil
+			}
+		}
+	case *ast.BinaryExpr:
+		x, err := eval(e.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := eval(e.Y)
+		if err != nil {
+			return nil, err
+		}
+		ints := x.Kind() == constant.Int && y.Kind() == constant.Int
+		switch e.Op {
+		case token.ADD, token.SUB, token.MUL:
+			return constant.BinaryOp(x, e.Op, y), nil
+		case token.QUO, token.REM:
+			if constant.Sign(y) == 0 {
+				return nil, errors.New("division by zero")
+			}
+			if e.Op == token.QUO && !ints {
+				return constant.BinaryOp(x, e.Op, y), nil
+			}
+			if ints {
+				if e.Op == token.QUO {
+					return constant.BinaryOp(x, token.QUO_ASSIGN, y), nil // integer division
+				}
+				return constant.BinaryOp(x, e.Op, y), nil
+			}
+		case token.AND, token.OR, token.XOR, token.AND_NOT:
+			if ints {
+				return constant.BinaryOp(x, e.Op, y), nil
+			}
+		case token.SHL, token.SHR:
+			if !ints {
+				break
+			}
+			if n, ok := constant.Uint64Val(y); ok && n <= 1024 {
+				return constant.Shift(x, e.Op, uint(n)), nil
+			}
+		}
+	}
+	// positions of the parsed expression start from 1
+	return nil, fmt.Errorf("unsupported expression at column %d", e.Pos())
+}
+
+// formatValue returns the value as inserted into the text.
+func formatValue(v constant.Value) string {
+	if v.Kind() == constant.Int {
+		return v.ExactString()
+	}
+	f, _ := constant.Float64Val(v)
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
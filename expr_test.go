@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestEvalExpr(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"0x1F * 3", "93"},
+		{"1<<10 | 0b11", "1027"},
+		{"7 / 2", "3"},
+		{"7.0 / 2", "3.5"},
+		{"-(1 + 2) % 2", "-1"},
+		{"^0", "-1"},
+		{"0xff &^ 0x0f", "240"},
+		{"'a' + 1", "98"},
+	}
+	for _, tt := range tests {
+		v, err := evalExpr(tt.expr)
+		if err != nil {
+			t.Errorf("evalExpr(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got := formatValue(v); got != tt.want {
+			t.Errorf("evalExpr(%q) = %s, want %s", tt.expr, got, tt.want)
+		}
+	}
+
+	for _, expr := range []string{"1 / 0", "1.5 | 1", "x + 1", "\"a\"", "1 <<"} {
+		if _, err := evalExpr(expr); err == nil {
+			t.Errorf("evalExpr(%q) expected error", expr)
+		}
+	}
+}
@@ -0,0 +1,541 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// textEdit replaces the bytes from start to end of a text with the new text.
+type textEdit struct {
+	start, end int
+	text       string
+}
+
+// applyEdits applies the edits of the text of the tab, in order and not overlapping,
+// undone as one change.
+func (st *State) applyEdits(text string, edits []textEdit) {
+	st.beginGroup()
+	defer st.endGroup()
+	// from the last so that the offsets of the preceding stay valid
+	for i := len(edits) - 1; i >= 0; i-- {
+		e := edits[i]
+		startRow, startCol := offsetPosition(text, e.start)
+		endRow, endCol := offsetPosition(text, e.end)
+		deleted := st.deleteRange(startRow, startCol, endRow, endCol)
+		st.insertText([]rune(e.text), startRow, startCol)
+		kind := editReplace
+		if deleted == "" {
+			kind = editInsert
+		}
+		st.recordChange(Change{row: startRow, col: startCol, oldText: deleted, newText: e.text, kind: kind})
+	}
+}
+
+var errSyntax = errors.New("the file has syntax errors")
+
+// trimRange returns the range of the text without the white spaces around.
+func trimRange(text string, start, end int) (int, int) {
+	for start < end && unicode.IsSpace(rune(text[start])) {
+		start++
+	}
+	for end > start && unicode.IsSpace(rune(text[end-1])) {
+		end--
+	}
+	return start, end
+}
+
+// lineIndent returns the offset of the start of the line of the offset and the white
+// spaces before it, reporting false if there is anything else.
+func lineIndent(text string, offset int) (int, string, bool) {
+	start := strings.LastIndexByte(text[:offset], '\n') + 1
+	indent := text[start:offset]
+	return start, indent, strings.TrimSpace(indent) == ""
+}
+
+// extractVariable returns the edits declaring the variable of the name as the expression
+// from start to end of the Go source, before the statement of the expression, and
+// replacing the expression by the variable.
+func extractVariable(src string, start, end int, name string) ([]textEdit, error) {
+	start, end = trimRange(src, start, end)
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, errSyntax
+	}
+	off := fset.File(f.Pos()).Offset
+
+	// the nodes from the file to the expression
+	var path, stack []ast.Node
+	ast.Inspect(f, func(n ast.Node) bool {
+		if n == nil {
+			stack = stack[:len(stack)-1]
+			return false
+		}
+		if off(n.Pos()) > start || off(n.End()) < end {
+			return false
+		}
+		stack = append(stack, n)
+		if _, ok := n.(ast.Expr); ok && path == nil && off(n.Pos()) == start && off(n.End()) == end {
+			path = slices.Clone(stack)
+		}
+		return true
+	})
+	if path == nil {
+		return nil, errors.New("the selection is not an expression")
+	}
+	expr := path[len(path)-1]
+	switch p := path[len(path)-2].(type) {
+	case *ast.AssignStmt:
+		if slices.Contains(p.Lhs, expr.(ast.Expr)) {
+			return nil, errors.New("the selection is assigned to")
+		}
+	case *ast.IncDecStmt:
+		return nil, errors.New("the selection is assigned to")
+	case *ast.UnaryExpr:
+		if p.Op == token.AND {
+			return nil, errors.New("the address of the selection is taken")
+		}
+	case *ast.SelectorExpr:
+		if p.Sel == expr {
+			return nil, errors.New("the selection is not an expression")
+		}
+	case *ast.KeyValueExpr:
+		if _, ok := expr.(*ast.Ident); ok && p.Key == expr {
+			return nil, errors.New("the selection is a field name")
+		}
+	}
+
+	// the statement in a block to declare the variable before, not a clause
+	var stmt ast.Node
+	for i := len(path) - 2; i > 0 && stmt == nil; i-- {
+		var init ast.Stmt // the header of the statement if the selection is in it
+		switch s := path[i].(type) {
+		case *ast.ForStmt:
+			if path[i+1] == s.Cond || path[i+1] == s.Post {
+				return nil, errors.New("the selection is evaluated by each iteration of the loop")
+			}
+		case *ast.CaseClause:
+			if !slices.ContainsFunc(s.Body, func(b ast.Stmt) bool { return b == path[i+1] }) {
+				return nil, errors.New("the selection is in a case")
+			}
+		case *ast.CommClause:
+			if path[i+1] == s.Comm {
+				return nil, errors.New("the selection is in a case")
+			}
+		case *ast.IfStmt:
+			if path[i+1] != s.Body { // the else chain is in the scope of the header too
+				init = s.Init
+			}
+		case *ast.SwitchStmt:
+			if path[i+1] != s.Body {
+				init = s.Init
+			}
+		case *ast.TypeSwitchStmt:
+			if path[i+1] != s.Body {
+				init = s.Init
+			}
+		}
+		if init != nil && init != path[i+1] {
+			if name := usesDeclared(expr, init); name != "" {
+				return nil, fmt.Errorf("the selection uses %s declared by the statement", name)
+			}
+		}
+		if _, ok := path[i].(ast.Stmt); !ok {
+			continue
+		}
+		switch path[i-1].(type) {
+		case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause:
+			stmt = path[i]
+		}
+	}
+	if stmt == nil {
+		return nil, errors.New("the selection is not in a function")
+	}
+	used := false
+	ast.Inspect(path[1], func(n ast.Node) bool {
+		if id, ok := n.(*ast.Ident); ok && id.Name == name {
+			used = true
+		}
+		return !used
+	})
+	if used {
+		return nil, fmt.Errorf("%s is already used in the function", name)
+	}
+	lineStart, indent, ok := lineIndent(src, off(stmt.Pos()))
+	if !ok {
+		return nil, errors.New("the statement of the selection does not start its line")
+	}
+	return []textEdit{
+		{lineStart, lineStart, indent + name + " := " + src[start:end] + "\n"},
+		{start, end, name},
+	}, nil
+}
+
+// usesDeclared returns the first name the node uses of the variables declared by
+// the statement, empty if none.
+func usesDeclared(n ast.Node, s ast.Stmt) string {
+	var names []string
+	switch s := s.(type) {
+	case *ast.AssignStmt:
+		if s.Tok == token.DEFINE {
+			for _, lhs := range s.Lhs {
+				if id, ok := lhs.(*ast.Ident); ok {
+					names = append(names, id.Name)
+				}
+			}
+		}
+	case *ast.DeclStmt:
+		if d, ok := s.Decl.(*ast.GenDecl); ok {
+			for _, spec := range d.Specs {
+				if vs, ok := spec.(*ast.ValueSpec); ok {
+					for _, id := range vs.Names {
+						names = append(names, id.Name)
+					}
+				}
+			}
+		}
+	}
+	var used string
+	ast.Inspect(n, func(m ast.Node) bool {
+		if id, ok := m.(*ast.Ident); ok && used == "" && slices.Contains(names, id.Name) {
+			used = id.Name
+		}
+		return used == ""
+	})
+	return used
+}
+
+// jumpOut returns the statement of the node, other than in function literals, that leaves
+// it or would not work in another function, like return and defer, empty if none.
+// A loop or a switch around the node lets continue or break go to it.
+func jumpOut(n ast.Node, loop, breakable bool) string {
+	var found string
+	ast.Inspect(n, func(m ast.Node) bool {
+		if found != "" || m == nil {
+			return false
+		}
+		switch s := m.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			found = "return"
+		case *ast.DeferStmt:
+			found = "defer"
+		case *ast.BranchStmt:
+			if s.Label != nil || s.Tok == token.GOTO || s.Tok == token.FALLTHROUGH ||
+				s.Tok == token.BREAK && !breakable || s.Tok == token.CONTINUE && !loop {
+				found = s.Tok.String()
+			}
+		case *ast.ForStmt:
+			found = jumpOut(s.Body, true, true)
+			return false
+		case *ast.RangeStmt:
+			found = jumpOut(s.Body, true, true)
+			return false
+		case *ast.SwitchStmt:
+			found = jumpOut(s.Body, loop, true)
+			return false
+		case *ast.TypeSwitchStmt:
+			found = jumpOut(s.Body, loop, true)
+			return false
+		case *ast.SelectStmt:
+			found = jumpOut(s.Body, loop, true)
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+// rootIdent returns the variable an expression like x.f[i] is part of, nil if none.
+func rootIdent(e ast.Expr) *ast.Ident {
+	for {
+		switch x := e.(type) {
+		case *ast.Ident:
+			return x
+		case *ast.SelectorExpr:
+			e = x.X
+		case *ast.IndexExpr:
+			e = x.X
+		case *ast.ParenExpr:
+			e = x.X
+		case *ast.StarExpr:
+			e = x.X
+		default:
+			return nil
+		}
+	}
+}
+
+// extractFunction returns the edits moving the statements from start to end of the Go source
+// to a new function of the name after the function of the statements, replacing them by
+// a call. The variables from before are passed in, those declared, or assigned, or possibly
+// changed by a method or a pointer, and used after are returned. The source alone is type
+// checked, so the types from other files and imports are taken from the declarations of
+// the variables.
+func extractFunction(src string, start, end int, name string) ([]textEdit, error) {
+	start, end = trimRange(src, start, end)
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, errSyntax
+	}
+	off := fset.File(f.Pos()).Offset
+
+	var decl *ast.FuncDecl
+	for _, d := range f.Decls {
+		if fd, ok := d.(*ast.FuncDecl); ok && fd.Body != nil && off(fd.Body.Lbrace) < start && end <= off(fd.Body.Rbrace) {
+			decl = fd
+		}
+	}
+	if decl == nil {
+		return nil, errors.New("the selection is not in a function")
+	}
+	var stmts []ast.Stmt
+	ast.Inspect(decl.Body, func(n ast.Node) bool {
+		if stmts != nil || n == nil || off(n.Pos()) > start || off(n.End()) < end {
+			return false
+		}
+		var list []ast.Stmt
+		switch n := n.(type) {
+		case *ast.BlockStmt:
+			list = n.List
+		case *ast.CaseClause:
+			list = n.Body
+		case *ast.CommClause:
+			list = n.Body
+		}
+		i := slices.IndexFunc(list, func(s ast.Stmt) bool { return off(s.Pos()) == start })
+		j := slices.IndexFunc(list, func(s ast.Stmt) bool { return off(s.End()) == end })
+		if i >= 0 && j >= i {
+			stmts = list[i : j+1]
+		}
+		return true
+	})
+	if stmts == nil {
+		return nil, errors.New("the selection is not whole statements")
+	}
+	for _, s := range stmts {
+		if jump := jumpOut(s, false, false); jump != "" {
+			return nil, fmt.Errorf("the selection has %s", jump)
+		}
+	}
+	lineStart, indent, ok := lineIndent(src, start)
+	if !ok {
+		return nil, errors.New("the selection does not start its line")
+	}
+
+	info := &types.Info{Defs: make(map[*ast.Ident]types.Object), Uses: make(map[*ast.Ident]types.Object)}
+	conf := types.Config{Importer: emptyImporter{}, FakeImportC: true, Error: func(error) {}}
+	pkg, _ := conf.Check(f.Name.Name, fset, []*ast.File{f}, info)
+	if pkg.Scope().Lookup(name) != nil {
+		return nil, fmt.Errorf("%s is already declared", name)
+	}
+	inFunc := func(p token.Pos) bool { return decl.Pos() <= p && p < decl.End() }
+	inSelection := func(p token.Pos) bool { return start <= off(p) && off(p) < end }
+	usedAfter := func(v *types.Var) bool {
+		for id, obj := range info.Uses {
+			if obj == v && off(id.Pos()) >= end && inFunc(id.Pos()) {
+				return true
+			}
+		}
+		return false
+	}
+	var params, defined, assigned []*types.Var
+	add := func(vars []*types.Var, v *types.Var) []*types.Var {
+		if slices.Contains(vars, v) {
+			return vars
+		}
+		return append(vars, v)
+	}
+	// change records the variable of the expression changed if from before and used after
+	change := func(e ast.Expr) {
+		if id := rootIdent(e); id != nil {
+			if v, ok := info.Uses[id].(*types.Var); ok && !v.IsField() && inFunc(v.Pos()) && !inSelection(v.Pos()) && usedAfter(v) {
+				assigned = add(assigned, v)
+			}
+		}
+	}
+	for _, s := range stmts {
+		ast.Inspect(s, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Ident:
+				if v, ok := info.Uses[n].(*types.Var); ok && !v.IsField() && inFunc(v.Pos()) && !inSelection(v.Pos()) {
+					params = add(params, v)
+				}
+				if v, ok := info.Defs[n].(*types.Var); ok && !v.IsField() && usedAfter(v) {
+					defined = add(defined, v)
+				}
+			case *ast.AssignStmt:
+				for _, lhs := range n.Lhs {
+					change(lhs)
+				}
+			case *ast.IncDecStmt:
+				change(n.X)
+			case *ast.UnaryExpr:
+				if n.Op == token.AND {
+					change(n.X)
+				}
+			case *ast.CallExpr:
+				if sel, ok := n.Fun.(*ast.SelectorExpr); ok {
+					change(sel.X)
+				}
+			}
+			return true
+		})
+	}
+
+	typeOf := func(v *types.Var) (string, error) {
+		s := types.TypeString(v.Type(), func(p *types.Package) string {
+			if p == pkg {
+				return ""
+			}
+			return p.Name()
+		})
+		if !strings.Contains(s, "invalid type") {
+			return s, nil
+		}
+		// the type the variable is declared with
+		var typ ast.Expr
+		ast.Inspect(decl, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.Field:
+				if slices.ContainsFunc(n.Names, func(id *ast.Ident) bool { return id.Pos() == v.Pos() }) {
+					typ = n.Type
+				}
+			case *ast.ValueSpec:
+				if slices.ContainsFunc(n.Names, func(id *ast.Ident) bool { return id.Pos() == v.Pos() }) {
+					typ = n.Type
+				}
+			}
+			return typ == nil
+		})
+		if typ == nil {
+			return "", fmt.Errorf("cannot infer the type of %s", v.Name())
+		}
+		return src[off(typ.Pos()):off(typ.End())], nil
+	}
+	var b strings.Builder
+	var args []string
+	fmt.Fprintf(&b, "\n\nfunc %s(", name)
+	for i, v := range params {
+		t, err := typeOf(v)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s %s", v.Name(), t)
+		args = append(args, v.Name())
+	}
+	b.WriteString(")")
+	results := slices.Concat(defined, assigned)
+	var resultNames, resultTypes, decls []string
+	for _, v := range results {
+		t, err := typeOf(v)
+		if err != nil {
+			return nil, err
+		}
+		resultNames = append(resultNames, v.Name())
+		resultTypes = append(resultTypes, t)
+		if len(assigned) > 0 && slices.Contains(defined, v) {
+			decls = append(decls, fmt.Sprintf("var %s %s\n%s", v.Name(), t, indent))
+		}
+	}
+	switch len(results) {
+	case 0:
+	case 1:
+		b.WriteString(" " + resultTypes[0])
+	default:
+		b.WriteString(" (" + strings.Join(resultTypes, ", ") + ")")
+	}
+	b.WriteString(" {\n")
+
+	// re-indent the lines, other than inside raw strings
+	var raw [][2]int
+	for _, s := range stmts {
+		ast.Inspect(s, func(n ast.Node) bool {
+			if lit, ok := n.(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				raw = append(raw, [2]int{off(lit.Pos()), off(lit.End())})
+			}
+			return true
+		})
+	}
+	for i := lineStart; i < end; {
+		j := strings.IndexByte(src[i:end], '\n')
+		if j < 0 {
+			j = end - i
+		}
+		line := src[i : i+j]
+		if !slices.ContainsFunc(raw, func(r [2]int) bool { return r[0] < i && i < r[1] }) {
+			if line = strings.TrimPrefix(line, indent); line != "" {
+				line = "\t" + line
+			}
+		}
+		b.WriteString(line + "\n")
+		i += j + 1
+	}
+	if len(results) > 0 {
+		fmt.Fprintf(&b, "\treturn %s\n", strings.Join(resultNames, ", "))
+	}
+	b.WriteString("}")
+
+	call := fmt.Sprintf("%s(%s)", name, strings.Join(args, ", "))
+	switch {
+	case len(results) == 0:
+	case len(assigned) == 0:
+		call = strings.Join(resultNames, ", ") + " := " + call
+	default:
+		call = strings.Join(decls, "") + strings.Join(resultNames, ", ") + " = " + call
+	}
+	return []textEdit{
+		{start, end, call},
+		{off(decl.End()), off(decl.End()), b.String()},
+	}, nil
+}
+
+// extract replaces the selection of the Go tab by a variable declared before its
+// statement (fn false), or by a call to a function of its statements (fn true).
+func (a *App) extract(fn bool, name string) {
+	a.s.focus = focusEditor
+	if !strings.HasSuffix(a.s.filename, ".go") {
+		a.showMessage("Not a Go file")
+		return
+	}
+	if !a.editable() {
+		return
+	}
+	sel := a.s.selected()
+	if sel == nil {
+		a.showMessage("Select the expression or the statements to extract")
+		return
+	}
+	text := a.s.text()
+	start, end := a.s.byteOffset(sel.startRow, sel.startCol), a.s.byteOffset(sel.endRow, sel.endCol)
+	var edits []textEdit
+	var err error
+	if fn {
+		edits, err = extractFunction(text, start, end, cmp.Or(name, "newFunction"))
+	} else {
+		edits, err = extractVariable(text, start, end, cmp.Or(name, "x"))
+	}
+	if err != nil {
+		a.showError("Cannot extract: " + err.Error())
+		return
+	}
+	a.s.selection = nil
+	a.s.applyEdits(text, edits)
+	row, _ := offsetPosition(text, edits[0].start)
+	col := slices.IndexFunc(a.s.line(row).Value, func(r rune) bool { return !unicode.IsSpace(r) })
+	a.jump(row, max(col, 0))
+	a.drawEditor()
+}
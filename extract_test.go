@@ -0,0 +1,153 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// applyTextEdits returns the text with the edits applied.
+func applyTextEdits(text string, edits []textEdit) string {
+	for i := len(edits) - 1; i >= 0; i-- {
+		text = text[:edits[i].start] + edits[i].text + text[edits[i].end:]
+	}
+	return text
+}
+
+func TestExtractVariable(t *testing.T) {
+	src := `package p
+
+func f(a, b int) int {
+	if a > 0 {
+		return g(a+b, 2)
+	}
+	for i := 0; i < a*b; i++ {
+		a = b
+	}
+	return 0
+}
+`
+	at := strings.Index(src, "a+b")
+	edits, err := extractVariable(src, at, at+3, "sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(src, "\t\treturn g(a+b, 2)", "\t\tsum := a+b\n\t\treturn g(sum, 2)", 1)
+	if got := applyTextEdits(src, edits); got != want {
+		t.Errorf("extracted:\n%s\nwant:\n%s", got, want)
+	}
+
+	for _, tt := range []struct{ sel, name string }{
+		{"a*b", "x"},       // the loop condition
+		{"a = b", "x"},     // a statement
+		{"a+b, 2", "x"},    // not one expression
+		{"g(a+b, 2)", "b"}, // the name is used
+		{"= b", "x"},       // not syntax
+	} {
+		at := strings.Index(src, tt.sel)
+		if _, err := extractVariable(src, at, at+len(tt.sel), tt.name); err == nil {
+			t.Errorf("extracting %q as %s succeeds, want an error", tt.sel, tt.name)
+		}
+	}
+	headers := `package p
+
+func g(m map[int]int, x int) {
+	if v, ok := m[1]; ok && v > 3 {
+	} else if v < 0 {
+	}
+	switch x {
+	case x + 1:
+	}
+}
+`
+	for _, sel := range []string{"v > 3", "v < 0", "x + 1"} {
+		at := strings.Index(headers, sel)
+		if edits, err := extractVariable(headers, at, at+len(sel), "tmp"); err == nil {
+			t.Errorf("extracting %q succeeds, want an error:\n%s", sel, applyTextEdits(headers, edits))
+		}
+	}
+	at = strings.Index(headers, "m[1]")
+	edits, err = extractVariable(headers, at, at+4, "tmp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = strings.Replace(headers, "\tif v, ok := m[1];", "\ttmp := m[1]\n\tif v, ok := tmp;", 1)
+	if got := applyTextEdits(headers, edits); got != want {
+		t.Errorf("extracted from the header:\n%s\nwant:\n%s", got, want)
+	}
+
+	at = strings.Index(src, "\ta = b") + 1
+	if _, err := extractVariable(src, at, at+1, "x"); err == nil {
+		t.Error("extracting the left side of an assignment succeeds, want an error")
+	}
+}
+
+func TestExtractFunction(t *testing.T) {
+	src := `package p
+
+import "strings"
+
+func f(items []string) string {
+	var b strings.Builder
+	n := 0
+	for _, s := range items {
+		if s == "" {
+			continue
+		}
+		total := len(s)
+		n += total
+		b.WriteString(` + "`a\n  b`" + `)
+	}
+	return b.String() + string(rune(n))
+}
+`
+	start := strings.Index(src, "\tfor _, s")
+	end := strings.Index(src, "\treturn b")
+	edits, err := extractFunction(src, start, end, "collect")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `package p
+
+import "strings"
+
+func f(items []string) string {
+	var b strings.Builder
+	n := 0
+	n, b = collect(items, n, b)
+	return b.String() + string(rune(n))
+}
+
+func collect(items []string, n int, b strings.Builder) (int, strings.Builder) {
+	for _, s := range items {
+		if s == "" {
+			continue
+		}
+		total := len(s)
+		n += total
+		b.WriteString(` + "`a\n  b`" + `)
+	}
+	return n, b
+}
+`
+	if got := applyTextEdits(src, edits); got != want {
+		t.Errorf("extracted:\n%s\nwant:\n%s", got, want)
+	}
+
+	// declared and used after
+	start = strings.Index(src, "\tn := 0")
+	end = start + len("\tn := 0")
+	edits, err = extractFunction(src, start, end, "zero")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := applyTextEdits(src, edits); !strings.Contains(got, "\tn := zero()\n") || !strings.Contains(got, "func zero() int {\n\tn := 0\n\treturn n\n}") {
+		t.Errorf("extracted:\n%s", got)
+	}
+
+	for _, sel := range []string{"continue", "return b.String() + string(rune(n))", "len(s)", "f"} {
+		start := strings.Index(src, sel)
+		if _, err := extractFunction(src, start, start+len(sel), "g"); err == nil {
+			t.Errorf("extracting %q succeeds, want an error", sel)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// fileType describes how the editor treats the files of a language.
+type fileType struct {
+	name string
+	exts []string
+	// names are the file names of the type regardless of the extension.
+	names []string
+	// wordChars are the characters besides letters and digits that form a word,
+	// used by the identifier scan of go-to-symbol and completion hint, set by the
+	// [wordchars] section of the settings.
+	wordChars string
+	// highlight returns the styled texts of a screen line, nil for plain text.
+	highlight func(line []rune) []textStyle
+	// lineComment starts a comment to the line end, continued on Enter.
+	lineComment string
+	// blockComment tells whether "/* */" blocks are continued with " * " on Enter.
+	blockComment bool
+	// lists tells whether "- " and "1. " list items are continued on Enter.
+	lists bool
+	// rulers are the columns marked on every line.
+	rulers []int
+	// colors tells whether the color values are shown with a swatch.
+	colors bool
+	// complete returns the completions of the word at [start, col) of the line, nil if none.
+	complete func(line []rune, start, col int) []string
+}
+
+var fileTypes = []*fileType{
+	{name: "go", exts: []string{".go"}, wordChars: "_", highlight: highlightGoLine, lineComment: "//", blockComment: true},
+	{name: "css", exts: []string{".css", ".scss", ".less"}, wordChars: "-_", blockComment: true, colors: true},
+	{name: "lisp", exts: []string{".lisp", ".el", ".clj", ".scm"}, wordChars: "-_?!*+<>=/", lineComment: ";"},
+	{name: "shell", exts: []string{".sh", ".bash", ".zsh"}, wordChars: "_$", lineComment: "#"},
+	{name: "dockerfile", names: []string{"Dockerfile", "Containerfile"}, exts: []string{".dockerfile"}, wordChars: "_-", lineComment: "#"},
+	{name: "markdown", exts: []string{".md", ".markdown"}, wordChars: "_", lists: true},
+	{name: "gomod", names: []string{"go.mod", "go.work"}, wordChars: "_-./~+", highlight: highlightGoModLine, lineComment: "//",
+		complete: func(line []rune, start, col int) []string {
+			return moduleCompletions(moduleDownloads(), line, start, col)
+		}},
+	{name: "gosum", names: []string{"go.sum", "go.work.sum"}, highlight: highlightGoSumLine},
+	{name: "json", exts: []string{".json"}, wordChars: "_-", colors: true},
+	{name: "yaml", exts: []string{".yaml", ".yml"}, wordChars: "_-", lineComment: "#", colors: true},
+	{name: "config", exts: []string{".toml", ".ini", ".conf", ".cfg"}, wordChars: "_-", lineComment: "#", colors: true},
+	{name: "gitcommit", names: []string{"COMMIT_EDITMSG", "MERGE_MSG", "TAG_EDITMSG"}, wordChars: "_-", highlight: highlightCommitLine, rulers: []int{50, 72}},
+	{name: "gotemplate", exts: []string{".tmpl", ".gotmpl", ".tpl", ".html"}, wordChars: "_", highlight: highlightTemplateLine},
+}
+
+// plainText is the file type of files not registered.
+var plainText = &fileType{name: "text", wordChars: "_"}
+
+// fileTypeOf returns the file type according to the file name, or its extension.
+func fileTypeOf(filename string) *fileType {
+	base := filepath.Base(filename)
+	for _, ft := range fileTypes {
+		if slices.Contains(ft.names, base) {
+			return ft
+		}
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	for _, ft := range fileTypes {
+		for _, e := range ft.exts {
+			if e == ext {
+				return ft
+			}
+		}
+	}
+	return plainText
+}
+
+// isWordChar reports whether the rune is part of a word.
+func (ft *fileType) isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune(ft.wordChars, r)
+}
+
+// listItem matches the bullet of a list item, like "- ", "* [ ] " or "1. ".
+var listItem = regexp.MustCompile(`^(?:([-*+])|(\d+)([.)]))[ \t]+(\[[ xX]\][ \t]+)?`)
+
+// continuation returns the leader to repeat after the indentation of a new line broken at col,
+// such as "// " in a comment or "- " in a list. It returns an empty string if the line
+// has no leader before col. blank reports whether the line has nothing but the leader.
+func (ft *fileType) continuation(line []rune, col int) (leader string, blank bool) {
+	n := leadingWhitespaces(line)
+	text := string(line[n:])
+	var prefix string // the leader as it is in the line
+	switch {
+	case ft.lineComment != "" && strings.HasPrefix(text, ft.lineComment):
+		rest := text[len(ft.lineComment):]
+		prefix = text[:len(text)-len(strings.TrimLeft(rest, " \t"))]
+		leader = prefix
+	case ft.blockComment && strings.HasPrefix(text, "/*") && !strings.Contains(text, "*/"):
+		prefix, leader = "/*", " * "
+	case ft.blockComment && (text == "*" || strings.HasPrefix(text, "* ")) && !strings.Contains(text, "*/"):
+		prefix, leader = "*", "* "
+	case ft.lists:
+		m := listItem.FindStringSubmatch(text)
+		if m == nil {
+			break
+		}
+		prefix = m[0]
+		bullet := m[1]
+		if m[2] != "" {
+			num, _ := strconv.Atoi(m[2])
+			bullet = strconv.Itoa(num+1) + m[3]
+		}
+		leader = bullet + " "
+		if m[4] != "" {
+			leader += "[ ] "
+		}
+	}
+	if leader == "" || col < n+len([]rune(prefix)) {
+		return "", false
+	}
+	return leader, strings.TrimSpace(text) == strings.TrimSpace(prefix)
+}
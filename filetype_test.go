@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+func TestContinuation(t *testing.T) {
+	goType, md := fileTypeOf("a.go"), fileTypeOf("a.md")
+	tests := []struct {
+		ft     *fileType
+		line   string
+		col    int
+		leader string
+		blank  bool
+	}{
+		{goType, "\t// hello", 9, "// ", false},
+		{goType, "\t// hello", 2, "", false},
+		{goType, "//", 2, "//", true},
+		{goType, "/* hello", 8, " * ", false},
+		{goType, " * hello", 8, "* ", false},
+		{goType, " */", 3, "", false},
+		{goType, "x := 1 // c", 11, "", false},
+		{md, "- item", 6, "- ", false},
+		{md, "  * [x] done", 12, "* [ ] ", false},
+		{md, "9. item", 7, "10. ", false},
+		{md, "- ", 2, "- ", true},
+		{md, "-item", 5, "", false},
+	}
+	for _, tt := range tests {
+		leader, blank := tt.ft.continuation([]rune(tt.line), tt.col)
+		if leader != tt.leader || blank != tt.blank {
+			t.Errorf("continuation(%q, %d) = %q, %v, want %q, %v", tt.line, tt.col, leader, blank, tt.leader, tt.blank)
+		}
+	}
+}
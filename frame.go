@@ -0,0 +1,31 @@
+package main
+
+import (
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// frameInterval is the least time between updates of the terminal, so that bursts of
+// events, such as a held key or fast wheel scrolling, show only the final state.
+const frameInterval = time.Second / 60
+
+// frameEvent is posted as interrupt data when a delayed update of the terminal is due.
+type frameEvent struct{}
+
+// present updates the terminal with the drawing, at most once a frame,
+// delaying the update until the frame ends if too soon.
+func (a *App) present() {
+	if wait := frameInterval - time.Since(a.lastShow); wait > 0 {
+		if !a.showPending {
+			a.showPending = true
+			time.AfterFunc(wait, func() {
+				screen.PostEvent(tcell.NewEventInterrupt(frameEvent{}))
+			})
+		}
+		return
+	}
+	a.showPending = false
+	a.lastShow = time.Now()
+	screen.Show()
+}
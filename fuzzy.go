@@ -0,0 +1,67 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// isWordStart reports whether the rune at i starts a word, after a separator
+// like '.' or '_', or as the upper case letter of camel case.
+func isWordStart(s []rune, i int) bool {
+	if i == 0 {
+		return true
+	}
+	prev := s[i-1]
+	if !unicode.IsLetter(prev) && !unicode.IsDigit(prev) {
+		return true
+	}
+	return unicode.IsUpper(s[i]) && unicode.IsLower(prev)
+}
+
+// fuzzyScore reports whether the runes of the pattern appear in order in s, ignoring case,
+// and scores the match. Runes at word starts and consecutive runes score more,
+// and the longer s the less score.
+func fuzzyScore(pattern, s string) (int, bool) {
+	p := []rune(strings.ToLower(pattern))
+	runes := []rune(s)
+	score, pi, prev := 0, 0, -2
+	for i, r := range runes {
+		if pi == len(p) {
+			break
+		}
+		if unicode.ToLower(r) != p[pi] {
+			continue
+		}
+		score += 10
+		if isWordStart(runes, i) {
+			score += 20
+		}
+		if prev == i-1 {
+			score += 15
+		}
+		prev = i
+		pi++
+	}
+	if pi < len(p) {
+		return 0, false
+	}
+	return score - len(runes), true
+}
+
+// symbolScore scores the symbol by the pattern of the @ picker. A pattern like "t.lin"
+// matches the receiver and the name separately, so it finds Tab.line.
+func symbolScore(pattern string, sym Symbol) (int, bool) {
+	if recvPattern, namePattern, ok := strings.Cut(pattern, "."); ok && sym.Receiver != "" {
+		recvScore, ok1 := fuzzyScore(recvPattern, sym.Receiver)
+		nameScore, ok2 := fuzzyScore(namePattern, sym.Name)
+		if ok1 && ok2 {
+			return recvScore + nameScore, true
+		}
+	}
+	if score, ok := fuzzyScore(pattern, sym.Name); ok {
+		return score, true
+	}
+	// matching across the receiver ranks lower
+	score, ok := fuzzyScore(pattern, symbolName(sym))
+	return score - 50, ok
+}
@@ -0,0 +1,52 @@
+package main
+
+import "testing"
+
+func TestFuzzyScore(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		ok      bool
+	}{
+		{"", "line", true},
+		{"lin", "line", true},
+		{"LIN", "line", true},
+		{"nl", "newLine", true},
+		{"enil", "line", false},
+		{"lines", "line", false},
+	}
+	for _, tt := range tests {
+		if _, ok := fuzzyScore(tt.pattern, tt.s); ok != tt.ok {
+			t.Errorf("fuzzyScore(%q, %q) ok = %v, want %v", tt.pattern, tt.s, ok, tt.ok)
+		}
+	}
+
+	// word starts and consecutive runes rank first
+	better := [][3]string{
+		{"lin", "line", "lastIndexNext"},
+		{"nl", "newLine", "channel"},
+		{"buf", "buf", "buffer"},
+	}
+	for _, b := range better {
+		s1, _ := fuzzyScore(b[0], b[1])
+		s2, _ := fuzzyScore(b[0], b[2])
+		if s1 <= s2 {
+			t.Errorf("fuzzyScore(%q): %q scores %d, not more than %q %d", b[0], b[1], s1, b[2], s2)
+		}
+	}
+}
+
+func TestSymbolScore(t *testing.T) {
+	line := Symbol{Name: "line", Receiver: "Tab"}
+	lines := Symbol{Name: "lines", Receiver: "State"}
+	s1, ok := symbolScore("t.lin", line)
+	if !ok {
+		t.Fatal("t.lin does not match Tab.line")
+	}
+	if s2, ok := symbolScore("t.lin", lines); ok && s2 >= s1 {
+		t.Errorf("t.lin scores State.lines %d, not less than Tab.line %d", s2, s1)
+	}
+	if _, ok := symbolScore("x.lin", line); ok {
+		t.Error("x.lin matches Tab.line")
+	}
+}
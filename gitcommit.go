@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var (
+	colorRuler      = tcell.ColorWhiteSmoke
+	styleMisspelled = styleBase.Underline(tcell.UnderlineStyleCurly, tcell.ColorRed)
+)
+
+// dictionaryFiles are the word lists looked for to spell check commit messages.
+var dictionaryFiles = []string{"/usr/share/dict/words", "/usr/dict/words"}
+
+// dictionary returns the lower case words of the first word list found, nil if none.
+var dictionary = sync.OnceValue(func() map[string]bool {
+	for _, name := range dictionaryFiles {
+		f, err := os.Open(name)
+		if err != nil {
+			continue
+		}
+		defer f.Close()
+		words := make(map[string]bool)
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			words[strings.ToLower(scanner.Text())] = true
+		}
+		return words
+	}
+	return nil
+})
+
+// misspelled reports whether the word is not in the dictionary. Words with digits,
+// or upper case letters after the first like identifiers and acronyms, are not checked.
+func misspelled(dict map[string]bool, word string) bool {
+	if dict == nil || len([]rune(word)) < 2 {
+		return false
+	}
+	for i, r := range []rune(word) {
+		if !unicode.IsLetter(r) && r != '\'' || i > 0 && unicode.IsUpper(r) {
+			return false
+		}
+	}
+	lower := strings.ToLower(word)
+	if dict[lower] {
+		return false
+	}
+	for _, suffix := range []string{"'s", "s", "ed", "ing"} {
+		if stem, ok := strings.CutSuffix(lower, suffix); ok && dict[stem] {
+			return false
+		}
+	}
+	return true
+}
+
+// highlightCommitLine dims the comment lines of a commit message,
+// and underlines the misspelled words of the others.
+func highlightCommitLine(line []rune) []textStyle {
+	return highlightCommitWords(line, dictionary())
+}
+
+func highlightCommitWords(line []rune, dict map[string]bool) []textStyle {
+	if len(line) > 0 && line[0] == '#' {
+		return []textStyle{{text: line, style: styleComment}}
+	}
+	var parts []textStyle
+	isWordRune := func(i int) bool {
+		r := line[i]
+		// an apostrophe only inside a word like "don't"
+		return unicode.IsLetter(r) || unicode.IsDigit(r) ||
+			r == '\'' && i > 0 && unicode.IsLetter(line[i-1]) && i+1 < len(line) && unicode.IsLetter(line[i+1])
+	}
+	for i := 0; i < len(line); {
+		j := i
+		for j < len(line) && isWordRune(j) {
+			j++
+		}
+		if j == i {
+			parts = append(parts, textStyle{text: line[i : i+1], style: styleBase})
+			i++
+			continue
+		}
+		style := styleBase
+		if misspelled(dict, string(line[i:j])) {
+			style = styleMisspelled
+		}
+		parts = append(parts, textStyle{text: line[i:j], style: style})
+		i = j
+	}
+	return parts
+}
+
+// drawRulers marks the columns of the rulers of the file type, the text reaching one is marked too.
+func (a *App) drawRulers(texts []textStyle, rulers []int) []textStyle {
+	for _, col := range rulers {
+		if col-a.s.left < 0 {
+			continue
+		}
+		texts = highlightRange(texts, col-a.s.left, col-a.s.left+1, colorRuler)
+	}
+	return texts
+}
+
+// saveAndQuit saves current tab and quits if saved, like ":wq" of vim,
+// to finish editing the commit message as git's core.editor.
+func (a *App) saveAndQuit() {
+	if a.s.filename == "" {
+		a.showMessage("No file to save")
+		return
+	}
+	a.handleCommand(">save " + a.s.filename)
+	if a.s.dirty() {
+		return
+	}
+	postFunc(func() { close(a.done) })
+}
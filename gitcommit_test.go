@@ -0,0 +1,22 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHighlightCommitWords(t *testing.T) {
+	dict := map[string]bool{"fix": true, "the": true, "parser": true, "don't": true, "panic": true, "on": true, "in": true, "go": true}
+	parts := highlightCommitWords([]rune("Fix teh parsers, don't panik on HTTP2 in go.mod"), dict)
+	if got := styledWords(parts, styleMisspelled); !reflect.DeepEqual(got, []string{"teh", "panik", "mod"}) {
+		t.Errorf("misspelled = %q", got)
+	}
+	parts = highlightCommitWords([]rune("# Please enter the commit message"), dict)
+	if len(parts) != 1 || parts[0].style != styleComment {
+		t.Errorf("comment line = %v", parts)
+	}
+	parts = highlightCommitWords([]rune("anything"), nil)
+	if got := styledWords(parts, styleMisspelled); got != nil {
+		t.Errorf("misspelled without dictionary = %q", got)
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"cmp"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// goModKeywords are the directives of go.mod and go.work files.
+var goModKeywords = []string{"module", "go", "toolchain", "require", "replace", "exclude", "retract", "godebug", "tool", "ignore", "use"}
+
+// isVersion reports whether the word looks like a module version, such as "v1.2.3" or "1.23".
+func isVersion(word string) bool {
+	word = strings.TrimPrefix(word, "v")
+	return word != "" && unicode.IsDigit(rune(word[0]))
+}
+
+// splitFields splits the line into runs of spaces and runs of other runes.
+func splitFields(line []rune) [][]rune {
+	var fields [][]rune
+	start := 0
+	for i := 1; i <= len(line); i++ {
+		if i == len(line) || unicode.IsSpace(line[i]) != unicode.IsSpace(line[start]) {
+			fields = append(fields, line[start:i])
+			start = i
+		}
+	}
+	return fields
+}
+
+// highlightGoModLine highlights the directives, versions and comments of go.mod and go.work.
+func highlightGoModLine(line []rune) []textStyle {
+	var parts []textStyle
+	first := true
+	for i, field := range splitFields(line) {
+		word := string(field)
+		style := styleBase
+		switch {
+		case strings.HasPrefix(word, "//"):
+			rest := slices.Concat(splitFields(line)[i:]...)
+			return append(parts, textStyle{text: rest, style: styleComment})
+		case unicode.IsSpace(field[0]):
+			parts = append(parts, textStyle{text: field, style: styleBase})
+			continue
+		case first && slices.Contains(goModKeywords, word), word == "=>":
+			style = styleKeyword
+		case strings.HasPrefix(word, `"`) || strings.HasPrefix(word, "`"):
+			style = styleString
+		case !first && isVersion(word):
+			style = styleNumber
+		}
+		first = false
+		parts = append(parts, textStyle{text: field, style: style})
+	}
+	return parts
+}
+
+// highlightGoSumLine highlights the versions and hashes of go.sum.
+func highlightGoSumLine(line []rune) []textStyle {
+	var parts []textStyle
+	n := 0 // index of the non-space field
+	for _, field := range splitFields(line) {
+		style := styleBase
+		if !unicode.IsSpace(field[0]) {
+			switch n {
+			case 1:
+				style = styleNumber
+			case 2:
+				style = styleComment
+			}
+			n++
+		}
+		parts = append(parts, textStyle{text: field, style: style})
+	}
+	return parts
+}
+
+// escapeModulePath escapes the upper case letters of the module path as the module cache does,
+// "!" followed by the lower case letter.
+func escapeModulePath(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// unescapeModulePath reverses escapeModulePath.
+func unescapeModulePath(path string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range path {
+		if r == '!' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// moduleDownloads returns the download directory of the module cache, empty if unknown.
+var moduleDownloads = sync.OnceValue(func() string {
+	dir := os.Getenv("GOMODCACHE")
+	if dir == "" {
+		out, err := exec.Command("go", "env", "GOMODCACHE").Output()
+		if err != nil {
+			return ""
+		}
+		dir = strings.TrimSpace(string(out))
+	}
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "cache", "download")
+})
+
+// compareVersions compares the semantic versions like "v1.2.3" and "v1.10.0-rc.1" by their numbers,
+// a pre-release before the release.
+func compareVersions(a, b string) int {
+	aCore, aPre, aHasPre := strings.Cut(strings.TrimPrefix(a, "v"), "-")
+	bCore, bPre, bHasPre := strings.Cut(strings.TrimPrefix(b, "v"), "-")
+	aNums, bNums := strings.Split(aCore, "."), strings.Split(bCore, ".")
+	for i := range max(len(aNums), len(bNums)) {
+		var x, y int
+		if i < len(aNums) {
+			x, _ = strconv.Atoi(aNums[i])
+		}
+		if i < len(bNums) {
+			y, _ = strconv.Atoi(bNums[i])
+		}
+		if x != y {
+			return cmp.Compare(x, y)
+		}
+	}
+	switch {
+	case aHasPre && !bHasPre:
+		return -1
+	case !aHasPre && bHasPre:
+		return 1
+	}
+	return strings.Compare(aPre, bPre)
+}
+
+// moduleCompletions returns the completions of the word at [start, col) of the go.mod line
+// from the module cache in downloads: the next path element of a module path,
+// or the versions of the module before the word, the latest first.
+func moduleCompletions(downloads string, line []rune, start, col int) []string {
+	if downloads == "" {
+		return nil
+	}
+	word := string(line[start:col])
+	fields := strings.Fields(string(line[:start]))
+	if len(fields) > 0 && fields[0] != "//" && (isVersion(word) || word == "") && strings.Contains(fields[len(fields)-1], ".") {
+		// the version after the module path
+		modPath := fields[len(fields)-1]
+		data, err := os.ReadFile(filepath.Join(downloads, escapeModulePath(modPath), "@v", "list"))
+		if err != nil {
+			return nil
+		}
+		versions := strings.Fields(string(data))
+		slices.SortFunc(versions, func(a, b string) int { return compareVersions(b, a) })
+		return versions
+	}
+	if word == "" || isVersion(word) {
+		return nil
+	}
+	// the next path element
+	dir, prefix := "", word
+	if i := strings.LastIndex(word, "/"); i >= 0 {
+		dir, prefix = word[:i+1], word[i+1:]
+	}
+	entries, err := os.ReadDir(filepath.Join(downloads, escapeModulePath(dir)))
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, e := range entries {
+		name := unescapeModulePath(e.Name())
+		if e.IsDir() && name != "@v" && strings.HasPrefix(name, prefix) {
+			paths = append(paths, dir+name)
+		}
+	}
+	return paths
+}
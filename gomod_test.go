@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// styledWords returns the non-space texts of the styled texts with the given style.
+func styledWords(parts []textStyle, style tcell.Style) []string {
+	var words []string
+	for _, p := range parts {
+		if p.style == style && string(p.text) != " " && string(p.text) != "\t" {
+			words = append(words, string(p.text))
+		}
+	}
+	return words
+}
+
+func TestHighlightGoModLine(t *testing.T) {
+	parts := highlightGoModLine([]rune("require example.com/m v1.2.3 // indirect"))
+	if got := styledWords(parts, styleKeyword); !reflect.DeepEqual(got, []string{"require"}) {
+		t.Errorf("keywords = %q", got)
+	}
+	if got := styledWords(parts, styleNumber); !reflect.DeepEqual(got, []string{"v1.2.3"}) {
+		t.Errorf("versions = %q", got)
+	}
+	if got := styledWords(parts, styleComment); !reflect.DeepEqual(got, []string{"// indirect"}) {
+		t.Errorf("comments = %q", got)
+	}
+	parts = highlightGoModLine([]rune("\tgo.example/go v0.1.0 => ../go"))
+	if got := styledWords(parts, styleKeyword); !reflect.DeepEqual(got, []string{"=>"}) {
+		t.Errorf("keywords in a block = %q", got)
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	versions := []string{"v1.10.0", "v1.2.0", "v1.10.0-rc.1", "v0.9.9", "v1.2.0-beta"}
+	slices.SortFunc(versions, compareVersions)
+	want := []string{"v0.9.9", "v1.2.0-beta", "v1.2.0", "v1.10.0-rc.1", "v1.10.0"}
+	if !reflect.DeepEqual(versions, want) {
+		t.Errorf("sorted = %q, want %q", versions, want)
+	}
+}
+
+func TestModuleCompletions(t *testing.T) {
+	downloads := t.TempDir()
+	for _, dir := range []string{"github.com/!burnt!sushi/toml/@v", "github.com/gdamore/tcell/v2/@v", "github.com/gdamore/encoding/@v"} {
+		if err := os.MkdirAll(filepath.Join(downloads, dir), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := os.WriteFile(filepath.Join(downloads, "github.com/gdamore/tcell/v2/@v/list"), []byte("v2.7.0\nv2.10.1\nv2.8.1\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"require github.com/gdamore/", []string{"github.com/gdamore/encoding", "github.com/gdamore/tcell"}},
+		{"\tgithub.com/B", []string{"github.com/BurntSushi"}},
+		{"\tgithub.com/gdamore/tcell/v2 v2.", []string{"v2.10.1", "v2.8.1", "v2.7.0"}},
+		{"\tgithub.com/none v", nil},
+	}
+	ft := fileTypeOf("go.mod")
+	for _, tt := range tests {
+		line := []rune(tt.line)
+		start := len(line)
+		for start > 0 && ft.isWordChar(line[start-1]) {
+			start--
+		}
+		if got := moduleCompletions(downloads, line, start, len(line)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("moduleCompletions(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
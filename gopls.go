@@ -0,0 +1,471 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lspSession is gopls started by >lsp, serving the Go files of the tabs.
+// The documents follow the changes recorded, see recordChange.
+type lspSession struct {
+	client   *lspClient
+	cmd      *exec.Cmd
+	mu       sync.Mutex   // guards versions and keeps the changes in order
+	versions map[*Tab]int // versions of the documents opened, by tab
+}
+
+// stdioConn is the connection to a server through its standard input and output.
+type stdioConn struct {
+	io.ReadCloser
+	io.WriteCloser
+}
+
+func (c stdioConn) Close() error {
+	c.WriteCloser.Close()
+	return c.ReadCloser.Close()
+}
+
+// isGoTab reports whether the tab is a Go file served by the language server.
+func isGoTab(t *Tab) bool {
+	return strings.HasSuffix(t.filename, ".go") && !t.readOnly
+}
+
+// open opens the document of the tab, or replaces its whole text if already open,
+// as when the file is loaded again.
+func (s *lspSession) open(t *Tab) {
+	if !isGoTab(t) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	uri := fileURI(t.filename)
+	var err error
+	if v, ok := s.versions[t]; ok {
+		s.versions[t] = v + 1
+		err = s.client.notify("textDocument/didChange", map[string]any{
+			"textDocument":   map[string]any{"uri": uri, "version": v + 1},
+			"contentChanges": []lspContentChange{{Text: t.text()}},
+		})
+	} else {
+		s.versions[t] = 1
+		err = s.client.notify("textDocument/didOpen", map[string]any{
+			"textDocument": map[string]any{"uri": uri, "languageId": "go", "version": 1, "text": t.text()},
+		})
+	}
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// change sends the change just applied to the tab, if its document is open.
+func (s *lspSession) change(t *Tab, c Change) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	v, ok := s.versions[t]
+	if !ok {
+		return
+	}
+	s.versions[t] = v + 1
+	var line []rune
+	if e := t.line(c.row); e != nil {
+		line = e.Value
+	}
+	err := s.client.notify("textDocument/didChange", map[string]any{
+		"textDocument":   map[string]any{"uri": fileURI(t.filename), "version": v + 1},
+		"contentChanges": []lspContentChange{changeEvent(line, c)},
+	})
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// save tells the server the document of the tab is saved.
+func (s *lspSession) save(t *Tab) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.versions[t]; ok {
+		s.client.notify("textDocument/didSave", map[string]any{"textDocument": map[string]any{"uri": fileURI(t.filename)}})
+	}
+}
+
+// close closes the document of the tab.
+func (s *lspSession) close(t *Tab) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.versions[t]; ok {
+		delete(s.versions, t)
+		s.client.notify("textDocument/didClose", map[string]any{"textDocument": map[string]any{"uri": fileURI(t.filename)}})
+	}
+}
+
+// stop shuts the server down, killing it if it does not exit in time.
+func (s *lspSession) stop() {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if s.client.request(ctx, "shutdown", nil, nil) == nil {
+		s.client.notify("exit", nil)
+	}
+	s.client.close()
+	stopKill := context.AfterFunc(ctx, func() { s.cmd.Process.Kill() })
+	s.cmd.Wait()
+	stopKill()
+}
+
+// lspCommand handles >lsp, starting or stopping gopls without argument.
+func (a *App) lspCommand(arg string) {
+	switch arg {
+	case "":
+		if a.s.lsp != nil {
+			a.stopLSP()
+			return
+		}
+		a.startLSP()
+	case "start":
+		a.startLSP()
+	case "stop":
+		a.stopLSP()
+	default:
+		a.showError("Unknown lsp command: " + arg)
+	}
+}
+
+// startLSP runs gopls for the working directory and opens the Go files of the tabs.
+// It runs in the command loop.
+func (a *App) startLSP() {
+	if a.s.lsp != nil {
+		a.showMessage("gopls is running, >lsp stop to stop it")
+		return
+	}
+	root, err := os.Getwd()
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	t := a.s.tasks.start("starting gopls", 0)
+	defer t.finish()
+	cmd := exec.Command("gopls")
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		a.showError("Failed to start gopls: " + err.Error())
+		return
+	}
+	s := &lspSession{cmd: cmd, versions: make(map[*Tab]int)}
+	s.client = newLSPClient(stdioConn{stdout, stdin}, func(method string, params json.RawMessage) {
+		a.lspNotification(method, params)
+	})
+	err = s.client.request(t.ctx, "initialize", map[string]any{
+		"processId": os.Getpid(),
+		"rootUri":   fileURI(root),
+		"clientInfo": map[string]any{
+			"name": "tino",
+		},
+		"capabilities": map[string]any{
+			"textDocument": map[string]any{
+				"synchronization":    map[string]any{"didSave": true},
+				"completion":         map[string]any{"completionItem": map[string]any{"snippetSupport": false}},
+				"hover":              map[string]any{"contentFormat": []string{"plaintext"}},
+				"publishDiagnostics": map[string]any{},
+			},
+		},
+	}, nil)
+	if err == nil {
+		err = s.client.notify("initialized", map[string]any{})
+	}
+	if t.ctx.Err() != nil {
+		go s.stop()
+		a.showMessage("Starting gopls canceled")
+		return
+	}
+	if err != nil {
+		go s.stop()
+		a.showError("Failed to start gopls: " + err.Error())
+		return
+	}
+	go func() {
+		<-s.client.done
+		postFunc(func() { a.endLSP(s, "gopls exited") })
+	}()
+	postFunc(func() {
+		a.s.lsp = s
+		for _, tab := range a.s.tabs {
+			s.open(tab)
+		}
+		a.showMessage("gopls started, ctrl-b goes to the definition, >hover shows the doc")
+	})
+}
+
+// stopLSP stops gopls.
+func (a *App) stopLSP() {
+	if a.s.lsp == nil {
+		a.showError("gopls is not running")
+		return
+	}
+	a.endLSP(a.s.lsp, "gopls stopped")
+}
+
+// endLSP clears the session if it is still the current one, and the diagnostics.
+func (a *App) endLSP(s *lspSession, msg string) {
+	if a.s.lsp != s {
+		return
+	}
+	a.s.lsp = nil
+	for _, t := range a.s.tabs {
+		if isGoTab(t) {
+			t.lineErrors = nil
+		}
+	}
+	a.drawEditor()
+	a.showMessage(msg)
+	go s.stop()
+}
+
+// lspNotification handles the notification of the server, it is called in the reading goroutine.
+func (a *App) lspNotification(method string, params json.RawMessage) {
+	switch method {
+	case "textDocument/publishDiagnostics":
+		var p struct {
+			URI         string          `json:"uri"`
+			Diagnostics []lspDiagnostic `json:"diagnostics"`
+		}
+		if err := json.Unmarshal(params, &p); err != nil {
+			log.Print(err)
+			return
+		}
+		postFunc(func() { a.showDiagnostics(uriPath(p.URI), p.Diagnostics) })
+	case "window/showMessage", "window/logMessage":
+		var p struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(params, &p)
+		log.Printf("gopls: %s", p.Message)
+	}
+}
+
+// diagnosticErrors returns the messages of the errors and warnings by row,
+// the first of each row.
+func diagnosticErrors(diags []lspDiagnostic) map[int]string {
+	errs := make(map[int]string)
+	for _, d := range diags {
+		if d.Severity > 2 {
+			continue
+		}
+		if _, ok := errs[d.Range.Start.Line]; !ok {
+			errs[d.Range.Start.Line] = d.Message
+		}
+	}
+	return errs
+}
+
+// showDiagnostics marks the rows of the file with errors or warnings in the gutter,
+// the status bar shows the message on the row of the cursor.
+func (a *App) showDiagnostics(path string, diags []lspDiagnostic) {
+	i := a.s.findFile(path)
+	if i < 0 || a.s.lsp == nil {
+		return
+	}
+	a.s.tabs[i].lineErrors = diagnosticErrors(diags)
+	if a.s.tabs[i] == a.s.Tab {
+		a.drawEditor()
+		a.syncCursor()
+	}
+}
+
+// lspPosition returns the document and position of the cursor for requests.
+func (st *State) lspPosition() map[string]any {
+	return map[string]any{
+		"textDocument": map[string]any{"uri": fileURI(st.filename)},
+		"position":     lspPositionOf(st.line(st.row).Value, st.row, st.col),
+	}
+}
+
+// lspReady reports whether gopls serves the current tab, telling why not otherwise.
+func (a *App) lspReady() bool {
+	if a.s.lsp == nil {
+		a.showError("gopls is not running, >lsp to start it")
+		return false
+	}
+	if !isGoTab(a.s.Tab) {
+		a.showError("Not a Go file")
+		return false
+	}
+	return true
+}
+
+// hover shows the documentation of the identifier at the cursor, in the "hover" tab
+// if it has many lines. It runs in the command loop.
+func (a *App) hover() {
+	a.s.focus = focusEditor
+	if !a.lspReady() {
+		return
+	}
+	t := a.s.tasks.start("hover", 0)
+	defer t.finish()
+	var result struct {
+		Contents struct {
+			Value string `json:"value"`
+		} `json:"contents"`
+	}
+	if err := a.s.lsp.client.request(t.ctx, "textDocument/hover", a.s.lspPosition(), &result); err != nil {
+		if t.ctx.Err() == nil {
+			a.showError(err.Error())
+		}
+		return
+	}
+	text := strings.TrimSpace(result.Contents.Value)
+	if text == "" {
+		a.showMessage("No documentation")
+		return
+	}
+	if !strings.Contains(text, "\n") {
+		a.showMessage(text)
+		return
+	}
+	postFunc(func() { a.updateBuffer("hover", text, true) })
+}
+
+// definition goes to the definition of the identifier at the cursor, opening its file
+// if needed. It runs in the command loop.
+func (a *App) definition() {
+	a.s.focus = focusEditor
+	if !a.lspReady() {
+		return
+	}
+	t := a.s.tasks.start("definition", 0)
+	defer t.finish()
+	var raw json.RawMessage
+	if err := a.s.lsp.client.request(t.ctx, "textDocument/definition", a.s.lspPosition(), &raw); err != nil {
+		if t.ctx.Err() == nil {
+			a.showError(err.Error())
+		}
+		return
+	}
+	locs := parseLocations(raw)
+	if len(locs) == 0 {
+		a.showMessage("No definition found")
+		return
+	}
+	postFunc(func() { a.goToLocation(locs[0]) })
+}
+
+// parseLocations decodes a location or a list of them, the results of requests like definition.
+func parseLocations(raw json.RawMessage) []lspLocation {
+	var locs []lspLocation
+	if json.Unmarshal(raw, &locs) == nil {
+		return locs
+	}
+	var loc lspLocation
+	if json.Unmarshal(raw, &loc) == nil && loc.URI != "" {
+		return []lspLocation{loc}
+	}
+	return nil
+}
+
+// goToLocation opens the file of the location and flashes its range.
+func (a *App) goToLocation(loc lspLocation) {
+	path := uriPath(loc.URI)
+	if path == "" {
+		a.showError("Unsupported location " + loc.URI)
+		return
+	}
+	a.recordPositon(a.s.row, a.s.col)
+	if !a.switchToFile(path) {
+		return
+	}
+	a.s.focus = focusEditor
+	start, end := loc.Range.Start, loc.Range.End
+	row := min(start.Line, a.s.lines.Len()-1)
+	line := a.s.line(row).Value
+	col := runeColumn(line, start.Character)
+	a.s.flash = nil
+	if end.Line == start.Line {
+		a.s.flash = &flashRange{row: row, start: col, end: runeColumn(line, end.Character)}
+	}
+	a.jump(row, col)
+	a.draw()
+	a.syncCursor()
+}
+
+// requestCompletion asks gopls to complete the word before the cursor at the end of
+// the line, showing the first candidate as the hint when it arrives, unless the cursor
+// moved or the text changed meanwhile.
+func (a *App) requestCompletion() {
+	s := a.s.lsp
+	if s == nil || !isGoTab(a.s.Tab) || a.s.hint != "" {
+		return
+	}
+	line := a.s.line(a.s.row).Value
+	if a.s.col != len(line) || a.s.col == 0 {
+		return
+	}
+	ft := fileTypeOf(a.s.filename)
+	start := a.s.col
+	for start > 0 && ft.isWordChar(line[start-1]) {
+		start--
+	}
+	if start == a.s.col && line[start-1] != '.' {
+		return
+	}
+	word := string(line[start:a.s.col])
+	tab, row, col, edits := a.s.Tab, a.s.row, a.s.col, a.s.edits
+	params := a.s.lspPosition()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		var raw json.RawMessage
+		if err := s.client.request(ctx, "textDocument/completion", params, &raw); err != nil {
+			log.Print(err)
+			return
+		}
+		labels := completionLabels(raw)
+		postFunc(func() {
+			if a.s.Tab != tab || a.s.row != row || a.s.col != col || a.s.edits != edits || a.s.hint != "" {
+				return
+			}
+			a.s.setHintFrom(labels, word)
+			if a.s.hint != "" {
+				a.drawEditorLine(row, a.s.line(row).Value)
+				a.syncCursor()
+			}
+		})
+	}()
+}
+
+// completionLabels returns the labels of the completion items in order, from a list
+// of items or a completion list.
+func completionLabels(raw json.RawMessage) []string {
+	type item struct {
+		Label    string `json:"label"`
+		SortText string `json:"sortText"`
+	}
+	var items []item
+	if json.Unmarshal(raw, &items) != nil {
+		var list struct {
+			Items []item `json:"items"`
+		}
+		json.Unmarshal(raw, &list)
+		items = list.Items
+	}
+	slices.SortStableFunc(items, func(a, b item) int { return strings.Compare(a.SortText, b.SortText) })
+	labels := make([]string, len(items))
+	for i, it := range items {
+		labels[i] = it.Label
+	}
+	return labels
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// templateKeywords are the keywords of text/template and html/template actions.
+var templateKeywords = []string{"if", "else", "end", "range", "with", "define", "template", "block", "break", "continue", "nil"}
+
+var styleTemplateAction = styleBase.Foreground(tcell.ColorTeal)
+
+// highlightTemplateLine highlights the {{ }} actions of Go templates, the text around them is plain.
+// An action or a comment spanning lines is not highlighted after its first line.
+func highlightTemplateLine(line []rune) []textStyle {
+	var parts []textStyle
+	text := string(line)
+	for {
+		open := strings.Index(text, "{{")
+		if open < 0 {
+			break
+		}
+		end := strings.Index(text[open:], "}}")
+		if end < 0 {
+			end = len(text)
+		} else {
+			end += open + 2
+		}
+		if open > 0 {
+			parts = append(parts, textStyle{text: []rune(text[:open]), style: styleBase})
+		}
+		parts = append(parts, highlightAction(text[open:end])...)
+		text = text[end:]
+	}
+	if text != "" {
+		parts = append(parts, textStyle{text: []rune(text), style: styleBase})
+	}
+	return parts
+}
+
+// highlightAction highlights the action from "{{" to "}}".
+func highlightAction(action string) []textStyle {
+	inner := strings.TrimPrefix(action, "{{")
+	if c := strings.TrimLeft(strings.TrimPrefix(inner, "-"), " "); strings.HasPrefix(c, "/*") {
+		return []textStyle{{text: []rune(action), style: styleComment}}
+	}
+	var parts []textStyle
+	var word strings.Builder
+	flush := func() {
+		if word.Len() == 0 {
+			return
+		}
+		style := styleTemplateAction
+		if w := word.String(); slices.Contains(templateKeywords, w) {
+			style = styleKeyword
+		}
+		parts = append(parts, textStyle{text: []rune(word.String()), style: style})
+		word.Reset()
+	}
+	runes := []rune(action)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '"' || r == '`':
+			flush()
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				if runes[j] == '\\' && r == '"' {
+					j++
+				}
+				j++
+			}
+			j = min(j+1, len(runes))
+			parts = append(parts, textStyle{text: runes[i:j], style: styleString})
+			i = j - 1
+		case r == '{' || r == '}':
+			flush()
+			parts = append(parts, textStyle{text: []rune{r}, style: styleKeyword})
+		case r == ' ' || r == '|' || r == '(' || r == ')':
+			flush()
+			parts = append(parts, textStyle{text: []rune{r}, style: styleTemplateAction})
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return parts
+}
@@ -0,0 +1,22 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHighlightTemplateLine(t *testing.T) {
+	parts := highlightTemplateLine([]rune(`<a href="{{.URL}}">{{if .Title}}{{printf "%s" .Title}}{{end}}</a>{{/* note */}}`))
+	if got := styledWords(parts, styleKeyword); !reflect.DeepEqual(got, []string{"{", "{", "}", "}", "{", "{", "if", "}", "}", "{", "{", "}", "}", "{", "{", "end", "}", "}"}) {
+		t.Errorf("keywords = %q", got)
+	}
+	if got := styledWords(parts, styleString); !reflect.DeepEqual(got, []string{`"%s"`}) {
+		t.Errorf("strings = %q", got)
+	}
+	if got := styledWords(parts, styleComment); !reflect.DeepEqual(got, []string{"{{/* note */}}"}) {
+		t.Errorf("comments = %q", got)
+	}
+	if got := styledWords(parts, styleBase); !reflect.DeepEqual(got, []string{`<a href="`, `">`, "</a>"}) {
+		t.Errorf("plain = %q", got)
+	}
+}
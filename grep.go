@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// maxGrepFileSize is the size of files beyond which >grep does not read.
+const maxGrepFileSize = 1 << 20
+
+// maxGrepMatches is the number of matches >grep stops at.
+const maxGrepMatches = 10000
+
+// indexFiles returns the paths relative to the root of the files under it, skipping
+// hidden files and hidden and excluded directories, as listed by quick open.
+func indexFiles(root string, exclude []string, t *task) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := t.ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() && (strings.HasPrefix(d.Name(), ".") || slices.Contains(exclude, d.Name())) {
+			return filepath.SkipDir
+		}
+		if strings.HasPrefix(d.Name(), ".") || d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, rel)
+		t.add(1)
+		return nil
+	})
+	return files, err
+}
+
+// grepFile appends the matches of the searcher in the file, skipping large and binary files.
+func grepFile(items []resultItem, root, name string, s *searcher) []resultItem {
+	path := filepath.Join(root, name)
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxGrepFileSize {
+		return items
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || bytes.IndexByte(data[:min(len(data), 512)], 0) >= 0 {
+		return items
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, maxGrepFileSize)
+	for row := 0; scanner.Scan(); row++ {
+		line := []rune(scanner.Text())
+		for col := 0; ; {
+			start, end := s.index(line, col)
+			if start < 0 {
+				break
+			}
+			items = append(items, resultItem{file: path, group: name, row: row, col: start, text: string(line)})
+			col = end
+		}
+	}
+	return items
+}
+
+// grep searches the files of quick open under the working directory for the keyword,
+// or the last search, in the background, and lists the matches in the "grep" tab.
+func (a *App) grep(keyword []rune) {
+	a.s.focus = focusEditor
+	if len(keyword) == 0 {
+		keyword = a.s.lastSearch
+	}
+	if len(keyword) == 0 {
+		a.showMessage("Usage: >grep text")
+		return
+	}
+	s, err := newSearcher(keyword, a.s.smartCase)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	a.s.lastSearch = keyword
+	root, err := filepath.Abs(".")
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	t := a.s.tasks.start("indexing files", 0)
+	exclude := a.s.excludeDirs
+	go func() {
+		files, err := indexFiles(root, exclude, t)
+		canceled := t.ctx.Err() != nil
+		t.finish()
+		if canceled {
+			return
+		}
+		if err != nil {
+			postFunc(func() { a.showError(err.Error()) })
+			return
+		}
+		t = a.s.tasks.start("grep", int64(len(files)))
+		defer t.finish()
+		var items []resultItem
+		for _, name := range files {
+			if t.ctx.Err() != nil {
+				return
+			}
+			items = grepFile(items, root, name, s)
+			t.add(1)
+			if len(items) >= maxGrepMatches {
+				break
+			}
+		}
+		postFunc(func() {
+			a.s.files = files
+			if len(items) == 0 {
+				a.showMessage("No match found")
+				return
+			}
+			header := fmt.Sprintf("%d matches of %s", len(items), string(keyword))
+			if len(items) >= maxGrepMatches {
+				header = fmt.Sprintf("first %d matches of %s", len(items), string(keyword))
+			}
+			a.showResults("grep", header, items)
+		})
+	}()
+}
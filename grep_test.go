@@ -0,0 +1,30 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGrepFile(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.go"), []byte("package a\n\nfunc foo() { foo() }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.bin"), []byte("foo\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, _ := newSearcher([]rune("/foo\\(/"), true)
+	var items []resultItem
+	for _, name := range []string{"a.go", "b.bin", "missing"} {
+		items = grepFile(items, dir, name, s)
+	}
+	if len(items) != 2 {
+		t.Fatalf("got %d matches, want 2: %v", len(items), items)
+	}
+	for i, col := range []int{5, 13} {
+		if items[i].group != "a.go" || items[i].row != 2 || items[i].col != col {
+			t.Errorf("match %d = %+v, want a.go at 3:%d", i, items[i], col+1)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// gutterMarker annotates a line in the gutter before the line number.
+type gutterMarker struct {
+	char  rune
+	style tcell.Style
+}
+
+// gutterSources return the marker of the row, such as bookmarks, diagnostics and diff.
+// The first source returning a marker wins.
+var gutterSources = []func(st *State, row int) (gutterMarker, bool){
+	errorGutter,
+	quickfixGutter,
+	markGutter,
+	annotationGutter,
+}
+
+var (
+	styleErrorGutter = styleBase.Foreground(tcell.ColorRed).Bold(true)
+	styleMarkGutter  = styleBase.Foreground(tcell.ColorDodgerBlue).Bold(true)
+)
+
+// errorGutter marks the rows having errors.
+func errorGutter(st *State, row int) (gutterMarker, bool) {
+	if _, ok := st.lineErrors[row]; ok {
+		return gutterMarker{char: '!', style: styleErrorGutter}, true
+	}
+	return gutterMarker{}, false
+}
+
+// markGutter marks the rows of the marks in current tab with the mark name,
+// the first name in order if there are many.
+func markGutter(st *State, row int) (gutterMarker, bool) {
+	var name rune
+	for _, marks := range []map[rune]*mark{st.Tab.marks, st.marks} {
+		for n, m := range marks {
+			if m.tab == st.Tab && m.row == row && (name == 0 || n < name) {
+				name = n
+			}
+		}
+	}
+	if name == 0 {
+		return gutterMarker{}, false
+	}
+	return gutterMarker{char: name, style: styleMarkGutter}, true
+}
+
+// annotate places the marker at the row on behalf of the owner, such as breakpoints
+// of a debugger or lines of a coverage profile. The markers shift with the edited lines.
+func (t *Tab) annotate(owner string, row int, m gutterMarker) {
+	if t.annotations == nil {
+		t.annotations = make(map[string]map[int]gutterMarker)
+	}
+	if t.annotations[owner] == nil {
+		t.annotations[owner] = make(map[int]gutterMarker)
+	}
+	t.annotations[owner][row] = m
+}
+
+// unannotate removes the marker of the owner at the row.
+func (t *Tab) unannotate(owner string, row int) {
+	delete(t.annotations[owner], row)
+}
+
+// clearAnnotations removes all markers of the owner.
+func (t *Tab) clearAnnotations(owner string) {
+	delete(t.annotations, owner)
+}
+
+// shiftAnnotations moves the annotated rows according to the change.
+func (t *Tab) shiftAnnotations(c Change) {
+	if strings.Count(c.oldText, "\n") == strings.Count(c.newText, "\n") {
+		return
+	}
+	for owner, markers := range t.annotations {
+		shifted := make(map[int]gutterMarker, len(markers))
+		for row, m := range markers {
+			if row > c.row {
+				row, _ = c.shift(row, 0)
+			}
+			shifted[row] = m
+		}
+		t.annotations[owner] = shifted
+	}
+}
+
+// annotationGutter marks the rows annotated by integrations, the first owner in order wins.
+func annotationGutter(st *State, row int) (gutterMarker, bool) {
+	owners := make([]string, 0, len(st.annotations))
+	for owner, markers := range st.annotations {
+		if _, ok := markers[row]; ok {
+			owners = append(owners, owner)
+		}
+	}
+	if len(owners) == 0 {
+		return gutterMarker{}, false
+	}
+	return st.annotations[slices.Min(owners)][row], true
+}
+
+// gutterMarkerOf returns the marker of the row from the gutter sources.
+func (st *State) gutterMarkerOf(row int) (gutterMarker, bool) {
+	for _, source := range gutterSources {
+		if m, ok := source(st, row); ok {
+			return m, true
+		}
+	}
+	return gutterMarker{}, false
+}
+
+// withGutterMarker puts the marker of the row over the leading space of the line number.
+func (st *State) withGutterMarker(lineNum textStyle, row int) []textStyle {
+	m, ok := st.gutterMarkerOf(row)
+	if !ok || len(lineNum.text) == 0 {
+		return []textStyle{lineNum}
+	}
+	return []textStyle{
+		{text: []rune{m.char}, style: m.style},
+		{text: lineNum.text[1:], style: lineNum.style},
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// keyBindings are the built-in keys not bound to a console command.
+var keyBindings = []struct {
+	keys string
+	desc string
+}{
+	{"ctrl-o", "open file"},
+	{"ctrl-s", "save file"},
+	{"ctrl-q", "quit"},
+	{"ctrl-t", "new tab"},
+	{"ctrl-w", "close tab"},
+	{"ctrl-f", "find"},
+	{"ctrl-c", "copy"},
+	{"ctrl-x", "cut"},
+	{"ctrl-v", "paste"},
+	{"ctrl-z", "undo"},
+	{"ctrl-y", "redo"},
+	{"ctrl-_", "go back"},
+	{"ctrl-g", "go to line"},
+	{"ctrl-r", "go to symbol"},
+	{"ctrl-a", "go to line start"},
+	{"ctrl-e", "go to line end"},
+	{"ctrl-b", "go to symbol under the cursor, also in the package and the imported packages, or its definition by gopls"},
+	{"ctrl-u", "delete back to line start"},
+	{"ctrl-p", "command"},
+	{"ctrl-l", "redraw the screen"},
+	{"shift-tab", "decrease indent"},
+	{"insert", "toggle overwrite mode"},
+	{"alt-.", "repeat the last edit"},
+	{"alt-enter", "break the line without continuing the comment or list item, open a result, file or symbol in the adjacent pane"},
+	{"esc", "cancel the running operation, such as indexing or saving"},
+	{"f1", "help"},
+	{"f10", "menu"},
+}
+
+// commands are the console commands run after the '>' prefix.
+var commands = []struct {
+	name string
+	args string
+	desc string
+}{
+	{"open", "<file>", "open the file, a new Go file starts with its package clause"},
+	{"save", "<file>", "save to the file"},
+	{"help", "", "show this help"},
+	{"settings", "", "edit the settings, saving the tab applies them"},
+	{"theme", "[light|dark]", "switch the color theme, show the current one without name"},
+	{"new", "", "open an empty tab after the current one"},
+	{"menu", "", "open the menu, also by clicking the button at the right of the tabbar"},
+	{"linenumber", "", "toggle line number"},
+	{"ambiwidth", "1|2", "width of East Asian ambiguous characters"},
+	{"emojiwidth", "1|2", "width of emoji"},
+	{"smartcase", "", "toggle smart case searching"},
+	{"inlayhints", "", "toggle parameter names before call arguments"},
+	{"json", "fmt|check", "format or validate the JSON of the selected lines or the tab"},
+	{"yaml", "check", "check the YAML of the selected lines or the tab for common mistakes"},
+	{"bench", "[pattern]", "run the benchmarks of the package with CPU and memory profiles"},
+	{"pprof", "[cpu|mem]", "show the top entries of the profile of the last benchmark"},
+	{"reload", "", "reload the file, picking the hunks if the tab has unsaved changes"},
+	{"suspend", "", "suspend to the shell, fg resumes"},
+	{"reopen", "", "reopen the most recently closed tab at its cursor position"},
+	{"man", "[section] topic", "show the man page, alt-up/down or @ go to its sections"},
+	{"wq", "", "save the tab and quit, to finish a commit message"},
+	{"count", "", "show the lines, words, characters and bytes of the selection or the tab"},
+	{"tail", "", "follow the lines appended to the file like tail -f, again to stop"},
+	{"todos", "", "list the TODO, FIXME and HACK comments of the workspace"},
+	{"keys", "", "show the keys pressed as delivered by the terminal, escape twice to stop"},
+	{"share", "[addr]", "share the tab for another tinotext to join and edit together, again to end"},
+	{"join", "[addr]", "join the tab shared at the address in a new tab"},
+	{"testgen", "", "generate a table-driven test of the function at the cursor in the test file"},
+	{"extractvar", "[name]", "declare the selected Go expression as a variable before its statement, x by default"},
+	{"extractfunc", "[name]", "move the selected Go statements to a function called instead, newFunction by default"},
+	{"alt", "", "switch between the Go file and its test, creating the missing one"},
+	{"lint", "", "lint the shell script with shellcheck or the Dockerfile with hadolint"},
+	{"calls", "[out]", "show the callers of the function under the cursor, or the calls in it"},
+	{"virtualspace", "", "toggle moving the cursor beyond line ends"},
+	{"overwrite", "", "toggle overwrite mode"},
+	{"repeat", "", "repeat the last edit"},
+	{"indent", "[count]", "indent the selected lines or current line by count levels"},
+	{"unindent", "[count]", "unindent the selected lines or current line by count levels"},
+	{"task", "[name]", "run the task of the project, or list the tasks"},
+	{"generate", "", "run go generate ./... as a task"},
+	{"make", "[target]", "run make as a task, tab completes the targets of the Makefile"},
+	{"nexterror", "", "go to the next location reported by the last task, also f4"},
+	{"preverror", "", "go to the previous location reported by the last task, also shift-f4"},
+	{"debug", "[main|test|stop|vars]", "debug the main package or the tests of current package with Delve, vars shows the variables and output"},
+	{"debug", "continue|next|step|stepout", "resume the stopped program, also f5, f6, f7 and f8"},
+	{"lsp", "[start|stop]", "start or stop gopls for completion, diagnostics, hover and definition in Go files"},
+	{"hover", "", "show the documentation of the identifier under the cursor by gopls"},
+	{"definition", "", "go to the definition of the identifier under the cursor by gopls, also ctrl-b"},
+	{"references", "", "list the references of the identifier under the cursor in the files of ctrl-o, also ctrl-k r"},
+	{"breakpoint", "", "toggle the breakpoint at the cursor, also f9 or clicking the left edge of the gutter"},
+	{"selectline", "", "select the current line, again to extend by the next line, shift-up/down extend by lines"},
+	{"reselect", "", "select again the last selection dropped or replaced, like by a search"},
+	{"expand", "", "select the enclosing Go expression, statement, block or function, also alt-right"},
+	{"shrink", "", "go back to the selection before the last >expand, also alt-left"},
+	{"undosave", "", "undo or redo to the text of the last save"},
+	{"changes", "", "list the undo history, enter undoes or redoes to a change"},
+	{"scratch", "", "open a throwaway tab that is never dumped or restored"},
+	{"paste", "", "paste the clipboard verbatim"},
+	{"pasteindent", "", "paste the clipboard re-indented to the indentation at the cursor"},
+	{"copyappend", "", "append the selection or current line to the clipboard"},
+	{"easymotion", "", "label the visible words and jump to the typed label"},
+	{"mark", "<name>", "put the mark at the cursor, a-z for the tab and A-Z for global"},
+	{"marks", "", "list the marks"},
+	{"date", "[layout]", "insert the current time, layout is date, time, datetime, rfc3339, rfc1123, unix or a Go layout"},
+	{"uuid", "", "insert a random UUID"},
+	{"vim", "", "toggle vim-style modal editing"},
+	{"mouse", "on|off", "turn off to select text with the terminal"},
+	{"scrollbind", "", "toggle locking the scroll of the tab with other bound tabs"},
+	{"messages", "", "show the history of status messages"},
+	{"findall", "[text]", "put a cursor on every match of the text or the last search"},
+	{"selectnext", "", "select the word under the cursor, then add a cursor at its next occurrence"},
+	{"let", "[[b:]name [value]]", "set a variable, global or of the tab with b:, show it without value, list all without name"},
+	{"unlet", "name", "remove the variable"},
+	{"findtabs", "[text]", "list the matches of the text or the last search in all open tabs"},
+	{"split", "", "split the editor into stacked panes, each showing a tab with its own cursor and scroll"},
+	{"vsplit", "", "split the editor into panes side by side"},
+	{"nextpane", "", "focus the next pane"},
+	{"movetab", "", "move the tab to the adjacent pane, splitting side by side if not split"},
+	{"resizepane", "+N|-N", "grow or shrink the focused pane by N rows or columns"},
+	{"closepane", "", "close the focused pane"},
+	{"grep", "[text]", "list the matches of the text or the last search in the files of ctrl-o"},
+	{"replace", "old [new]", "replace the match found by # or the next one, quote as Go strings for spaces"},
+	{"replaceall", "old [new]", "replace every match in the tab, undone as one"},
+	{"back", "", "go back"},
+	{"forward", "", "go forward"},
+	{"nextparagraph", "", "go to the next blank line separating blocks"},
+	{"prevparagraph", "", "go to the previous blank line separating blocks"},
+	{"nextdecl", "", "go to the next top-level declaration of Go file"},
+	{"prevdecl", "", "go to the previous top-level declaration of Go file"},
+	{"declup", "", "move the top-level declaration with its doc comment before the previous one, also ctrl-k up"},
+	{"decldown", "", "move the top-level declaration with its doc comment after the next one, also ctrl-k down"},
+}
+
+// consolePrefixes are the prefixes that tell the console what to do.
+var consolePrefixes = []struct {
+	prefix string
+	desc   string
+}{
+	{"#<text>", "find text, case sensitive only if the text has upper case letters"},
+	{"#/<regexp>/", "find a match of the Go regexp, ^ and $ match the start and end of a line"},
+	{"#s/<regexp>/<template>/", "replace every match of the regexp in the tab, $1 or ${name} in the template for the groups"},
+	{"@<symbol>", "go to symbol by fuzzy match, t.lin matches Tab.line, prefix func:, type:, var:, const: or field: to filter by kind"},
+	{":<line>", "go to line"},
+	{":<range> <op>", "run del, yank, sort or uniq on the lines, e.g. :10,20 sort, :.,+5 del, :% yank"},
+	{"'<mark>", "go to mark"},
+	{"=<expression>", "evaluate arithmetic and bitwise expression like 0x1F * 3, alt-enter inserts the result"},
+	{"><command>", "run command"},
+}
+
+// helpText generates the help documenting key bindings, commands and console prefixes.
+// Keys of commands come from the current key sequences, so they reflect remapping.
+func helpText() string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "Key bindings:")
+	for _, k := range keyBindings {
+		fmt.Fprintf(w, "  %s\t%s\n", k.keys, k.desc)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "Commands, run with ctrl-p:")
+	for _, c := range commands {
+		var keys []string
+		for seq, cmd := range chords {
+			if cmd == ">"+c.name {
+				keys = append(keys, seq)
+			}
+		}
+		slices.Sort(keys)
+		name := ">" + c.name
+		if c.args != "" {
+			name += " " + c.args
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", name, strings.Join(keys, ", "), c.desc)
+	}
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "Console prefixes:")
+	for _, p := range consolePrefixes {
+		fmt.Fprintf(w, "  %s\t%s\n", p.prefix, p.desc)
+	}
+	w.Flush()
+	return b.String()
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// maxExcerpt is the number of runes of the text of a change listed by >changes.
+const maxExcerpt = 30
+
+// undoTo undoes or redoes the changes until n of them are applied, n being
+// at the end of an undo step. The selection and the extra cursors are dropped,
+// being positions in the text before.
+func (st *State) undoTo(n int) {
+	for st.applied() > n {
+		st.undo()
+	}
+	for st.applied() < n {
+		st.redo()
+	}
+	st.selection = nil
+	st.lineSelect = false
+	st.cursors = nil
+}
+
+// undoSteps returns the number of changes applied after each undo step, a change or
+// a group of them undone as one, in order.
+func undoSteps(changes []Change) []int {
+	var ends []int
+	for i, c := range changes {
+		if c.group == 0 || i == len(changes)-1 || changes[i+1].group != c.group {
+			ends = append(ends, i+1)
+		}
+	}
+	return ends
+}
+
+// excerpt returns the text quoted, cut to maxExcerpt runes.
+func excerpt(text string) string {
+	runes := []rune(text)
+	if len(runes) > maxExcerpt {
+		return fmt.Sprintf("%q…", string(runes[:maxExcerpt]))
+	}
+	return fmt.Sprintf("%q", text)
+}
+
+// changeText describes the change by its time, kind, position and text.
+func changeText(c Change) string {
+	var kind, text string
+	switch c.kind {
+	case editInsert:
+		kind, text = "insert", excerpt(c.newText)
+	case editDelete:
+		kind, text = "delete", excerpt(c.oldText)
+	default:
+		kind, text = "replace", excerpt(c.oldText)+" → "+excerpt(c.newText)
+	}
+	return fmt.Sprintf("%s  %-7s  %d:%d  %s", c.time.Format("15:04:05"), kind, c.row+1, c.col+1, text)
+}
+
+// historyText lists the undo steps of the changes from the oldest, after the original
+// text, the applied one marked with "*". It returns the number of changes applied
+// after the step of each line, -1 if none.
+func historyText(header string, changes []Change, applied int) (string, []int) {
+	var b strings.Builder
+	b.WriteString(header)
+	rows := []int{-1}
+	mark := func(n int) string {
+		if n == applied {
+			return "*"
+		}
+		return " "
+	}
+	fmt.Fprintf(&b, "\n%s    0  original", mark(0))
+	rows = append(rows, 0)
+	start := 0
+	for i, end := range undoSteps(changes) {
+		fmt.Fprintf(&b, "\n%s %4d  %s", mark(end), i+1, changeText(changes[start]))
+		if n := end - start; n > 1 {
+			fmt.Fprintf(&b, " and %d more", n-1)
+		}
+		rows = append(rows, end)
+		start = end
+	}
+	return b.String(), rows
+}
+
+// showChanges lists the undo history of the tab in the "changes" tab, enter on a step
+// undoes or redoes the tab to the text after it.
+func (a *App) showChanges() {
+	a.s.focus = focusEditor
+	t := a.s.Tab
+	if len(t.changes) == 0 {
+		a.showMessage("No change")
+		return
+	}
+	header := fmt.Sprintf("changes of %s, enter goes to the text after one", t.name())
+	text, rows := historyText(header, t.changes, t.applied())
+	if i := a.s.findBuffer("changes"); i >= 0 {
+		a.s.closeTab(i)
+	}
+	a.openBuffer("changes", text)
+	a.jump(min(slices.Index(rows, t.applied()), a.s.lines.Len()-1), 0)
+	a.drawEditor()
+	a.s.onKey = func(a *App, ev *tcell.EventKey) bool {
+		if ev.Key() != tcell.KeyEnter || a.s.row >= len(rows) || rows[a.s.row] < 0 {
+			return false
+		}
+		a.goToChange(t, rows[a.s.row], header)
+		return true
+	}
+}
+
+// goToChange switches to the tab and undoes or redoes it until n changes are applied,
+// moving the cursor to the last of them, then marks the step in the "changes" tab.
+func (a *App) goToChange(t *Tab, n int, header string) {
+	i := slices.Index(a.s.tabs, t)
+	if i < 0 {
+		a.showError("The tab is closed")
+		return
+	}
+	if n > len(t.changes) {
+		a.showError("The change is no longer in the history, >changes again")
+		return
+	}
+	a.s.switchTab(i)
+	if !a.editable() {
+		return
+	}
+	a.s.undoTo(n)
+	c := t.changes[max(n-1, 0)]
+	row := min(c.row, a.s.lines.Len()-1)
+	a.jump(row, min(c.col, len(a.s.line(row).Value)))
+	a.draw()
+	text, _ := historyText(header, t.changes, t.applied())
+	a.updateBuffer("changes", text, false)
+	a.showMessage(fmt.Sprintf("At change %d of %d", n, len(t.changes)))
+}
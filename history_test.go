@@ -0,0 +1,78 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestHistoryText(t *testing.T) {
+	at := time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC)
+	changes := []Change{
+		{row: 0, col: 0, newText: "foo", kind: editInsert, time: at},
+		{row: 1, col: 2, oldText: "a\nb", newText: "c", kind: editReplace, time: at, group: 1},
+		{row: 2, col: 0, oldText: "x", kind: editDelete, time: at, group: 1},
+		{row: 3, col: 0, oldText: strings.Repeat("y", 40), kind: editDelete, time: at},
+	}
+	if got, want := undoSteps(changes), []int{1, 3, 4}; !slices.Equal(got, want) {
+		t.Errorf("undoSteps = %v, want %v", got, want)
+	}
+	text, rows := historyText("changes", changes, 3)
+	want := "changes\n" +
+		"     0  original\n" +
+		"     1  15:04:05  insert   1:1  \"foo\"\n" +
+		"*    2  15:04:05  replace  2:3  \"a\\nb\" → \"c\" and 1 more\n" +
+		"     3  15:04:05  delete   4:1  \"" + strings.Repeat("y", maxExcerpt) + "\"…"
+	if text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+	if want := []int{-1, 0, 1, 3, 4}; !slices.Equal(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+}
+
+func TestUndoTo(t *testing.T) {
+	st := &State{Tab: &Tab{}}
+	st.loadSource(strings.NewReader("foo foo\nbar"))
+	st.replaceAll([]rune("foo"), []rune("x"))
+	st.substitute(regexp.MustCompile(`bar`), "baz")
+	final := st.text()
+	st.undoTo(0)
+	if got, want := st.text(), "foo foo\nbar\n"; got != want {
+		t.Errorf("text at 0 = %q, want %q", got, want)
+	}
+	st.undoTo(len(st.changes))
+	if got := st.text(); got != final {
+		t.Errorf("text at the end = %q, want %q", got, final)
+	}
+}
+
+func TestUndoSaveDropsSelection(t *testing.T) {
+	for _, viaChanges := range []bool{false, true} {
+		a := newTestApp(t, "x\n")
+		a.s.markSaved()
+		a.jump(0, 1)
+		a.paste(" and more\nlines\nto undo")
+		a.jump(0, 0)
+		a.selectLine()
+		a.extendLines(2)
+		a.s.cursors = []Selection{{startRow: 2, startCol: 0, endRow: 2, endCol: 2}, {startRow: 1, startCol: 0, endRow: 1, endCol: 5}}
+		if viaChanges {
+			a.goToChange(a.s.Tab, 0, "changes")
+		} else {
+			a.handleCommand(">undosave")
+		}
+		if got := a.s.text(); got != "x\n" {
+			t.Errorf("text = %q, want the saved one", got)
+		}
+		if a.s.selected() != nil || a.s.selectingLines() || a.s.cursors != nil {
+			t.Errorf("selection %v and cursors %v left", a.s.selection, a.s.cursors)
+		}
+		a.syncCursor()
+		a.editorEvent(tcell.NewEventKey(tcell.KeyBackspace2, 0, tcell.ModNone))
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// indentWidth returns the length of one indent level at the start of the line,
+// a tab or up to the indent unit of spaces.
+func (st *State) indentWidth(line []rune) int {
+	if len(line) > 0 && line[0] == '\t' {
+		return 1
+	}
+	n := tabSize
+	if st.indent != "\t" {
+		n = len(st.indent)
+	}
+	i := 0
+	for i < n && i < len(line) && line[i] == ' ' {
+		i++
+	}
+	return i
+}
+
+// shiftLines indents the rows by levels of the indent unit, or unindents them if levels is negative.
+// Empty lines are left alone. The cursor and the selection stay with the text.
+func (a *App) shiftLines(startRow, endRow, levels int) {
+	a.s.beginGroup()
+	defer a.s.endGroup()
+	e := a.s.line(startRow)
+	for row := startRow; row <= endRow && e != nil; row, e = row+1, e.Next() {
+		line := e.Value
+		var c Change
+		if levels > 0 {
+			if len(line) == 0 {
+				continue
+			}
+			text := strings.Repeat(a.s.indent, levels)
+			e.Value = slices.Concat([]rune(text), line)
+			c = Change{row: row, col: 0, newText: text, kind: editInsert}
+		} else {
+			n := 0
+			for range -levels {
+				n += a.s.indentWidth(line[n:])
+			}
+			if n == 0 {
+				continue
+			}
+			e.Value = line[n:]
+			c = Change{row: row, col: 0, oldText: string(line[:n]), kind: editDelete}
+		}
+		a.s.recordChange(c)
+		a.s.row, a.s.col = c.shift(a.s.row, a.s.col)
+		if sel := a.s.selection; sel != nil {
+			sel.startRow, sel.startCol = c.shift(sel.startRow, sel.startCol)
+			sel.endRow, sel.endCol = c.shift(sel.endRow, sel.endCol)
+		}
+	}
+	a.jump(a.s.row, a.s.col)
+	a.drawEditor()
+}
+
+// shiftSelected shifts the lines of the selection, or current line if nothing selected.
+// A selection ending at the start of a line leaves that line alone.
+func (a *App) shiftSelected(levels int) {
+	sel := a.s.selected()
+	if sel == nil {
+		a.shiftLines(a.s.row, a.s.row, levels)
+		return
+	}
+	endRow := sel.endRow
+	if sel.endCol == 0 && endRow > sel.startRow {
+		endRow--
+	}
+	a.shiftLines(sel.startRow, endRow, levels)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var styleInlay = styleBase.Foreground(tcell.ColorDarkGray).Italic(true)
+
+// inlay is a hint drawn before the column of a line, not part of the text.
+type inlay struct {
+	col  int
+	text string
+}
+
+// paramName returns the name of the parameter like "row int", empty if unnamed.
+func paramName(param string) string {
+	name, _, ok := strings.Cut(param, " ")
+	if !ok || name == "_" {
+		return ""
+	}
+	return name
+}
+
+// paramInlays returns the parameter names before the arguments of the calls in the line,
+// to functions found by the lookup. An argument spelled like its parameter has no hint,
+// nor has a function declaration.
+func paramInlays(line []rune, lookup func(name string) (Symbol, bool)) []inlay {
+	if strings.HasPrefix(strings.TrimSpace(string(line)), "func ") {
+		return nil
+	}
+	type call struct {
+		sym   Symbol
+		ok    bool
+		arg   int
+		start int // column of the argument
+	}
+	var inlays []inlay
+	var calls []call // the open brackets, not every one is a call
+	// hint adds the name of the argument ended at the column
+	hint := func(c call, end int) {
+		if !c.ok || c.arg >= len(c.sym.Params) {
+			return
+		}
+		arg := strings.TrimSpace(string(line[c.start:end]))
+		name := paramName(c.sym.Params[c.arg])
+		if arg == "" || name == "" || arg == name || strings.HasSuffix(arg, "."+name) {
+			return
+		}
+		col := c.start
+		for line[col] == ' ' || line[col] == '\t' {
+			col++
+		}
+		inlays = append(inlays, inlay{col: col, text: name + ": "})
+	}
+	var inString rune
+	for i := 0; i < len(line); i++ {
+		r := line[i]
+		if inString != 0 {
+			if r == '\\' && inString != '`' {
+				i++
+			} else if r == inString {
+				inString = 0
+			}
+			continue
+		}
+		switch r {
+		case '"', '\'', '`':
+			inString = r
+		case '/':
+			if i+1 < len(line) && line[i+1] == '/' {
+				return inlays
+			}
+		case '(', '[', '{':
+			var c call
+			if r == '(' {
+				start := i
+				for start > 0 && isIdentRune(line[start-1]) {
+					start--
+				}
+				c.sym, c.ok = lookup(string(line[start:i]))
+			}
+			c.start = i + 1
+			calls = append(calls, c)
+		case ',':
+			if n := len(calls); n > 0 {
+				hint(calls[n-1], i)
+				calls[n-1].arg++
+				calls[n-1].start = i + 1
+			}
+		case ')', ']', '}':
+			if n := len(calls); n > 0 {
+				hint(calls[n-1], i)
+				calls = calls[:n-1]
+			}
+		}
+	}
+	// the arguments continued on next lines
+	for _, c := range calls {
+		hint(c, len(line))
+	}
+	slices.SortFunc(inlays, func(a, b inlay) int { return a.col - b.col })
+	return inlays
+}
+
+// lookupFunc finds the function of the name in the symbol index.
+func (st *State) lookupFunc(name string) (Symbol, bool) {
+	for _, sym := range st.symbols[name] {
+		if sym.Kind == SymbolFunc {
+			return sym, true
+		}
+	}
+	return Symbol{}, false
+}
+
+// overlayInlays inserts the parameter hints of the line into the styled texts.
+// The cursor line has no hints so the cursor stays where the text is.
+func (a *App) overlayInlays(texts []textStyle, row int, line []rune) []textStyle {
+	if row == a.s.row {
+		return texts
+	}
+	inlays := paramInlays(line, a.s.lookupFunc)
+	if len(inlays) == 0 {
+		return texts
+	}
+	var runes []textStyle
+	for _, ts := range texts {
+		for _, r := range ts.text {
+			runes = append(runes, textStyle{text: []rune{r}, style: ts.style})
+		}
+	}
+	// insert from the end so the columns before stay valid
+	for i := len(inlays) - 1; i >= 0; i-- {
+		col := columnToVisual(line, inlays[i].col) - a.s.left
+		if col < 0 || col > len(runes) {
+			continue
+		}
+		runes = slices.Insert(runes, col, textStyle{text: []rune(inlays[i].text), style: styleInlay})
+	}
+	return runes
+}
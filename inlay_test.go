@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParamInlays(t *testing.T) {
+	funcs := map[string]Symbol{
+		"jump":  {Name: "jump", Params: []string{"row int", "col int"}},
+		"print": {Name: "print", Params: []string{"a ...any"}},
+		"anon":  {Name: "anon", Params: []string{"int"}},
+	}
+	lookup := func(name string) (Symbol, bool) {
+		sym, ok := funcs[name]
+		return sym, ok
+	}
+	tests := []struct {
+		line string
+		want []inlay
+	}{
+		{"a.jump(1, 2)", []inlay{{7, "row: "}, {10, "col: "}}},
+		{"jump(row, a.col)", nil},
+		{"jump(f(1, 2), x)", []inlay{{5, "row: "}, {14, "col: "}}},
+		{`jump(len(","), 0) // jump(1, 2)`, []inlay{{5, "row: "}, {15, "col: "}}},
+		{"jump(", nil},
+		{"jump(1,", []inlay{{5, "row: "}}},
+		{"print(1)", []inlay{{6, "a: "}}},
+		{"anon(1)", nil},
+		{"other(1)", nil},
+		{"func (a *App) jump(row, col int) {}", nil},
+	}
+	for _, tt := range tests {
+		if got := paramInlays([]rune(tt.line), lookup); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("paramInlays(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// chords binds key sequences to console commands,
+// keys in a sequence are separated by a space.
+var chords = map[string]string{
+	"ctrl-k ctrl-l": ">linenumber",
+	"ctrl-k ctrl-o": ">overwrite",
+	"ctrl-k ctrl-v": ">virtualspace",
+	"ctrl-k ctrl-a": ">findall",
+	"ctrl-d":        ">selectnext",
+	"ctrl-k ctrl-b": ">back",
+	"ctrl-k ctrl-f": ">forward",
+	"ctrl-k ctrl-r": ">repeat",
+	"ctrl-k ctrl-j": ">easymotion",
+	"ctrl-k ctrl-c": ">copyappend",
+	"ctrl-k ctrl-p": ">pasteindent",
+	"ctrl-k ctrl-s": ">selectline",
+	"ctrl-k ctrl-g": ">reselect",
+	"ctrl-k ctrl-t": ">alt",
+	"ctrl-k ctrl-x": ">wq",
+	"ctrl-k ctrl-z": ">suspend",
+	"ctrl-k ctrl-w": ">nextpane",
+	"ctrl-k h":      ">hover",
+	"ctrl-k r":      ">references",
+	"ctrl-k m":      ">movetab",
+	"ctrl-k up":     ">declup",
+	"ctrl-k down":   ">decldown",
+	"ctrl-k =":      ">resizepane +2",
+	"ctrl-k -":      ">resizepane -2",
+	"shift-ctrl-t":  ">reopen",
+	"ctrl-down":     ">nextparagraph",
+	"ctrl-up":       ">prevparagraph",
+	"alt-down":      ">nextdecl",
+	"alt-up":        ">prevdecl",
+	"alt-right":     ">expand",
+	"alt-left":      ">shrink",
+}
+
+// chordTimeout is how long a pending prefix waits for the next key.
+const chordTimeout = 2 * time.Second
+
+// chordTimeoutEvent is posted as interrupt data when a pending prefix may have expired.
+type chordTimeoutEvent struct{}
+
+// keyName returns the name of the key event as written in key bindings, e.g. "ctrl-k", "alt-.", "f1".
+func keyName(ev *tcell.EventKey) string {
+	var name string
+	if ev.Key() == tcell.KeyRune {
+		name = string(ev.Rune())
+	} else if n, ok := tcell.KeyNames[ev.Key()]; ok {
+		name = strings.ToLower(n)
+	} else {
+		name = fmt.Sprintf("key%d", ev.Key())
+	}
+	mod := ev.Modifiers()
+	if strings.HasPrefix(name, "ctrl-") {
+		mod &^= tcell.ModCtrl
+	}
+	if mod&tcell.ModCtrl != 0 {
+		name = "ctrl-" + name
+	}
+	if mod&tcell.ModShift != 0 && ev.Key() != tcell.KeyRune {
+		name = "shift-" + name
+	}
+	if mod&tcell.ModAlt != 0 {
+		name = "alt-" + name
+	}
+	return name
+}
+
+// isChordPrefix reports whether the keys start some bound key sequence.
+func isChordPrefix(keys string) bool {
+	for seq := range chords {
+		if strings.HasPrefix(seq, keys+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// handleChord collects keys of a key sequence and runs the bound command
+// once the sequence completes. It returns false if the key is not part of a sequence.
+func (a *App) handleChord(ev *tcell.EventKey) bool {
+	keys := keyName(ev)
+	if a.s.pendingKeys != "" {
+		if time.Since(a.s.pendingSince) < chordTimeout {
+			keys = a.s.pendingKeys + " " + keys
+		}
+		a.s.pendingKeys = ""
+	}
+
+	if cmd, ok := chords[keys]; ok {
+		a.syncCursor()
+		a.cmdCh <- cmd
+		return true
+	}
+	if isChordPrefix(keys) {
+		a.s.pendingKeys = keys
+		a.s.pendingSince = time.Now()
+		a.status.draw([]rune(keys + " -"))
+		time.AfterFunc(chordTimeout, func() {
+			screen.PostEvent(tcell.NewEventInterrupt(chordTimeoutEvent{}))
+		})
+		return true
+	}
+	if strings.Contains(keys, " ") {
+		a.showMessage(keys + " is not bound")
+		return true
+	}
+	return false
+}
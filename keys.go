@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// describeKey returns a line telling the key event as delivered by tcell: its name as
+// written in key bindings, the tcell key, rune and modifiers, and what it is bound to.
+func describeKey(ev *tcell.EventKey) string {
+	name := keyName(ev)
+	key, ok := tcell.KeyNames[ev.Key()]
+	switch {
+	case ev.Key() == tcell.KeyRune:
+		key = "Rune"
+	case !ok:
+		key = fmt.Sprintf("Key(%d)", ev.Key())
+	}
+	var mods []string
+	for _, m := range []struct {
+		mod  tcell.ModMask
+		name string
+	}{{tcell.ModShift, "shift"}, {tcell.ModCtrl, "ctrl"}, {tcell.ModAlt, "alt"}, {tcell.ModMeta, "meta"}} {
+		if ev.Modifiers()&m.mod != 0 {
+			mods = append(mods, m.name)
+		}
+	}
+	mod := "none"
+	if len(mods) > 0 {
+		mod = strings.Join(mods, "+")
+	}
+	line := fmt.Sprintf("%-16s key %-10s rune %-8q mod %s", name, key, ev.Rune(), mod)
+	var bound []string
+	if cmd, ok := chords[name]; ok {
+		bound = append(bound, cmd)
+	}
+	if cmd, ok := debugKeys[ev.Key()]; ok && ev.Modifiers() == tcell.ModNone {
+		bound = append(bound, cmd)
+	}
+	var prefixes []string
+	for seq := range chords {
+		if strings.HasPrefix(seq, name+" ") {
+			prefixes = append(prefixes, seq)
+		}
+	}
+	if len(prefixes) > 0 {
+		slices.Sort(prefixes)
+		bound = append(bound, "prefix of "+strings.Join(prefixes, ", "))
+	}
+	if len(bound) > 0 {
+		line += "  bound to " + strings.Join(bound, "; ")
+	}
+	return line
+}
+
+// viewKeys opens the keys tab showing every key pressed as tcell delivers it, before
+// the key bindings, to tell what the terminal sends. Escape twice in a row stops it.
+func (a *App) viewKeys() {
+	const title = "keys"
+	header := "Press keys to see them as delivered by the terminal, escape twice to stop\n"
+	if !a.updateBuffer(title, header, true) {
+		return
+	}
+	a.jump(a.s.lines.Len()-1, 0)
+	a.drawEditor()
+	tab := a.s.Tab
+	escaped := false
+	tab.rawKey = func(a *App, ev *tcell.EventKey) {
+		if ev.Key() == tcell.KeyEscape && escaped {
+			tab.rawKey = nil
+			a.showMessage("Stopped viewing keys")
+			return
+		}
+		escaped = ev.Key() == tcell.KeyEscape
+		last := tab.lines.Back()
+		last.Value = []rune(describeKey(ev))
+		tab.lines.PushBack([]rune{})
+		a.jump(tab.lines.Len()-1, 0)
+		a.drawEditor()
+	}
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestDescribeKey(t *testing.T) {
+	tests := []struct {
+		ev   *tcell.EventKey
+		want []string
+	}{
+		{tcell.NewEventKey(tcell.KeyRune, 'a', tcell.ModNone), []string{"a ", "key Rune", "rune 'a'", "mod none"}},
+		{tcell.NewEventKey(tcell.KeyCtrlK, 0, tcell.ModCtrl), []string{"ctrl-k ", "key Ctrl-K", "mod ctrl", "prefix of ctrl-k", "ctrl-k ctrl-a,"}},
+		{tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModAlt), []string{"alt-down ", "mod alt", "bound to >nextdecl"}},
+		{tcell.NewEventKey(tcell.KeyF5, 0, tcell.ModNone), []string{"f5 ", "bound to >debug continue"}},
+	}
+	for _, tt := range tests {
+		got := describeKey(tt.ev)
+		for _, want := range tt.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("describeKey(%s) = %q, want containing %q", keyName(tt.ev), got, want)
+			}
+		}
+	}
+}
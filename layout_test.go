@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// screenLine returns the text of the row of the screen.
+func screenLine(s tcell.SimulationScreen, y int) string {
+	s.Show()
+	cells, w, _ := s.GetContents()
+	var b strings.Builder
+	for _, c := range cells[y*w : (y+1)*w] {
+		b.WriteString(string(c.Runes))
+	}
+	return b.String()
+}
+
+func TestLayoutSmallScreens(t *testing.T) {
+	tests := []struct {
+		w, h     int
+		tooSmall bool
+		editorY  int // row of the first line of the text
+	}{
+		{80, 24, false, 1},
+		{80, 4, false, 1},
+		{80, 3, false, 0},
+		{80, 2, false, 0},
+		{80, 1, true, 0},
+		{19, 10, true, 0},
+		{5, 1, true, 0},
+		{0, 0, true, 0},
+	}
+	for _, tt := range tests {
+		s := tcell.NewSimulationScreen("UTF-8")
+		if err := s.Init(); err != nil {
+			t.Fatal(err)
+		}
+		s.SetSize(tt.w, tt.h)
+		screen = s
+		a := &App{s: &State{tabs: []*Tab{{}}}}
+		a.s.Tab = a.s.tabs[0]
+		a.s.loadSource(strings.NewReader("first line\nsecond line\nthird line\n"))
+		a.resize()
+		a.draw()
+		a.jump(2, 3)
+		a.draw()
+		if a.tooSmall != tt.tooSmall {
+			t.Errorf("%dx%d: tooSmall = %v, want %v", tt.w, tt.h, a.tooSmall, tt.tooSmall)
+		}
+		if tt.w == 0 || tt.h == 0 {
+			continue
+		}
+		got := screenLine(s, tt.editorY)
+		if tt.tooSmall {
+			for y := range tt.h {
+				got = screenLine(s, y)
+				if strings.Contains(got, "third") {
+					t.Errorf("%dx%d: row %d = %q, want the layout hidden", tt.w, tt.h, y, got)
+				}
+			}
+			if want := "window too small"[:min(tt.w, 16)]; !strings.Contains(screenLine(s, (tt.h-1)/2), want) {
+				t.Errorf("%dx%d: screen does not tell %q", tt.w, tt.h, want)
+			}
+			continue
+		}
+		// the first line, or another the editor scrolled to
+		if !strings.Contains(got, " line") {
+			t.Errorf("%dx%d: row %d = %q, want a line of the text", tt.w, tt.h, tt.editorY, got)
+		}
+	}
+}
+
+func TestSplitPanes(t *testing.T) {
+	s := tcell.NewSimulationScreen("UTF-8")
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	s.SetSize(40, 10)
+	screen = s
+	a := &App{s: &State{tabs: []*Tab{{}}}, cmdCh: make(chan string, 1)}
+	a.s.Tab = a.s.tabs[0]
+	a.s.loadSource(strings.NewReader("first line\nsecond line\nthird line\n"))
+	a.resize()
+	a.split(true)
+	a.jump(2, 0)
+	a.s.top = 2
+	a.draw()
+	if got := screenLine(s, 1); !strings.HasPrefix(got, "first line") || !strings.Contains(got, "│third line") {
+		t.Errorf("side by side row 1 = %q, want the first line left and the third right", got)
+	}
+	a.focusPane(0)
+	if a.s.row != 0 || a.s.top != 0 {
+		t.Errorf("left pane cursor at row %d top %d, want its own 0 and 0", a.s.row, a.s.top)
+	}
+	a.split(false)
+	if got := screenLine(s, 4); !strings.HasPrefix(got, "────") {
+		t.Errorf("stacked row 4 = %q, want a separator", got)
+	}
+	a.closePane()
+	a.closePane()
+	if a.panes != nil || len(a.editor) != 7 {
+		t.Errorf("after closing the panes: %d panes, %d rows, want none and 7", len(a.panes), len(a.editor))
+	}
+}
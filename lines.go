@@ -0,0 +1,161 @@
+package main
+
+// lineBuffer is the lines of a tab in a gap buffer: getting a line by its row is O(1),
+// and inserting or removing lines is O(1) amortized near the row edited last, as edits
+// usually are. Its methods follow container/list, which it replaces. The zero value
+// is an empty buffer.
+type lineBuffer struct {
+	elems  []*lineElem // the lines before the gap, the gap, then the lines after it
+	gap    int         // start of the gap in elems
+	gapEnd int         // end of the gap in elems
+}
+
+// lineElem is a line of a lineBuffer.
+type lineElem struct {
+	Value []rune
+	buf   *lineBuffer
+	// index in elems if before the gap, otherwise the negative offset from the end of elems,
+	// so moving the gap renumbers only the lines moved over, and growing none
+	pos int
+}
+
+func newLineBuffer() *lineBuffer {
+	return &lineBuffer{}
+}
+
+// Init clears the buffer.
+func (b *lineBuffer) Init() *lineBuffer {
+	*b = lineBuffer{}
+	return b
+}
+
+// Len returns the number of lines.
+func (b *lineBuffer) Len() int {
+	return len(b.elems) - (b.gapEnd - b.gap)
+}
+
+// slot returns the index of the row in elems.
+func (b *lineBuffer) slot(row int) int {
+	if row < b.gap {
+		return row
+	}
+	return row + b.gapEnd - b.gap
+}
+
+// row returns the row of the line.
+func (b *lineBuffer) row(e *lineElem) int {
+	if e.pos >= 0 {
+		return e.pos
+	}
+	return len(b.elems) + e.pos - (b.gapEnd - b.gap)
+}
+
+// At returns the line of the row, nil if out of range.
+func (b *lineBuffer) At(row int) *lineElem {
+	if row < 0 || row >= b.Len() {
+		return nil
+	}
+	return b.elems[b.slot(row)]
+}
+
+// Front returns the first line, nil if empty.
+func (b *lineBuffer) Front() *lineElem {
+	return b.At(0)
+}
+
+// Back returns the last line, nil if empty.
+func (b *lineBuffer) Back() *lineElem {
+	return b.At(b.Len() - 1)
+}
+
+// moveGap moves the gap before the row.
+func (b *lineBuffer) moveGap(row int) {
+	for b.gap > row {
+		b.gap--
+		b.gapEnd--
+		e := b.elems[b.gap]
+		b.elems[b.gap] = nil
+		b.elems[b.gapEnd] = e
+		e.pos = b.gapEnd - len(b.elems)
+	}
+	for b.gap < row {
+		e := b.elems[b.gapEnd]
+		b.elems[b.gapEnd] = nil
+		b.elems[b.gap] = e
+		e.pos = b.gap
+		b.gap++
+		b.gapEnd++
+	}
+}
+
+// insert inserts the line at the row.
+func (b *lineBuffer) insert(row int, v []rune) *lineElem {
+	b.moveGap(row)
+	if b.gap == b.gapEnd {
+		// grow, the lines after the gap keep their offsets from the end
+		elems := make([]*lineElem, max(16, 2*len(b.elems)))
+		copy(elems, b.elems[:b.gap])
+		after := len(b.elems) - b.gapEnd
+		copy(elems[len(elems)-after:], b.elems[b.gapEnd:])
+		b.gapEnd = len(elems) - after
+		b.elems = elems
+	}
+	e := &lineElem{Value: v, buf: b, pos: b.gap}
+	b.elems[b.gap] = e
+	b.gap++
+	return e
+}
+
+// PushBack appends the line.
+func (b *lineBuffer) PushBack(v []rune) *lineElem {
+	return b.insert(b.Len(), v)
+}
+
+// PushFront inserts the line at the start.
+func (b *lineBuffer) PushFront(v []rune) *lineElem {
+	return b.insert(0, v)
+}
+
+// InsertAfter inserts the line after the mark, a line of the buffer.
+func (b *lineBuffer) InsertAfter(v []rune, mark *lineElem) *lineElem {
+	if mark.buf != b {
+		return nil
+	}
+	return b.insert(b.row(mark)+1, v)
+}
+
+// InsertBefore inserts the line before the mark, a line of the buffer.
+func (b *lineBuffer) InsertBefore(v []rune, mark *lineElem) *lineElem {
+	if mark.buf != b {
+		return nil
+	}
+	return b.insert(b.row(mark), v)
+}
+
+// Remove removes the line from the buffer, and returns its value.
+func (b *lineBuffer) Remove(e *lineElem) []rune {
+	if e.buf != b {
+		return e.Value
+	}
+	b.moveGap(b.row(e))
+	b.elems[b.gapEnd] = nil
+	b.gapEnd++
+	e.buf = nil
+	return e.Value
+}
+
+// Next returns the line after, nil if the last.
+func (e *lineElem) Next() *lineElem {
+	if e.buf == nil {
+		return nil
+	}
+	return e.buf.At(e.buf.row(e) + 1)
+}
+
+// Prev returns the line before, nil if the first.
+func (e *lineElem) Prev() *lineElem {
+	if e.buf == nil {
+		return nil
+	}
+	return e.buf.At(e.buf.row(e) - 1)
+}
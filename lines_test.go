@@ -0,0 +1,69 @@
+package main
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestLineBuffer(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var b lineBuffer
+	var want []string
+	check := func(step int) {
+		t.Helper()
+		if b.Len() != len(want) {
+			t.Fatalf("step %d: Len() = %d, want %d", step, b.Len(), len(want))
+		}
+		var got []string
+		for e := b.Front(); e != nil; e = e.Next() {
+			got = append(got, string(e.Value))
+		}
+		if !slices.Equal(got, want) {
+			t.Fatalf("step %d: forward %q, want %q", step, got, want)
+		}
+		got = got[:0]
+		for e := b.Back(); e != nil; e = e.Prev() {
+			got = append(got, string(e.Value))
+		}
+		slices.Reverse(got)
+		if !slices.Equal(got, want) {
+			t.Fatalf("step %d: backward %q, want %q", step, got, want)
+		}
+		for i, s := range want {
+			if e := b.At(i); string(e.Value) != s || b.row(e) != i {
+				t.Fatalf("step %d: At(%d) = %q at row %d, want %q", step, i, string(e.Value), b.row(e), s)
+			}
+		}
+	}
+	for step := range 2000 {
+		s := string(rune('a' + step%26))
+		switch n := len(want); {
+		case n == 0 || r.Intn(3) > 0:
+			i := r.Intn(n + 1)
+			switch {
+			case i == n:
+				b.PushBack([]rune(s))
+			case i == 0 && r.Intn(2) == 0:
+				b.PushFront([]rune(s))
+			case i == 0:
+				b.InsertBefore([]rune(s), b.At(0))
+			default:
+				b.InsertAfter([]rune(s), b.At(i-1))
+			}
+			want = slices.Insert(want, i, s)
+		default:
+			i := r.Intn(n)
+			e := b.At(i)
+			b.Remove(e)
+			if e.Next() != nil || e.Prev() != nil {
+				t.Fatalf("step %d: removed line still linked", step)
+			}
+			want = slices.Delete(want, i, i+1)
+		}
+		check(step)
+	}
+	b.Init()
+	want = nil
+	check(-1)
+}
@@ -0,0 +1,45 @@
+package main
+
+// selectLines selects the whole lines from the anchor row to the cursor row. It enters
+// the line-wise selection mode, where shift-up/down extend the selection by lines.
+func (a *App) selectLines(anchor, row int) {
+	a.s.lineSelect = true
+	a.s.lineAnchor = anchor
+	start, end := min(anchor, row), max(anchor, row)
+	sel := &Selection{startRow: start, startCol: 0, endRow: end + 1, endCol: 0}
+	if end >= a.s.lines.Len()-1 {
+		// no line break after the last line
+		sel.endRow, sel.endCol = end, len(a.s.line(end).Value)
+	}
+	a.s.selection = sel
+	if row != a.s.row {
+		a.jump(row, a.s.col)
+	}
+	a.drawEditor()
+}
+
+// selectingLines reports whether the selection is in the line-wise mode.
+func (st *State) selectingLines() bool {
+	return st.lineSelect && st.selection != nil
+}
+
+// selectedLines returns the first and last rows of the line-wise selection.
+func (st *State) selectedLines() (int, int) {
+	return min(st.lineAnchor, st.row), max(st.lineAnchor, st.row)
+}
+
+// extendLines moves the cursor by n rows, extending the line-wise selection.
+func (a *App) extendLines(n int) {
+	row := max(0, min(a.s.row+n, a.s.lines.Len()-1))
+	a.selectLines(a.s.lineAnchor, row)
+}
+
+// selectLine selects the current line, or extends the line-wise selection by the next line.
+func (a *App) selectLine() {
+	if a.s.selectingLines() {
+		a.extendLines(1)
+		return
+	}
+	a.unselect()
+	a.selectLines(a.s.row, a.s.row)
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// linter is an external tool checking the files of a type, run on save when installed.
+type linter struct {
+	command    string
+	args       []string // before the file name, asking for the JSON output
+	codePrefix string   // of the numeric codes, like "SC" of shellcheck
+}
+
+// linters are the linters by file type name.
+var linters = map[string]linter{
+	"shell":      {command: "shellcheck", args: []string{"-f", "json"}, codePrefix: "SC"},
+	"dockerfile": {command: "hadolint", args: []string{"-f", "json"}},
+}
+
+// lintFinding is a finding in the JSON output of shellcheck and hadolint, which share the fields.
+type lintFinding struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Level   string `json:"level"`
+	Code    any    `json:"code"` // a number of shellcheck, a string of hadolint
+	Message string `json:"message"`
+}
+
+// parseLint returns the locations of the findings in the output of the linter,
+// relative paths are joined to the dir.
+func (l linter) parseLint(output []byte, dir string) ([]quickfixItem, error) {
+	var findings []lintFinding
+	if err := json.Unmarshal(output, &findings); err != nil {
+		return nil, fmt.Errorf("%s: %w", l.command, err)
+	}
+	items := make([]quickfixItem, 0, len(findings))
+	for _, f := range findings {
+		code := fmt.Sprint(f.Code)
+		if _, ok := f.Code.(float64); ok {
+			code = l.codePrefix + code
+		}
+		file := f.File
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		items = append(items, quickfixItem{
+			file: filepath.Clean(file),
+			row:  f.Line - 1,
+			col:  max(f.Column-1, 0),
+			text: fmt.Sprintf("%s %s: %s", f.Level, code, f.Message),
+		})
+	}
+	return items, nil
+}
+
+// lintFile runs the linter of the file type on the saved file in the background if installed,
+// its findings become the quickfix list, marked in the gutter, and the text of the "lint" tab,
+// opened if open is true. It reports whether a linter runs.
+func (a *App) lintFile(filename string, open bool) bool {
+	l, ok := linters[fileTypeOf(filename).name]
+	if !ok {
+		return false
+	}
+	if _, err := exec.LookPath(l.command); err != nil {
+		return false
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return false
+	}
+	t := a.s.tasks.start(l.command, 0)
+	go func() {
+		defer t.finish()
+		cmd := exec.CommandContext(t.ctx, l.command, append(l.args, filepath.Base(abs))...)
+		cmd.Dir = filepath.Dir(abs)
+		// both exit with 1 if there are findings
+		out, runErr := cmd.Output()
+		if t.ctx.Err() != nil {
+			return
+		}
+		items, err := l.parseLint(out, cmd.Dir)
+		postFunc(func() {
+			if err != nil {
+				if runErr != nil {
+					err = fmt.Errorf("%s: %w", l.command, runErr)
+				}
+				a.showError(err.Error())
+				return
+			}
+			a.s.quickfix = items
+			a.s.quickfixIdx = -1
+			a.drawEditor()
+			var b strings.Builder
+			for _, item := range items {
+				fmt.Fprintf(&b, "%s:%d:%d: %s\n", filepath.Base(item.file), item.row+1, item.col+1, item.text)
+			}
+			a.updateBuffer("lint", b.String(), open)
+			if len(items) == 0 {
+				a.showMessage(fmt.Sprintf("%s: no problem", l.command))
+				return
+			}
+			a.showError(fmt.Sprintf("%s: %d problems, f4 to go to the next, >lint to list", l.command, len(items)))
+		})
+	}()
+	return true
+}
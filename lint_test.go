@@ -0,0 +1,35 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLint(t *testing.T) {
+	shellcheck := `[{"file":"run.sh","line":3,"endLine":3,"column":6,"endColumn":10,"level":"info","code":2086,"message":"Double quote to prevent globbing and word splitting.","fix":null}]`
+	items, err := linters["shell"].parseLint([]byte(shellcheck), "/w")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []quickfixItem{{file: "/w/run.sh", row: 2, col: 5, text: "info SC2086: Double quote to prevent globbing and word splitting."}}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("shellcheck items = %+v, want %+v", items, want)
+	}
+
+	hadolint := `[{"code":"DL3006","column":1,"file":"Dockerfile","level":"warning","line":1,"message":"Always tag the version of an image explicitly"}]`
+	items, err = linters["dockerfile"].parseLint([]byte(hadolint), "/w")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = []quickfixItem{{file: "/w/Dockerfile", row: 0, col: 0, text: "warning DL3006: Always tag the version of an image explicitly"}}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("hadolint items = %+v, want %+v", items, want)
+	}
+
+	if _, err := linters["shell"].parseLint([]byte("oops"), "/w"); err == nil {
+		t.Error("invalid output, want error")
+	}
+	if got := fileTypeOf("/w/Dockerfile").name; got != "dockerfile" {
+		t.Errorf("file type of Dockerfile = %q", got)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// lockPath returns the path of the lock file of the file, like ".#main.go" next to it.
+func lockPath(filename string) string {
+	return filepath.Join(filepath.Dir(filename), ".#"+filepath.Base(filename))
+}
+
+// lockOwner is who holds a lock file, written in it as "user@host pid".
+type lockOwner struct {
+	user, host string
+	pid        int
+}
+
+func (o lockOwner) String() string {
+	return fmt.Sprintf("%s@%s %d", o.user, o.host, o.pid)
+}
+
+// currentOwner returns the owner of the locks of this process.
+func currentOwner() lockOwner {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, _ := os.Hostname()
+	return lockOwner{user: name, host: host, pid: os.Getpid()}
+}
+
+// parseLockOwner parses the content of a lock file.
+func parseLockOwner(s string) (lockOwner, bool) {
+	id, pid, ok := strings.Cut(strings.TrimSpace(s), " ")
+	if !ok {
+		return lockOwner{}, false
+	}
+	name, host, ok := strings.Cut(id, "@")
+	if !ok {
+		return lockOwner{}, false
+	}
+	n, err := strconv.Atoi(pid)
+	if err != nil {
+		return lockOwner{}, false
+	}
+	return lockOwner{user: name, host: host, pid: n}, true
+}
+
+// processAlive reports whether the process of the pid on this host is running.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// finding opens the process, which fails if it is gone
+		return true
+	}
+	return p.Signal(syscall.Signal(0)) == nil
+}
+
+// stale reports whether the owner is gone, only known for this host.
+func (o lockOwner) stale(self lockOwner) bool {
+	return o.host == self.host && o.pid != self.pid && !processAlive(o.pid)
+}
+
+// lock creates the lock file of the tab's file exclusively, which works on network mounts
+// unlike flock. It returns an error telling the owner if another editor holds it.
+// Stale locks of processes gone on this host are taken over.
+func (t *Tab) lock() error {
+	if t.filename == "" || t.lockFile != "" {
+		return nil
+	}
+	path := lockPath(t.filename)
+	self := currentOwner()
+	for range 2 {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			_, err = f.WriteString(self.String() + "\n")
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+			if err != nil {
+				os.Remove(path)
+				return err
+			}
+			t.lockFile = path
+			return nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) {
+				// a new file in a missing directory, or a read-only directory
+				return nil
+			}
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		owner, ok := parseLockOwner(string(data))
+		if ok && owner == self {
+			t.lockFile = path
+			return nil
+		}
+		if !ok || !owner.stale(self) {
+			t.lockedBy = strings.TrimSpace(string(data))
+			return fmt.Errorf("%s is being edited by %s", filepath.Base(t.filename), t.lockedBy)
+		}
+		os.Remove(path)
+	}
+	return nil
+}
+
+// unlock removes the lock file held by the tab.
+func (t *Tab) unlock() {
+	if t.lockFile != "" {
+		os.Remove(t.lockFile)
+		t.lockFile = ""
+	}
+	t.lockedBy = ""
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLock(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "a.txt")
+	path := filepath.Join(dir, ".#a.txt")
+
+	a, b := &Tab{filename: filename}, &Tab{filename: filename}
+	if err := a.lock(); err != nil || a.lockFile != path {
+		t.Fatalf("lock = %v, lock file %q", err, a.lockFile)
+	}
+	if owner, ok := parseLockOwner(readFile(t, path)); !ok || owner != currentOwner() {
+		t.Errorf("lock owner = %v, %v", owner, ok)
+	}
+	// the same process may open the file in another tab
+	if err := b.lock(); err != nil {
+		t.Errorf("lock by the same process = %v", err)
+	}
+	a.unlock()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lock file not removed: %v", err)
+	}
+
+	os.WriteFile(path, []byte("bob@elsewhere 1\n"), 0o644)
+	c := &Tab{filename: filename}
+	if err := c.lock(); err == nil || !strings.Contains(err.Error(), "bob@elsewhere 1") || c.lockedBy == "" {
+		t.Errorf("lock held by another host = %v", err)
+	}
+
+	// a gone process of this host leaves a stale lock
+	self := currentOwner()
+	os.WriteFile(path, []byte(lockOwner{self.user, self.host, 1 << 30}.String()), 0o644)
+	if err := c.lock(); err != nil || c.lockFile != path {
+		t.Errorf("lock over a stale one = %v", err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode/utf16"
+)
+
+// lspMessage is a request, response or notification of the Language Server Protocol,
+// which are JSON-RPC 2.0 messages.
+type lspMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // a number or a string, absent in notifications
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *lspError       `json:"error,omitempty"`
+}
+
+// lspError is the error of a response.
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// lspPosition is a position in a document, the character counts UTF-16 code units.
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+// lspDiagnostic is an error or warning of a document published by the server.
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"` // 1 error, 2 warning, 3 information, 4 hint
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+// lspContentChange is a change of a document, the whole text if the range is nil.
+type lspContentChange struct {
+	Range *lspRange `json:"range,omitempty"`
+	Text  string    `json:"text"`
+}
+
+// utf16Len returns the length of the runes in UTF-16 code units.
+func utf16Len(runes []rune) int {
+	n := 0
+	for _, r := range runes {
+		n += max(utf16.RuneLen(r), 1)
+	}
+	return n
+}
+
+// runeColumn returns the column of the line at the UTF-16 offset of an LSP position.
+func runeColumn(line []rune, character int) int {
+	n := 0
+	for i, r := range line {
+		if n >= character {
+			return i
+		}
+		n += max(utf16.RuneLen(r), 1)
+	}
+	return len(line)
+}
+
+// lspPositionOf returns the LSP position of the column of the line at the row.
+func lspPositionOf(line []rune, row, col int) lspPosition {
+	return lspPosition{Line: row, Character: utf16Len(line[:min(col, len(line))])}
+}
+
+// changeEvent returns the LSP change of the change already applied, the line being
+// the line of the change after it, whose text before the change column is kept.
+func changeEvent(line []rune, c Change) lspContentChange {
+	start := lspPositionOf(line, c.row, c.col)
+	end := start
+	if i := strings.LastIndexByte(c.oldText, '\n'); i >= 0 {
+		end = lspPosition{Line: c.row + strings.Count(c.oldText, "\n"), Character: utf16Len([]rune(c.oldText[i+1:]))}
+	} else {
+		end.Character += utf16Len([]rune(c.oldText))
+	}
+	return lspContentChange{Range: &lspRange{Start: start, End: end}, Text: c.newText}
+}
+
+// fileURI returns the URI of the file at the path.
+func fileURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// uriPath returns the path of the file URI, empty for other URIs.
+func uriPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return ""
+	}
+	return filepath.FromSlash(u.Path)
+}
+
+// errLSPClosed is returned by requests once the server is gone.
+var errLSPClosed = errors.New("language server disconnected")
+
+// lspClient talks to a language server such as gopls.
+type lspClient struct {
+	conn    io.ReadWriteCloser
+	mu      sync.Mutex // guards the fields below and writing
+	id      int
+	pending map[string]chan lspMessage // response channels by request id
+	closed  bool
+	// onNotify is called in the reading goroutine, it must not block on requests.
+	onNotify func(method string, params json.RawMessage)
+	done     chan struct{} // closed when the connection is lost
+}
+
+// newLSPClient starts reading the messages of the connection.
+func newLSPClient(conn io.ReadWriteCloser, onNotify func(method string, params json.RawMessage)) *lspClient {
+	c := &lspClient{
+		conn:     conn,
+		pending:  make(map[string]chan lspMessage),
+		onNotify: onNotify,
+		done:     make(chan struct{}),
+	}
+	go c.read()
+	return c
+}
+
+func (c *lspClient) read() {
+	r := bufio.NewReader(c.conn)
+	for {
+		data, err := readContent(r)
+		if err != nil {
+			break
+		}
+		var m lspMessage
+		if err := json.Unmarshal(data, &m); err != nil {
+			continue
+		}
+		switch {
+		case m.Method == "" && m.ID != nil:
+			c.mu.Lock()
+			ch := c.pending[string(m.ID)]
+			delete(c.pending, string(m.ID))
+			c.mu.Unlock()
+			if ch != nil {
+				ch <- m
+			}
+		case m.ID != nil:
+			c.reply(m)
+		default:
+			c.onNotify(m.Method, m.Params)
+		}
+	}
+	c.mu.Lock()
+	c.closed = true
+	for id, ch := range c.pending {
+		close(ch)
+		delete(c.pending, id)
+	}
+	c.mu.Unlock()
+	close(c.done)
+}
+
+// reply answers a request of the server. The editor has no configuration and
+// accepts the rest, such as the progress tokens.
+func (c *lspClient) reply(m lspMessage) {
+	result := json.RawMessage("null")
+	if m.Method == "workspace/configuration" {
+		var params struct {
+			Items []json.RawMessage `json:"items"`
+		}
+		json.Unmarshal(m.Params, &params)
+		result, _ = json.Marshal(make([]any, len(params.Items)))
+	}
+	c.send(lspMessage{ID: m.ID, Result: result})
+}
+
+// send writes the message.
+func (c *lspClient) send(m lspMessage) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.write(m)
+}
+
+// write writes the message with c.mu held, filling the JSON-RPC version.
+func (c *lspClient) write(m lspMessage) error {
+	if c.closed {
+		return errLSPClosed
+	}
+	m.JSONRPC = "2.0"
+	return writeContent(c.conn, m)
+}
+
+// request sends the method and waits for the response, decoding its result into result
+// if not nil. Canceling the context cancels the request.
+func (c *lspClient) request(ctx context.Context, method string, params, result any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.id++
+	id := json.RawMessage(fmt.Sprint(c.id))
+	ch := make(chan lspMessage, 1)
+	c.pending[string(id)] = ch
+	err = c.write(lspMessage{ID: id, Method: method, Params: data})
+	if err != nil {
+		delete(c.pending, string(id))
+	}
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var resp lspMessage
+	var ok bool
+	select {
+	case resp, ok = <-ch:
+	case <-ctx.Done():
+		c.mu.Lock()
+		delete(c.pending, string(id))
+		c.mu.Unlock()
+		c.notify("$/cancelRequest", map[string]any{"id": id})
+		return ctx.Err()
+	}
+	if !ok {
+		return errLSPClosed
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("%s: %s", method, resp.Error.Message)
+	}
+	if result != nil && len(resp.Result) > 0 {
+		return json.Unmarshal(resp.Result, result)
+	}
+	return nil
+}
+
+// notify sends the notification, which has no response.
+func (c *lspClient) notify(method string, params any) error {
+	data, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	return c.send(lspMessage{Method: method, Params: data})
+}
+
+func (c *lspClient) close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestLSPClient(t *testing.T) {
+	client, server := net.Pipe()
+	notes := make(chan string, 1)
+	c := newLSPClient(client, func(method string, params json.RawMessage) {
+		notes <- method + " " + string(params)
+	})
+	defer c.close()
+
+	// a fake server asking the configuration, answering a request, then notifying
+	replies := make(chan string, 1)
+	go func() {
+		r := bufio.NewReader(server)
+		data, err := readContent(r)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		var req lspMessage
+		json.Unmarshal(data, &req)
+		writeContent(server, lspMessage{JSONRPC: "2.0", ID: json.RawMessage(`"c1"`), Method: "workspace/configuration",
+			Params: json.RawMessage(`{"items":[{"section":"gopls"}]}`)})
+		data, _ = readContent(r)
+		replies <- string(data)
+		writeContent(server, lspMessage{JSONRPC: "2.0", ID: req.ID, Result: json.RawMessage(`{"contents":{"value":"func f()"}}`)})
+		writeContent(server, lspMessage{JSONRPC: "2.0", Method: "textDocument/publishDiagnostics", Params: json.RawMessage(`{"uri":"file:///a.go"}`)})
+	}()
+
+	var result struct {
+		Contents struct {
+			Value string `json:"value"`
+		} `json:"contents"`
+	}
+	if err := c.request(context.Background(), "textDocument/hover", map[string]any{}, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Contents.Value != "func f()" {
+		t.Errorf("hover = %q", result.Contents.Value)
+	}
+	if got, want := <-replies, `{"jsonrpc":"2.0","id":"c1","result":[null]}`; got != want {
+		t.Errorf("reply = %s, want %s", got, want)
+	}
+	if got, want := <-notes, `textDocument/publishDiagnostics {"uri":"file:///a.go"}`; got != want {
+		t.Errorf("notification = %q, want %q", got, want)
+	}
+
+	server.Close()
+	<-c.done
+	if err := c.request(context.Background(), "shutdown", nil, nil); err == nil {
+		t.Error("request after disconnected, want error")
+	}
+}
+
+func TestRuneColumn(t *testing.T) {
+	line := []rune("a😀b é")
+	if got := utf16Len(line); got != 6 {
+		t.Errorf("utf16Len = %d, want 6", got)
+	}
+	for col, character := range []int{0, 1, 3, 4, 5, 6} {
+		if got := lspPositionOf(line, 0, col).Character; got != character {
+			t.Errorf("character of column %d = %d, want %d", col, got, character)
+		}
+		if got := runeColumn(line, character); got != col {
+			t.Errorf("runeColumn(%d) = %d, want %d", character, got, col)
+		}
+	}
+}
+
+func TestChangeEvent(t *testing.T) {
+	tests := []struct {
+		line string
+		c    Change
+		want lspRange
+	}{
+		{"ab😀cd", Change{row: 1, col: 3, newText: "cd", kind: editInsert}, lspRange{lspPosition{1, 4}, lspPosition{1, 4}}},
+		{"😀", Change{row: 0, col: 1, oldText: "é😀", kind: editDelete}, lspRange{lspPosition{0, 2}, lspPosition{0, 5}}},
+		{"fooqux", Change{row: 2, col: 3, oldText: "bar\nbaz\n😀x", newText: "qux", kind: editReplace}, lspRange{lspPosition{2, 3}, lspPosition{4, 3}}},
+	}
+	for _, tt := range tests {
+		got := changeEvent([]rune(tt.line), tt.c)
+		if got.Range == nil || *got.Range != tt.want || got.Text != tt.c.newText {
+			t.Errorf("changeEvent(%q, %+v) = %+v, want %+v", tt.line, tt.c, got.Range, tt.want)
+		}
+	}
+}
+
+func TestLSPSessionSync(t *testing.T) {
+	client, server := net.Pipe()
+	s := &lspSession{client: newLSPClient(client, func(string, json.RawMessage) {}), versions: make(map[*Tab]int)}
+	defer s.client.close()
+	sent := make(chan string, 10)
+	go func() {
+		r := bufio.NewReader(server)
+		for {
+			data, err := readContent(r)
+			if err != nil {
+				close(sent)
+				return
+			}
+			sent <- string(data)
+		}
+	}()
+
+	st := &State{Tab: &Tab{filename: "/tmp/a.go"}}
+	st.loadSource(strings.NewReader("package a\n"))
+	s.open(st.Tab)
+	st.lsp = s
+	st.insertText([]rune("\nvar é = 1"), 0, 9)
+	st.recordChange(Change{row: 0, col: 9, newText: "\nvar é = 1", kind: editInsert})
+	st.undo()
+	s.close(st.Tab)
+	server.Close()
+
+	var methods []string
+	for data := range sent {
+		var m struct {
+			Method string `json:"method"`
+			Params struct {
+				TextDocument struct {
+					Version int `json:"version"`
+				} `json:"textDocument"`
+				ContentChanges []lspContentChange `json:"contentChanges"`
+			} `json:"params"`
+		}
+		json.Unmarshal([]byte(data), &m)
+		methods = append(methods, m.Method)
+		if m.Method == "textDocument/didChange" {
+			r := m.Params.ContentChanges[0].Range
+			if m.Params.TextDocument.Version == 2 && (*r != lspRange{lspPosition{0, 9}, lspPosition{0, 9}}) {
+				t.Errorf("range of the insert = %+v", *r)
+			}
+			if m.Params.TextDocument.Version == 3 && (*r != lspRange{lspPosition{0, 9}, lspPosition{1, 9}}) {
+				t.Errorf("range of the undo = %+v", *r)
+			}
+		}
+	}
+	want := []string{"textDocument/didOpen", "textDocument/didChange", "textDocument/didChange", "textDocument/didClose"}
+	if !slices.Equal(methods, want) {
+		t.Errorf("methods = %v, want %v", methods, want)
+	}
+}
+
+func TestParseLocations(t *testing.T) {
+	one := parseLocations(json.RawMessage(`{"uri":"file:///a/b.go","range":{"start":{"line":3,"character":5},"end":{"line":3,"character":8}}}`))
+	many := parseLocations(json.RawMessage(`[{"uri":"file:///a/b.go"},{"uri":"file:///a/c.go"}]`))
+	if len(one) != 1 || uriPath(one[0].URI) != "/a/b.go" || one[0].Range.Start.Character != 5 {
+		t.Errorf("single location = %+v", one)
+	}
+	if len(many) != 2 || many[1].URI != "file:///a/c.go" {
+		t.Errorf("locations = %+v", many)
+	}
+	if locs := parseLocations(json.RawMessage(`null`)); len(locs) != 0 {
+		t.Errorf("null = %+v, want none", locs)
+	}
+}
+
+func TestCompletionLabels(t *testing.T) {
+	list := `{"isIncomplete":false,"items":[{"label":"Println","sortText":"00001"},{"label":"Print","sortText":"00000"}]}`
+	if got, want := completionLabels(json.RawMessage(list)), []string{"Print", "Println"}; !slices.Equal(got, want) {
+		t.Errorf("labels = %v, want %v", got, want)
+	}
+	if got, want := completionLabels(json.RawMessage(`[{"label":"x"}]`)), []string{"x"}; !slices.Equal(got, want) {
+		t.Errorf("labels = %v, want %v", got, want)
+	}
+}
+
+func TestDiagnosticErrors(t *testing.T) {
+	diags := []lspDiagnostic{
+		{Range: lspRange{Start: lspPosition{Line: 2}}, Severity: 1, Message: "undefined: x"},
+		{Range: lspRange{Start: lspPosition{Line: 2}}, Severity: 2, Message: "second"},
+		{Range: lspRange{Start: lspPosition{Line: 4}}, Severity: 4, Message: "hint"},
+	}
+	if got := diagnosticErrors(diags); len(got) != 1 || got[2] != "undefined: x" {
+		t.Errorf("errors = %v", got)
+	}
+}
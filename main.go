@@ -3,27 +3,34 @@ package main
 import (
 	"bufio"
 	"bytes"
-	"container/list"
+	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"go/ast"
+	"go/constant"
 	"go/format"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"io"
 	"io/fs"
 
 	"log"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"slices"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/gdamore/tcell/v2"
 	"github.com/mattn/go-runewidth"
+	"github.com/rivo/uniseg"
 )
 
 type App struct {
@@ -34,25 +41,88 @@ type App struct {
 	console View
 	cmdCh   chan string
 	done    chan struct{}
+	// whether the screen is too small for the layout, see resize
+	tooSmall bool
+	// rows of the editor covered by the dropdown of the options, see showOptions
+	dropdownRows int
+	// panes of the split editor area, nil if not split, see split
+	panes         []*pane
+	paneIdx       int  // index of the focused pane, whose views are the editor
+	splitVertical bool // whether the panes are side by side, otherwise stacked
+	separators    []*View
+	// drawing of the events coalesced, see present
+	lastShow    time.Time // when the terminal was last updated
+	showPending bool      // whether a delayed update is due
+	staleEditor bool      // whether the editor scrolled without being drawn
+	// the selection and the focus at the last event, see trackSelection
+	seenTab          *Tab
+	seenSelection    *Selection
+	seenFocus        int
+	consoleTab       *Tab
+	consoleSelection *Selection // the selection when the console took the focus
 }
 
 type State struct {
 	*Tab          // active tab
 	tabs          []*Tab
-	tabIdx        int    // index of active tab
-	command       []rune // command in the console
-	commandCursor int    // Cursor position in the console
-	focus         int    // focus on editor or console
-	lineNumber    bool   // Whether to show line numbers in the editor
+	tabIdx        int               // index of active tab
+	closedTabs    []closedTab       // recently closed file tabs, the last is the most recent
+	command       []rune            // command in the console
+	commandCursor int               // Cursor position in the console
+	focus         int               // focus on editor or console
+	lineNumber    bool              // Whether to show line numbers in the editor
+	mouse         bool              // Whether to handle mouse events, otherwise the terminal selects text
+	lastSearch    []rune            // keyword of the last search
+	lastEdit      *Change           // the last edit, to be repeated at another position
+	groupDepth    int               // depth of nested undo groups, see beginGroup
+	groupSeq      int               // id of the last undo group
+	marks         map[rune]*mark    // global marks A-Z
+	smartCase     bool              // Whether searching is case sensitive only if the keyword has upper case letters
+	virtualSpace  bool              // Whether the cursor can move beyond the line end
+	overwrite     bool              // Whether typed runes replace the character under the cursor
+	indent        string            // indent unit inserted by Tab, "\t" or spaces
+	formatOnSave  bool              // Whether to format Go source on save
+	vars          map[string]string // global variables, see let
+	autoPair      bool              // Whether to insert the closing bracket or quote along the opening one
+	inlayHints    bool              // Whether to show parameter names before call arguments
+	ansiColors    bool              // Whether to interpret the ANSI colors of logs and generated tabs
+	lockFiles     bool              // Whether to hold lock files of the open files, warning of other editors
+	flash         *flashRange       // the identifier gone to by ctrl-b or @, highlighted until next key
+	benchProfiles string            // directory of the profiles of the last >bench, removed on exit
+	licenseFile   string            // file of the license header of new Go files
+	debug         *debugSession     // the program being debugged, nil if not debugging
+	lsp           *lspSession       // gopls started by >lsp, nil if not running
+	quickfix      []quickfixItem    // locations reported by the last task
+	quickfixIdx   int               // index of the current quickfix location, -1 before the first
+	excludeDirs   []string          // names of directories not listed by quick open
+	vim           bool              // Whether vim-style modal editing is enabled
+	vimMode       int
+	vimPending    string // pending operator and "g" prefix
+	vimCount      int    // count prefix
+	vimOpCount    int    // count prefix of the pending operator
+	vimAnchorRow  int    // where the visual selection starts
+	vimAnchorCol  int
+	vimLinewise   bool // Whether the clipboard holds whole lines yanked in vim mode
 	clipboard     string
-	files         []string // top level file names
-	options       []string // options listed in the status bar
-	optionIdx     int      // current option index
+	pasting       *strings.Builder // collects a bracketed paste, nil if not pasting
+	pendingKeys   string           // prefix of a key sequence waiting for the next key
+	pendingSince  time.Time
+	files         []string   // top level file names
+	tasks         tasks      // long-running operations
+	messages      []message  // history of status messages
+	message       *message   // message kept in the status bar
+	options       []string   // options listed in the status bar
+	optionIdx     int        // current option index
+	optionTop     int        // first option shown, scrolled by the wheel
+	menuIdx       int        // selected item of the menu
+	hints         []jumpHint // labels of positions to jump to, see startHints
+	hintTyped     string     // typed letters of a label
 }
 
 type Tab struct {
 	filename     string
-	lines        *list.List          // element is rune slice
+	title        string              // shown in the tabbar instead of the file name
+	lines        *lineBuffer         // lines of the text, see lineBuffer
 	row          int                 // Current row position (starts from 0)
 	col          int                 // Current column position (starts from 0)
 	top          int                 // vertical scroll  (starts from 0)
@@ -62,13 +132,40 @@ type Tab struct {
 	hint         string
 	hintOff      int
 	selecting    bool
+	lineSelect   bool // whether the selection spans whole lines, extended by shift-up/down
+	lineAnchor   int  // row where the line-wise selection starts
 	selection    *Selection
+	cursors      []Selection // multiple cursors in order, each on a single line
 	changes      []Change
 	changeIndex  int
+	saved        int  // number of changes applied at the last save, -1 if lost from the history
+	dirtyShown   bool // whether the tabbar shows the tab as dirty
 	lastChange   *Change
 	backStack    []int
 	forwardStack []int
 	prevLineNum  int
+	scrollBind   bool                                  // Whether the vertical scroll is locked with other bound tabs
+	readOnly     bool                                  // Whether the tab is a generated buffer not to edit
+	scratch      bool                                  // Whether the tab is a throwaway buffer, never dumped or restored
+	onSave       func(a *App, text string)             // called instead of writing a file on save
+	onKey        func(a *App, ev *tcell.EventKey) bool // handles the keys of a generated tab, false for the default
+	rawKey       func(a *App, ev *tcell.EventKey)      // gets every key before the key bindings, see viewKeys
+	lineErrors   map[int]string                        // errors by row, shown in the gutter
+	marks        map[rune]*mark                        // marks a-z of the tab
+	annotations  map[string]map[int]gutterMarker       // gutter markers by owner and row, see annotate
+	edits        int                                   // counts the changes of the text
+	checkedEdits int                                   // the edits when last checked for unused code, see scheduleCheck
+	unused       []unusedRange                         // unused imports and variables, dimmed
+	checkTimer   *time.Timer                           // checks for unused code when the typing pauses
+	tailStop     context.CancelFunc                    // stops following the growth of the file, see toggleTail
+	ansi         [][]ansiSpan                          // styles of the ANSI escape sequences by row, see interpretANSI
+	modTime      time.Time                             // modification time of the file when loaded or saved
+	lockFile     string                                // path of the lock file held for the file, see lock
+	lockedBy     string                                // owner of the lock of the file held by another editor
+	share        shareSession                          // the share of the tab edited together, see toggleShare
+	vars         map[string]string                     // variables local to the tab, see let
+	lastSelect   *Selection                            // the selection dropped or replaced last, see reselect
+	expandStack  []Selection                           // the selections expanded from, see expandSelection
 }
 
 type Selection struct {
@@ -78,25 +175,55 @@ type Selection struct {
 	endCol   int
 }
 
-// line returns the list element at the specified line index, or nil if out of bounds.
-func (t *Tab) line(i int) *list.Element {
-	if t.lines.Len() == 0 || i > t.lines.Len()-1 {
-		return nil
+// name returns the name shown in the tabbar.
+func (t *Tab) name() string {
+	if t.title != "" {
+		return t.title
+	}
+	name := "untitled"
+	if t.filename != "" {
+		name = filepath.Base(t.filename)
 	}
+	if t.dirty() {
+		name += "*"
+	}
+	return name
+}
 
-	if i < t.lines.Len()/2 {
-		e := t.lines.Front()
-		for range i {
-			e = e.Next()
-		}
-		return e
+// applied returns the number of changes applied, that is not undone.
+func (t *Tab) applied() int {
+	if len(t.changes) == 0 {
+		return 0
 	}
+	return t.changeIndex + 1
+}
+
+// dirty reports whether the text differs from the last saved.
+func (t *Tab) dirty() bool {
+	return t.applied() != t.saved
+}
+
+// markSaved records the current text as saved.
+func (t *Tab) markSaved() {
+	t.saved = t.applied()
+	t.lastChange = nil // further edits must not merge into the saved change
+}
+
+// line returns the line at the specified row, or nil if out of bounds.
+func (t *Tab) line(i int) *lineElem {
+	return t.lines.At(i)
+}
 
-	e := t.lines.Back()
-	for j := t.lines.Len() - 1; j > i; j-- {
-		e = e.Prev()
+// syncScroll locks the vertical scroll of the tabs bound together.
+func (st *State) syncScroll() {
+	if !st.scrollBind {
+		return
+	}
+	for _, t := range st.tabs {
+		if t != st.Tab && t.scrollBind {
+			t.top = min(st.top, max(0, t.lines.Len()-1))
+		}
 	}
-	return e
 }
 
 // switchTab clears the editor and switch to the specified tab.
@@ -118,12 +245,12 @@ type View struct {
 // draw draws a line and clears the remaining space
 func (v *View) draw(line []rune) {
 	col := 0
-	for _, c := range line {
+	for _, g := range graphemes(line) {
 		if col >= v.w {
 			return
 		}
-		screen.SetContent(v.x+col, v.y, c, nil, v.style)
-		col += runewidth.RuneWidth(c)
+		screen.SetContent(v.x+col, v.y, g[0], g[1:], v.style)
+		col += graphemeWidth(g)
 	}
 	// Clear remaining space
 	for i := col; i < v.w; i++ {
@@ -137,20 +264,29 @@ type textStyle struct {
 }
 
 // drawTexts draw inline texts with different styles.
+// A grapheme cluster spanning several texts takes the style of its first rune.
 func (v *View) drawTexts(texts []textStyle) {
-	col := 0
+	var runes []rune
+	var styles []tcell.Style
 	for _, ts := range texts {
 		style := ts.style
 		if style == tcell.StyleDefault {
 			style = v.style
 		}
 		for _, c := range ts.text {
-			if col >= v.w {
-				break
-			}
-			screen.SetContent(v.x+col, v.y, c, nil, style)
-			col += runewidth.RuneWidth(c)
+			runes = append(runes, c)
+			styles = append(styles, style)
+		}
+	}
+	col := 0
+	i := 0
+	for _, g := range graphemes(runes) {
+		if col >= v.w {
+			break
 		}
+		screen.SetContent(v.x+col, v.y, g[0], g[1:], styles[i])
+		col += graphemeWidth(g)
+		i += len(g)
 	}
 	// Clear remaining space
 	for i := col; i < v.w; i++ {
@@ -162,25 +298,61 @@ func (v *View) contains(x, y int) bool {
 	return x >= v.x && x < v.x+v.w && y >= v.y && y < v.y+v.h
 }
 
+// Screen sizes of the fallback layouts.
+const (
+	minWidth      = 20 // narrower shows the too small screen
+	minHeight     = 2  // lower shows the too small screen
+	compactHeight = 4  // lower hides the tabbar and the status bar
+)
+
 func (a *App) resize() {
 	w, h := screen.Size()
-	a.tabbar = View{0, 0, w, 1, styleComment}
-	a.editor = make([]*View, h-3)
-	for i := range a.editor {
-		a.editor[i] = &View{0, i + a.tabbar.h, w, 1, tcell.StyleDefault}
+	a.tooSmall = w < minWidth || h < minHeight
+	// views out of the screen are not drawn
+	off := 0
+	if a.tooSmall {
+		// lay out the smallest screen below the real one, so there is always a line to draw
+		off = h
+		w, h = max(w, minWidth), max(h, minHeight)
+	}
+	a.tabbar = View{0, off, w, 1, styleComment}
+	a.status = View{0, off + h - 2, w, 1, styleComment}
+	top, rows := a.tabbar.y+a.tabbar.h, h-3
+	if h < compactHeight {
+		// no room for the tabbar and the status bar
+		a.tabbar.y, a.status.y = -1, -1
+		top, rows = off, h-1
+	}
+	if len(a.panes) > 0 {
+		a.layoutPanes(0, top, w, rows)
+	} else {
+		a.editor = editorViews(0, top, w, rows)
+	}
+	a.console = View{0, off + h - 1, w, 1, tcell.StyleDefault}
+	// the dropdown is laid out again when the options are shown
+	a.dropdownRows = 0
+}
+
+// drawTooSmall tells the screen is too small in place of the layout.
+func (a *App) drawTooSmall() {
+	w, h := screen.Size()
+	screen.Fill(' ', styleBase)
+	screen.HideCursor()
+	if w > 0 && h > 0 {
+		v := View{0, (h - 1) / 2, w, 1, styleBase}
+		v.draw([]rune("window too small"))
 	}
-	a.status = View{0, h - 2, w, 1, styleComment}
-	a.console = View{0, h - 1, w, 1, tcell.StyleDefault}
 }
 
-const tabSize = 4
+// tabSize is the number of columns of a tab stop, see the tabsize setting.
+var tabSize = 4
 
 // expandTabs converts all tabs in a line to spaces for display
 func expandTabs(line []rune) []rune {
 	newline := make([]rune, 0, len(line))
 	col := 0
-	for _, char := range line {
-		if char == '\t' {
+	for _, g := range graphemes(line) {
+		if g[0] == '\t' {
 			// Add spaces to reach the next tab stop
 			spaces := tabSize - (col % tabSize)
 			for range spaces {
@@ -188,13 +360,90 @@ func expandTabs(line []rune) []rune {
 			}
 			col += spaces
 		} else {
-			newline = append(newline, char)
-			col += runewidth.RuneWidth(char)
+			newline = append(newline, g...)
+			col += graphemeWidth(g)
 		}
 	}
 	return newline
 }
 
+// graphemes splits the line into grapheme clusters (user-perceived characters),
+// so that emoji ZWJ sequences and combining accents stay together.
+func graphemes(line []rune) [][]rune {
+	clusters := make([][]rune, 0, len(line))
+	rest := string(line)
+	state := -1
+	i := 0
+	for len(rest) > 0 {
+		var cluster string
+		cluster, rest, _, state = uniseg.FirstGraphemeClusterInString(rest, state)
+		n := utf8.RuneCountInString(cluster)
+		clusters = append(clusters, line[i:i+n])
+		i += n
+	}
+	return clusters
+}
+
+// Screen widths of East Asian ambiguous characters and emoji, 1 or 2,
+// set to match the terminal. 0 means following runewidth.
+var (
+	ambiguousWidth int
+	emojiWidth     int
+)
+
+// updateWidthCondition applies the width settings to runewidth.DefaultCondition,
+// which is shared with tcell, so the cursor matches the rendered text.
+func updateWidthCondition() {
+	if ambiguousWidth > 0 {
+		runewidth.DefaultCondition.EastAsianWidth = ambiguousWidth == 2
+	}
+	runewidth.DefaultCondition.StrictEmojiNeutral = emojiWidth != 2
+}
+
+// isEmoji reports whether the grapheme cluster is rendered as an emoji,
+// either a pictograph or a character in emoji presentation.
+func isEmoji(g []rune) bool {
+	for _, r := range g {
+		if r == 0xFE0F || r == 0x200D { // emoji presentation selector, zero width joiner
+			return true
+		}
+	}
+	r := g[0]
+	return (r >= 0x1F000 && r <= 0x1FAFF) || (r >= 0x2600 && r <= 0x27BF)
+}
+
+// graphemeWidth returns the screen width of a grapheme cluster.
+func graphemeWidth(g []rune) int {
+	if emojiWidth > 0 && isEmoji(g) {
+		return emojiWidth
+	}
+	return runewidth.StringWidth(string(g))
+}
+
+// nextGrapheme returns the column right after the grapheme cluster at col.
+func nextGrapheme(line []rune, col int) int {
+	i := 0
+	for _, g := range graphemes(line) {
+		i += len(g)
+		if i > col {
+			return i
+		}
+	}
+	return len(line)
+}
+
+// prevGrapheme returns the column where the grapheme cluster before col starts.
+func prevGrapheme(line []rune, col int) int {
+	i := 0
+	for _, g := range graphemes(line) {
+		if i+len(g) >= col {
+			return i
+		}
+		i += len(g)
+	}
+	return i
+}
+
 // columnToVisual converts a column index in the line to column index in screen line
 func columnToVisual(line []rune, col int) int {
 	if col > len(line) {
@@ -213,74 +462,76 @@ func columnToVisual(line []rune, col int) int {
 
 // columnToScreenWidth converts a column index in the line to its screen width,
 // accounting for tabs and Unicode character widths (e.g., double-width for East Asian characters).
+// A column beyond the line end is in virtual space, taking one screen cell each.
 func columnToScreenWidth(line []rune, col int) int {
+	screenCol := 0
 	if col > len(line) {
+		screenCol = col - len(line)
 		col = len(line)
 	}
-	screenCol := 0
-	for i, char := range line {
+	i := 0
+	for _, g := range graphemes(line) {
 		if i >= col {
 			break
 		}
-		if char == '\t' {
+		if g[0] == '\t' {
 			spaces := tabSize - (screenCol % tabSize)
 			screenCol += spaces
 		} else {
-			screenCol += runewidth.RuneWidth(char)
+			screenCol += graphemeWidth(g)
 		}
+		i += len(g)
 	}
 	return screenCol
 }
 
 // columnFromScreenWidth converts screen width to column index in the line.
-// Use this to get the line column index from screen width
+// Use this to get the line column index from screen width.
+// The column always lands on the start of a grapheme cluster.
 func columnFromScreenWidth(line []rune, screenCol int) int {
 	if screenCol <= 0 {
 		return 0
 	}
 	width := 0
-	for i, char := range line {
-		if char == '\t' {
+	i := 0
+	for _, g := range graphemes(line) {
+		if g[0] == '\t' {
 			spaces := tabSize - (width % tabSize)
 			width += spaces
 		} else {
-			width += runewidth.RuneWidth(char)
+			width += graphemeWidth(g)
 		}
 		if screenCol < width {
 			return i
 		}
+		i += len(g)
 	}
 	return len(line)
 }
 
 // draw the whole layout and cursor
 func (a *App) draw() {
+	if a.tooSmall {
+		a.drawTooSmall()
+		return
+	}
 	a.drawTabs()
 	a.drawEditor()
+	a.drawPanes(true)
 	a.console.draw(a.s.command)
+	if a.s.focus == focusMenu {
+		a.drawMenu()
+	}
 	a.syncCursor()
 }
 
-const (
-	labelClose = "x|"
-	labelNew   = "New"
-	labelOpen  = "Open"
-	labelSave  = "Save"
-	labelQuit  = "Quit"
-)
-
-var menu = []string{labelNew, labelOpen, labelSave, labelQuit}
+const labelClose = "x|"
 
 func (a *App) drawTabs() {
 	var ts []textStyle
 	var totalTabWidth int
 	for i, tab := range a.s.tabs {
-		var name string
-		if tab.filename == "" {
-			name = "untitled"
-		} else {
-			name = filepath.Base(tab.filename)
-		}
+		name := tab.name()
 		style := a.tabbar.style
 		if i == a.s.tabIdx {
 			style = styleBase
@@ -289,18 +540,15 @@ func (a *App) drawTabs() {
 		ts = append(ts, textStyle{text: []rune{' '}})
 		ts = append(ts, textStyle{text: []rune(labelClose)})
 		ts = append(ts, textStyle{text: []rune{' '}})
-		for _, c := range name {
-			totalTabWidth += runewidth.RuneWidth(c)
-		}
+		totalTabWidth += runewidth.StringWidth(name)
 		totalTabWidth += len(labelClose) + 2
 	}
 
-	menuS := strings.Join(menu, " ")
-	padding := a.tabbar.w - totalTabWidth - len(menuS)
+	padding := a.tabbar.w - totalTabWidth - runewidth.StringWidth(labelMenu)
 	if padding > 0 {
 		ts = append(ts, textStyle{text: []rune(strings.Repeat(" ", padding))})
 	}
-	ts = append(ts, textStyle{text: []rune(menuS)})
+	ts = append(ts, textStyle{text: []rune(labelMenu), style: styleBase})
 	a.tabbar.drawTexts(ts)
 }
 
@@ -349,14 +597,15 @@ func (a *App) drawEditorLine(row int, line []rune) {
 		lineNum.text = []rune(a.s.newLineNum(row))
 		lineNum.style = styleComment
 		if row == a.s.row {
-			lineNum.style = styleBase.Background(tcell.ColorLightGray)
+			lineNum.style = styleBase.Background(colorLineNumber)
 		}
 	}
 	if len(line) == 0 {
-		texts := []textStyle{lineNum}
-		if sel := a.s.selected(); sel != nil && sel.startRow <= row && row <= sel.endRow {
+		texts := a.s.withGutterMarker(lineNum, row)
+		sel := a.s.selected()
+		if (sel != nil && sel.startRow <= row && row <= sel.endRow) || a.s.hasCursor(row) {
 			// make selection visible on empty line
-			style := styleBase.Background(tcell.ColorLightSteelBlue)
+			style := styleBase.Background(colorSelection)
 			texts = append(texts, textStyle{text: []rune{' '}, style: style})
 		}
 		a.editor[row-a.s.top].drawTexts(texts)
@@ -367,26 +616,37 @@ func (a *App) drawEditorLine(row int, line []rune) {
 	screenLine := expandTabs(line)
 	if a.s.left > 0 {
 		screenCol := 0
-		for i, r := range screenLine {
-			screenCol += runewidth.RuneWidth(r)
+		i := 0
+		for _, g := range graphemes(screenLine) {
+			screenCol += graphemeWidth(g)
+			i += len(g)
 			if screenCol >= a.s.left {
-				screenLine = screenLine[i+1:]
+				screenLine = screenLine[i:]
 				break
 			}
 		}
 		if screenCol < a.s.left {
-			a.editor[row-a.s.top].drawTexts([]textStyle{lineNum})
+			a.editor[row-a.s.top].drawTexts(a.s.withGutterMarker(lineNum, row))
 			return
 		}
 	}
 
 	// highlight syntax
 	var coloredLine []textStyle
-	if filepath.Ext(a.s.filename) == ".go" {
-		coloredLine = highlightGoLine(screenLine)
+	if ft := fileTypeOf(a.s.filename); ft.highlight != nil {
+		coloredLine = ft.highlight(screenLine)
 	} else {
 		coloredLine = []textStyle{{text: screenLine, style: styleBase}}
 	}
+	coloredLine = a.overlayANSI(coloredLine, row, line)
+	coloredLine = a.highlightTodos(coloredLine, line)
+
+	if f := a.s.flash; f != nil && f.row == row {
+		coloredLine = highlightRange(coloredLine, columnToVisual(line, f.start)-a.s.left, columnToVisual(line, f.end)-a.s.left, colorFlash)
+	}
+	if a.s.isDebugLine(row) {
+		coloredLine = highlightRange(coloredLine, 0, a.editor[0].w-a.s.lineNumLen(), colorDebugLine)
+	}
 
 	// highlight selection
 	if sel := a.s.selected(); sel != nil && sel.startRow <= row && row <= sel.endRow {
@@ -397,29 +657,110 @@ func (a *App) drawEditorLine(row int, line []rune) {
 		if sel.endRow == row {
 			end = columnToVisual(line, sel.endCol) - a.s.left
 		}
-
-		i := 0
-		newLine := make([]textStyle, 0, len(screenLine))
-		for _, ts := range coloredLine {
-			for _, r := range ts.text {
-				if start <= i && i < end {
-					style := ts.style.Background(tcell.ColorLightSteelBlue)
-					newLine = append(newLine, textStyle{text: []rune{r}, style: style})
-				} else {
-					newLine = append(newLine, textStyle{text: []rune{r}, style: ts.style})
-				}
-				i++
-			}
-		}
-		coloredLine = newLine
+		coloredLine = highlightRange(coloredLine, start, end, colorSelection)
 	} else if a.s.hint != "" && row == a.s.row {
 		hint := a.s.hint[a.s.hintOff:]
 		coloredLine = append(coloredLine, textStyle{text: []rune(hint), style: styleComment})
 	}
-	a.editor[row-a.s.top].drawTexts(slices.Concat([]textStyle{lineNum}, coloredLine))
+
+	// highlight multiple cursors
+	for _, c := range a.s.cursors {
+		if c.startRow != row {
+			continue
+		}
+		start := columnToVisual(line, c.startCol) - a.s.left
+		end := columnToVisual(line, c.endCol) - a.s.left
+		if start == end {
+			end++ // make the caret visible
+		}
+		coloredLine = highlightRange(coloredLine, start, end, colorSelection)
+	}
+	if len(a.s.hints) > 0 {
+		coloredLine = a.overlayHints(coloredLine, row, line)
+	}
+	if len(a.s.unused) > 0 {
+		coloredLine = a.dimUnused(coloredLine, row, line)
+	}
+	if a.s.inlayHints && len(a.s.symbols) > 0 {
+		coloredLine = a.overlayInlays(coloredLine, row, line)
+	}
+	if ft := fileTypeOf(a.s.filename); ft.colors {
+		coloredLine = a.overlaySwatches(coloredLine, row, line)
+	} else if ft.rulers != nil {
+		coloredLine = a.drawRulers(coloredLine, ft.rulers)
+	}
+	coloredLine = a.markClipped(coloredLine, row, line)
+	a.editor[row-a.s.top].drawTexts(slices.Concat(a.s.withGutterMarker(lineNum, row), coloredLine))
+}
+
+// markClipped replaces the first and the last visible runes of the line with "…" if the line
+// continues beyond the left or the right edge of the editor, except the rune under the cursor.
+func (a *App) markClipped(texts []textStyle, row int, line []rune) []textStyle {
+	width := a.editor[row-a.s.top].w - a.s.lineNumLen()
+	var cols []int
+	if a.s.left > 0 {
+		cols = append(cols, 0)
+	}
+	if columnToVisual(line, len(line))-a.s.left > width {
+		cols = append(cols, width-1)
+	}
+	if len(cols) == 0 {
+		return texts
+	}
+	cursor := -1
+	if row == a.s.row {
+		cursor = columnToVisual(line, a.s.col) - a.s.left
+	}
+	var runes []textStyle
+	for _, ts := range texts {
+		for _, r := range ts.text {
+			runes = append(runes, textStyle{text: []rune{r}, style: ts.style})
+		}
+	}
+	for _, col := range cols {
+		if col != cursor && col >= 0 && col < len(runes) {
+			runes[col] = textStyle{text: []rune{'…'}, style: styleComment}
+		}
+	}
+	return runes
+}
+
+// highlightRange sets the background of the runes in [start, end) of the styled texts,
+// padding spaces if the range exceeds the texts.
+func highlightRange(texts []textStyle, start, end int, bg tcell.Color) []textStyle {
+	i := 0
+	newTexts := make([]textStyle, 0, len(texts))
+	for _, ts := range texts {
+		for _, r := range ts.text {
+			style := ts.style
+			if start <= i && i < end {
+				style = style.Background(bg)
+			}
+			newTexts = append(newTexts, textStyle{text: []rune{r}, style: style})
+			i++
+		}
+	}
+	for ; i < end; i++ {
+		style := styleBase
+		if i >= start {
+			style = style.Background(bg)
+		}
+		newTexts = append(newTexts, textStyle{text: []rune{' '}, style: style})
+	}
+	return newTexts
 }
 
 func (a *App) drawEditor() {
+	a.s.syncScroll()
+	a.drawLines()
+	a.drawPanes(false)
+	if a.dropdownRows > 0 {
+		a.drawDropdown()
+	}
+}
+
+// drawLines draws the lines of the tab in the editor.
+func (a *App) drawLines() {
 	if a.s.lines.Len() == 0 {
 		// clear the editor area
 		for _, lineView := range a.editor {
@@ -428,10 +769,7 @@ func (a *App) drawEditor() {
 		return
 	}
 
-	e := a.s.lines.Front()
-	for range a.s.top {
-		e = e.Next()
-	}
+	e := a.s.line(a.s.top)
 	remainLines := a.s.lines.Len() - a.s.top
 
 	for i, lineView := range a.editor {
@@ -439,7 +777,7 @@ func (a *App) drawEditor() {
 			lineView.draw(nil)
 			continue
 		}
-		line := e.Value.([]rune)
+		line := e.Value
 		a.drawEditorLine(a.s.top+i, line)
 		e = e.Next()
 	}
@@ -450,16 +788,26 @@ var screen tcell.Screen
 const (
 	focusEditor = iota
 	focusConsole
+	focusMenu
 )
 
 func main() {
-	output := os.Getenv("TINO_LOG_FILE")
-	if output == "" {
+	lineNumber := flag.Bool("line-numbers", true, "show line numbers")
+	themeFlag := flag.String("theme", "", "the color `theme`, light or dark, defaults to the background of the terminal")
+	readOnly := flag.Bool("readonly", false, "open the file read-only")
+	output := flag.String("log", os.Getenv("TINO_LOG_FILE"), "write logs to the `file`, defaults to $TINO_LOG_FILE")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [file]\n\nFlags:\n", filepath.Base(os.Args[0]))
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *output == "" {
 		log.SetOutput(io.Discard)
 	} else {
-		f, err := os.OpenFile(output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		f, err := os.OpenFile(*output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 		if err != nil {
-			log.Fatalf("Failed to open log file %s: %v", output, err)
+			log.Fatalf("Failed to open log file %s: %v", *output, err)
 		}
 		defer f.Close()
 		log.SetOutput(f)
@@ -470,21 +818,54 @@ func main() {
 		cmdCh: make(chan string, 1),
 		done:  make(chan struct{}),
 		s: &State{
-			lineNumber: true,
-			tabs:       []*Tab{{filename: "", lines: list.New()}},
+			lineNumber:   true,
+			mouse:        true,
+			smartCase:    true,
+			indent:       "\t",
+			formatOnSave: true,
+			autoPair:     true,
+			ansiColors:   true,
+			tabs:         []*Tab{{filename: "", lines: newLineBuffer()}},
 		},
 	}
 	app.s.Tab = app.s.tabs[0]
+	applyTheme(defaultTheme(os.Getenv("COLORFGBG")))
+	// the project config overrides the user config
+	configErr := app.s.loadConfig(userConfigPath())
+	if err := app.s.loadConfig(projectConfig); err != nil {
+		configErr = err
+	}
+	// flags override the config
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "line-numbers":
+			app.s.lineNumber = *lineNumber
+		case "theme":
+			if *themeFlag != themeName {
+				if err := applyTheme(*themeFlag); err != nil {
+					fmt.Println(err)
+					os.Exit(2)
+				}
+			}
+		}
+	})
+	updateWidthCondition()
 	go app.commandLoop()
-	if len(os.Args) >= 2 {
-		filename := os.Args[1]
+	var lockErr error
+	if flag.NArg() >= 1 {
+		filename := flag.Arg(0)
 		app.s.filename = filename
+		app.s.readOnly = *readOnly
 		f, err := os.Open(filename)
 		if err != nil {
 			if !errors.Is(err, fs.ErrNotExist) {
 				fmt.Println(err)
 				return
 			}
+			if err := app.s.loadNewFile(); err != nil {
+				fmt.Println(err)
+				return
+			}
 		} else {
 			err = app.s.loadSource(f)
 			f.Close()
@@ -492,6 +873,10 @@ func main() {
 				fmt.Println(err)
 				return
 			}
+			app.s.statFile()
+		}
+		if app.s.lockFiles {
+			lockErr = app.s.lock()
 		}
 	}
 
@@ -505,24 +890,50 @@ func main() {
 	}
 	screen = s
 	s.SetStyle(styleBase)
-	s.SetCursorStyle(tcell.CursorStyleBlinkingBar, cursorColor)
-	s.EnableMouse()
+	app.updateCursorStyle()
+	if app.s.mouse {
+		s.EnableMouse()
+	}
 	s.EnablePaste()
 	s.Clear()
-	quit := func() {
-		err := recover()
-		s.Fini()
-		if err != nil {
-			panic(err)
+	defer func() {
+		if err := recover(); err != nil {
+			app.crash(err)
 		}
-	}
-	defer quit()
+		app.cleanup()
+		s.Fini()
+	}()
 	eventCh := make(chan tcell.Event, 10)
 	go s.ChannelEvents(eventCh, app.done)
+	app.watchSuspend()
+	app.watchTerminate()
+	app.watchFiles()
+	if configErr != nil {
+		s.PostEvent(tcell.NewEventInterrupt(func() { app.showError(configErr.Error()) }))
+	}
+	if lockErr != nil {
+		s.PostEvent(tcell.NewEventInterrupt(func() { app.showError(lockErr.Error()) }))
+	}
 
 	for {
-		// Update screen
-		s.Show()
+		app.trackSelection()
+		if app.s.edits != app.s.checkedEdits {
+			app.scheduleCheck()
+		}
+		if dirty := app.s.dirty(); dirty != app.s.dirtyShown {
+			// show or clear the unsaved marker in the tabbar
+			app.s.dirtyShown = dirty
+			app.drawTabs()
+		}
+		// Update screen after the pending events, to draw only the final state of a burst
+		if len(eventCh) == 0 {
+			if app.staleEditor {
+				app.staleEditor = false
+				app.drawEditor()
+				app.syncCursor()
+			}
+			app.present()
+		}
 		select {
 		case <-app.done:
 			return
@@ -532,8 +943,81 @@ func main() {
 				app.resize()
 				app.draw()
 				s.Sync()
+			case *tcell.EventInterrupt:
+				switch data := ev.Data().(type) {
+				case func():
+					// run on the main goroutine
+					data()
+				case progressEvent:
+					app.drawProgress()
+				case frameEvent:
+					// the delayed update is done before the next select
+				case chordTimeoutEvent:
+					if app.s.pendingKeys != "" && time.Since(app.s.pendingSince) >= chordTimeout {
+						app.s.pendingKeys = ""
+						app.syncCursor()
+					}
+				}
+			case *tcell.EventPaste:
+				// bracketed paste, collect the keys in between and insert them at once
+				if ev.Start() {
+					app.s.pasting = &strings.Builder{}
+					continue
+				}
+				if app.s.pasting == nil {
+					continue
+				}
+				text := app.s.pasting.String()
+				app.s.pasting = nil
+				if text == "" {
+					continue
+				}
+				switch app.s.focus {
+				case focusEditor:
+					app.paste(text)
+					app.syncCursor()
+				case focusConsole:
+					// the console holds a single line
+					text, _, _ = strings.Cut(text, "\n")
+					app.s.command = slices.Insert(app.s.command, app.s.commandCursor, []rune(text)...)
+					app.s.commandCursor += len([]rune(text))
+					app.console.draw(app.s.command)
+					app.syncCursor()
+				}
 			case *tcell.EventKey:
+				if app.s.pasting != nil {
+					switch ev.Key() {
+					case tcell.KeyRune:
+						app.s.pasting.WriteRune(ev.Rune())
+					case tcell.KeyEnter, tcell.KeyLF:
+						app.s.pasting.WriteRune('\n')
+					case tcell.KeyTab:
+						app.s.pasting.WriteRune('\t')
+					}
+					continue
+				}
 				log.Printf("Key pressed: %s %c", tcell.KeyNames[ev.Key()], ev.Rune())
+				if app.s.rawKey != nil && app.s.focus == focusEditor {
+					app.s.rawKey(app, ev)
+					continue
+				}
+				if ev.Key() == tcell.KeyEscape && app.s.tasks.cancelAll() {
+					continue
+				}
+				if len(app.s.hints) > 0 {
+					app.hintEvent(ev)
+					continue
+				}
+				if app.s.flash != nil {
+					app.clearFlash()
+				}
+				if app.s.focus == focusMenu {
+					app.menuEvent(ev)
+					continue
+				}
+				if app.handleChord(ev) {
+					continue
+				}
 				if ev.Key() == tcell.KeyCtrlQ {
 					close(app.done)
 					return
@@ -576,51 +1060,39 @@ func main() {
 						continue
 					}
 
-					var files []string
-					err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-						if err != nil {
-							return err
-						}
-						if strings.HasPrefix(d.Name(), ".") && d.IsDir() {
-							return filepath.SkipDir
-						}
-						if strings.HasPrefix(d.Name(), ".") || d.IsDir() {
-							return nil
-						}
-						rel, err := filepath.Rel(root, path)
-						if err != nil {
-							return err
-						}
-						files = append(files, rel)
-						return nil
-					})
-					if err != nil {
-						log.Print(err)
-						continue
-					}
-					app.s.files = files
-					app.s.options = files
-					app.s.optionIdx = -1 // no selected option by default
-					ts := make([]textStyle, 0, len(app.s.options))
-					for _, option := range app.s.options {
-						ts = append(ts, textStyle{text: []rune(option + " ")})
-					}
-					app.status.drawTexts(ts)
-
 					app.s.focus = focusConsole
 					app.setConsole("", "file name")
 					app.syncCursor()
+					t := app.s.tasks.start("indexing files", 0)
+					exclude := app.s.excludeDirs
+					go func() {
+						files, err := indexFiles(root, exclude, t)
+						t.finish()
+						if err != nil {
+							log.Print(err)
+							return
+						}
+						screen.PostEvent(tcell.NewEventInterrupt(func() {
+							app.s.files = files
+							if app.s.focus != focusConsole || len(app.s.command) > 0 {
+								return
+							}
+							app.s.options = files
+							app.s.optionIdx = -1 // no selected option by default
+							app.showOptions()
+						}))
+					}()
 					continue
 				}
 				if ev.Key() == tcell.KeyCtrlG {
 					app.s.focus = focusConsole
-					app.setConsole(":", "line number")
+					app.setConsole(":", "line[:column], +/-lines from the cursor")
 					app.syncCursor()
 					continue
 				}
 				if ev.Key() == tcell.KeyCtrlR {
 					app.s.focus = focusConsole
-					app.setConsole("@", "symbol")
+					app.setConsole("@", "symbol, func: type: var: const: to filter by kind")
 					app.syncCursor()
 					app.s.options = nil
 					app.s.optionIdx = -1
@@ -631,7 +1103,7 @@ func main() {
 					if sel := app.s.selected(); sel != nil && sel.startRow == sel.endRow {
 						e := app.s.line(sel.startRow)
 						if e != nil {
-							line := e.Value.([]rune)
+							line := e.Value
 							selected = string(line[sel.startCol:sel.endCol])
 						}
 					}
@@ -654,27 +1126,54 @@ func main() {
 					app.cmdCh <- ">save " + app.s.filename
 					continue
 				}
+				if ev.Key() == tcell.KeyF1 {
+					app.cmdCh <- ">help"
+					continue
+				}
+				if ev.Key() == tcell.KeyF10 {
+					app.openMenu()
+					continue
+				}
+				if cmd, ok := quickfixKey(ev); ok {
+					app.cmdCh <- cmd
+					continue
+				}
+				if cmd, ok := debugKeys[ev.Key()]; ok {
+					app.cmdCh <- cmd
+					continue
+				}
 				if ev.Key() == tcell.KeyCtrlT {
-					// new tab
-					app.s.tabs = append(app.s.tabs, &Tab{
-						filename: "",
-						lines:    list.New(),
-					})
-					app.s.switchTab(len(app.s.tabs) - 1)
-					app.draw()
+					app.newTab()
 					continue
 				}
 
 				switch app.s.focus {
 				case focusEditor:
-					app.editorEvent(ev)
+					if app.s.onKey != nil && app.s.onKey(app, ev) {
+						continue
+					}
+					if app.s.vim {
+						app.vimEvent(ev)
+					} else {
+						app.editorEvent(ev)
+					}
 				case focusConsole:
 					app.consoleEvent(ev)
 				}
 			case *tcell.EventMouse:
+				if !app.s.mouse {
+					continue
+				}
 				x, y := ev.Position()
 				switch ev.Buttons() {
 				case tcell.Button1:
+					if ev.Modifiers()&(tcell.ModCtrl|tcell.ModAlt) != 0 && !app.s.selecting && app.s.focus == focusEditor &&
+						len(app.editor) > 0 && y >= app.editor[0].y && y < app.editor[0].y+len(app.editor) {
+						// once per click, the release resets selecting
+						app.s.selecting = true
+						app.addCursorAt(x, y)
+						continue
+					}
 					// will receive this event many times
 					// when pressing left button and moving the mouse
 					// log.Print("Mouse left button clicked")
@@ -693,13 +1192,20 @@ func main() {
 						app.s.selection = nil
 					}
 				case tcell.WheelUp:
+					if app.s.focus == focusConsole && len(app.s.options) > 0 && (app.status.contains(x, y) || app.dropdownAt(y) >= 0) {
+						app.scrollOptions(-1)
+						continue
+					}
 					app.s.top -= int(float32(y) * scrollFactor)
 					if app.s.top < 0 {
 						app.s.top = 0
 					}
-					app.drawEditor()
-					app.syncCursor()
+					app.staleEditor = true
 				case tcell.WheelDown:
+					if app.s.focus == focusConsole && len(app.s.options) > 0 && (app.status.contains(x, y) || app.dropdownAt(y) >= 0) {
+						app.scrollOptions(1)
+						continue
+					}
 					// keep in viewport
 					if app.s.lines.Len() < len(app.editor) {
 						app.s.top = 0
@@ -708,67 +1214,33 @@ func main() {
 					app.s.top += int(float32(y) * scrollFactor)
 					if app.s.top > app.s.lines.Len()-len(app.editor) {
 						app.s.top = app.s.lines.Len() - len(app.editor)
-						continue
 					}
-					app.drawEditor()
-					app.syncCursor()
+					app.staleEditor = true
 				}
 			}
 		}
 	}
 }
 
-// A multiplier to be used on scrolling
-const scrollFactor = 0.1
+// A multiplier to be used on scrolling, see the scrollfactor setting.
+var scrollFactor float32 = 0.1
 
 func (a *App) handleClick(x, y int) {
+	if a.s.focus == focusMenu {
+		a.menuClick(x, y)
+		return
+	}
 	if a.tabbar.contains(x, y) {
 		if a.s.selecting {
 			return
 		}
 		var totalTabWidth int
 		for _, tab := range a.s.tabs {
-			tabName := tab.filename
-			if tabName == "" {
-				tabName = "untitled"
-			}
-			totalTabWidth += len(tabName) + len(labelClose) + 2
+			totalTabWidth += runewidth.StringWidth(tab.name()) + len(labelClose) + 2
 		}
-		sep := " "
-		menuS := strings.Join(menu, sep)
-		padding := max(0, a.tabbar.w-totalTabWidth-len(menuS))
 		// click menu
-		menuStart := a.tabbar.x + totalTabWidth + padding
-		if x >= menuStart {
-			start := menuStart
-			end := 0
-			for _, label := range menu {
-				end = start + len(label)
-				if x < start || x >= end {
-					start = end + len(sep) // separator
-					continue
-				}
-				switch label {
-				case labelNew:
-					a.s.tabs = slices.Insert(a.s.tabs, a.s.tabIdx+1, &Tab{filename: "", lines: list.New()})
-					a.s.switchTab(a.s.tabIdx + 1)
-					a.draw()
-					return
-				case labelOpen:
-					a.s.focus = focusConsole
-					a.setConsole(">open ")
-					a.syncCursor()
-					return
-				case labelSave:
-					if len(a.s.tabs) > 0 && a.s.tabIdx < len(a.s.tabs) {
-						a.cmdCh <- ">save " + a.s.filename
-					}
-					return
-				case labelQuit:
-					close(a.done)
-					return
-				}
-			}
+		if x >= a.tabbar.x+a.tabbar.w-runewidth.StringWidth(labelMenu) {
+			a.openMenu()
 			return
 		}
 
@@ -776,14 +1248,7 @@ func (a *App) handleClick(x, y int) {
 		if x < a.tabbar.x+totalTabWidth {
 			nameStart := a.tabbar.x
 			for i, tab := range a.s.tabs {
-				tabName := tab.filename
-				if tabName == "" {
-					tabName = "untitled"
-				}
-				nameEnd := nameStart
-				for _, char := range tabName {
-					nameEnd += runewidth.RuneWidth(char)
-				}
+				nameEnd := nameStart + runewidth.StringWidth(tab.name())
 				// A separator following a tab name is considered part of the name.
 				nameEnd += 1
 				closerEnd := nameEnd + len(labelClose)
@@ -820,25 +1285,55 @@ func (a *App) handleClick(x, y int) {
 	}
 
 	if a.status.contains(x, y) {
+		if len(a.s.options) > 0 {
+			a.optionClick(a.optionAt(x))
+		}
 		return
 	}
+	if i := a.dropdownAt(y); i >= 0 {
+		a.optionClick(i)
+		return
+	}
+	a.closeDropdown()
 
 	// click editor area
+	if i := a.paneAt(x, y); i >= 0 && i != a.paneIdx && !a.s.selecting {
+		a.focusPane(i)
+	}
 	a.s.focus = focusEditor
-	row, col := 0, 0
-	if a.s.lines.Len() > 0 {
-		row = min(y-a.editor[0].y+a.s.top, a.s.lines.Len()-1)
-		line := a.s.line(row).Value.([]rune)
-		screenCol := x - a.editor[0].x - a.s.lineNumLen() + a.s.left
-		col = columnFromScreenWidth(line, screenCol)
+	a.s.cursors = nil
+	row, col := a.editorPosition(x, y)
+
+	// click the gutter to select the line, drag to select more lines
+	inGutter := x-a.editor[0].x < a.s.lineNumLen()
+	if a.s.lines.Len() > 0 && (a.s.selecting && a.s.lineSelect || !a.s.selecting && inGutter) {
+		if !a.s.selecting && x == a.editor[0].x && fileTypeOf(a.s.filename).name == "go" {
+			// the column of gutter markers
+			a.toggleBreakpoint(row)
+			return
+		}
+		if !a.s.selecting {
+			a.recordPositon(a.s.row, a.s.col)
+			a.s.selecting = true
+			a.selectLines(row, row)
+			return
+		}
+		a.selectLines(a.s.lineAnchor, row)
+		return
 	}
 
+	// selection never goes into virtual space
+	selCol := col
+	if e := a.s.line(row); e != nil {
+		selCol = min(col, len(e.Value))
+	}
 	if !a.s.selecting {
-		a.s.selection = &Selection{startRow: row, startCol: col, endRow: row, endCol: col}
+		a.s.selection = &Selection{startRow: row, startCol: selCol, endRow: row, endCol: selCol}
 		a.s.selecting = true
+		a.s.lineSelect = false
 	} else {
 		a.s.selection.endRow = row
-		a.s.selection.endCol = col
+		a.s.selection.endCol = selCol
 	}
 
 	a.recordPositon(a.s.row, a.s.col)
@@ -847,7 +1342,7 @@ func (a *App) handleClick(x, y int) {
 	a.s.upDownCol = -1 // reset up/down column tracking
 	// debug
 	if line := a.s.line(row); line != nil {
-		log.Printf("clicked line: %s", string(line.Value.([]rune)))
+		log.Printf("clicked line: %s", string(line.Value))
 	}
 }
 
@@ -877,8 +1372,8 @@ func (a *App) jump(row, col int) {
 	if lineItem == nil {
 		return
 	}
-	line := lineItem.Value.([]rune)
-	if col < 0 || col > len(line) {
+	line := lineItem.Value
+	if col < 0 || (col > len(line) && !a.s.virtualSpace) {
 		col = len(line)
 	}
 	a.s.row = row
@@ -925,7 +1420,7 @@ func (a *App) jump(row, col int) {
 		a.drawEditorLine(row, line)
 		if row != a.s.prevLineNum && (a.s.top <= a.s.prevLineNum && a.s.prevLineNum < a.s.top+len(a.editor)) {
 			if e := a.s.line(a.s.prevLineNum); e != nil {
-				a.drawEditorLine(a.s.prevLineNum, e.Value.([]rune))
+				a.drawEditorLine(a.s.prevLineNum, e.Value)
 			}
 		}
 	}
@@ -935,6 +1430,9 @@ func (a *App) jump(row, col int) {
 
 func (a *App) consoleEvent(ev *tcell.EventKey) {
 	defer func() {
+		if a.s.focus != focusConsole {
+			a.closeDropdown()
+		}
 		a.console.draw(a.s.command)
 		a.syncCursor()
 	}()
@@ -945,21 +1443,44 @@ func (a *App) consoleEvent(ev *tcell.EventKey) {
 	switch ev.Key() {
 	case tcell.KeyEscape:
 		exitConsole()
+		a.restoreConsoleSelection()
 		// reset matched text
 		if line := a.s.line(a.s.row); line != nil {
-			a.drawEditorLine(a.s.row, line.Value.([]rune))
+			a.drawEditorLine(a.s.row, line.Value)
 		}
 	case tcell.KeyEnter:
 		cmd := strings.TrimSpace(string(a.s.command))
+		if ev.Modifiers()&tcell.ModAlt != 0 && strings.HasPrefix(cmd, "#") && len(cmd) > 1 {
+			// select all matches
+			a.s.command = nil
+			a.cmdCh <- ">findall " + cmd[1:]
+			return
+		}
+		if ev.Modifiers()&tcell.ModAlt != 0 && strings.HasPrefix(cmd, "=") && len(cmd) > 1 {
+			// insert the result at the cursor
+			v, err := evalExpr(cmd[1:])
+			if err != nil {
+				a.showError(err.Error())
+				return
+			}
+			exitConsole()
+			a.paste(formatValue(v))
+			return
+		}
+		// alt-enter opens the file or symbol picked in the adjacent pane
+		side := ev.Modifiers()&tcell.ModAlt != 0
 		if cmd == "" {
 			if len(a.s.options) > 0 && a.s.optionIdx >= 0 {
+				if side {
+					a.toSide()
+				}
 				a.cmdCh <- ">open " + a.s.options[a.s.optionIdx]
 			}
 			exitConsole()
 			return
 		}
 		switch cmd[0] {
-		case '#', ':', '>':
+		case '#', ':', '>', '=', '\'':
 			if len(cmd[1:]) == 0 {
 				exitConsole()
 				return
@@ -977,60 +1498,47 @@ func (a *App) consoleEvent(ev *tcell.EventKey) {
 			}
 			cmd = ">open " + a.s.options[a.s.optionIdx]
 		}
+		if side && (cmd[0] == '@' || strings.HasPrefix(cmd, ">open ")) {
+			a.toSide()
+		}
 		a.s.command = nil
 		a.cmdCh <- cmd
 	case tcell.KeyLeft:
 		if a.s.commandCursor > 1 {
-			a.s.commandCursor--
+			a.s.commandCursor = max(1, prevGrapheme(a.s.command, a.s.commandCursor))
 		}
 	case tcell.KeyRight:
 		if a.s.commandCursor < len(a.s.command) {
-			a.s.commandCursor++
+			a.s.commandCursor = nextGrapheme(a.s.command, a.s.commandCursor)
 		}
 	case tcell.KeyBackspace, tcell.KeyBackspace2:
 		if len(a.s.command) == 0 {
 			return
 		}
-		a.s.command = slices.Delete(a.s.command, a.s.commandCursor-1, a.s.commandCursor)
-		a.s.commandCursor--
+		start := prevGrapheme(a.s.command, a.s.commandCursor)
+		a.s.command = slices.Delete(a.s.command, start, a.s.commandCursor)
+		a.s.commandCursor = start
 		if len(a.s.command) == 0 {
 			a.s.options = a.s.files
 			a.s.optionIdx = -1
-		} else if char := a.s.command[0]; char == '#' || char == ':' || char == '>' {
+		} else if char := a.s.command[0]; char == '>' {
+			a.completeCommand()
+			return
+		} else if char == '#' || char == ':' || char == '=' || char == '\'' {
 			return
 		} else if char == '@' {
-			keyword := string(a.s.command[1:])
-			if len(keyword) == 0 {
+			query := string(a.s.command[1:])
+			if len(query) == 0 {
 				a.s.options = nil
 				a.status.draw(nil)
 				return
 			}
-			var filter []string
-			for _, v := range a.s.symbols {
-				for _, sym := range v {
-					name := sym.Name
-					if sym.Receiver != "" {
-						name = sym.Receiver + "." + sym.Name
-					}
-					if strings.Contains(strings.ToLower(name), strings.ToLower(keyword)) {
-						filter = append(filter, name)
-					}
-				}
-			}
+			filter := a.s.filterSymbols(query)
 			if len(filter) == 0 {
 				a.s.options = nil
 				a.status.draw(nil)
 				return
 			}
-			slices.Sort(filter)
-			j := 0
-			for i := range filter {
-				if strings.HasPrefix(strings.ToLower(filter[i]), strings.ToLower(keyword)) {
-					// move the relevant forward
-					filter[i], filter[j] = filter[j], filter[i]
-					j++
-				}
-			}
 			a.s.options = filter
 			a.s.optionIdx = 0
 		} else {
@@ -1066,39 +1574,22 @@ func (a *App) consoleEvent(ev *tcell.EventKey) {
 		a.s.command = slices.Insert(a.s.command, a.s.commandCursor, ev.Rune())
 		a.s.commandCursor++
 		switch a.s.command[0] {
-		case '>', '#', ':':
+		case '>':
+			a.completeCommand()
+			return
+		case '#', ':', '=', '\'':
 			return
 		case '@':
-			keyword := string(a.s.command[1:])
-			if keyword == "" {
+			query := string(a.s.command[1:])
+			if query == "" {
 				return
 			}
-			var filter []string
-			for _, v := range a.s.symbols {
-				for _, sym := range v {
-					name := sym.Name
-					if sym.Receiver != "" {
-						name = sym.Receiver + "." + sym.Name
-					}
-					if strings.Contains(strings.ToLower(name), strings.ToLower(keyword)) {
-						filter = append(filter, name)
-					}
-				}
-			}
+			filter := a.s.filterSymbols(query)
 			if len(filter) == 0 {
 				a.s.options = nil
 				a.status.draw(nil)
 				return
 			}
-			slices.Sort(filter)
-			j := 0
-			for i := range filter {
-				if strings.Index(strings.ToLower(filter[i]), strings.ToLower(keyword)) == 0 {
-					// move the relevant forward
-					filter[i], filter[j] = filter[j], filter[i]
-					j++
-				}
-			}
 			a.s.options = filter
 			a.s.optionIdx = 0
 			a.showOptions()
@@ -1139,16 +1630,28 @@ func (a *App) consoleEvent(ev *tcell.EventKey) {
 		} else {
 			a.s.optionIdx = (a.s.optionIdx - 1 + len(a.s.options)) % len(a.s.options)
 		}
+		if len(a.s.command) > 0 && a.s.command[0] == '>' {
+			a.fillCompletion()
+		}
 		a.showOptions()
 	case tcell.KeyCtrlUnderscore:
 		// go to previous found keyword
 		if len(a.s.command) > 0 && a.s.command[0] == '#' {
 			a.goBack()
-			keyword := a.s.command[1:]
+			start := a.s.col - len(a.s.command[1:])
+			if s, err := newSearcher(a.s.command[1:], a.s.smartCase); err == nil {
+				// the match ending at the cursor, a regexp match has its own length
+				line := a.s.line(a.s.row).Value
+				for i, j := s.index(line, 0); i >= 0 && j <= a.s.col; i, j = s.index(line, i+1) {
+					if j == a.s.col {
+						start = i
+					}
+				}
+			}
 			a.s.selection = &Selection{
 				startRow: a.s.row,
 				endRow:   a.s.row,
-				startCol: a.s.col - len(keyword),
+				startCol: start,
 				endCol:   a.s.col,
 			}
 			a.drawEditor()
@@ -1156,12 +1659,106 @@ func (a *App) consoleEvent(ev *tcell.EventKey) {
 	}
 }
 
+// openBuffer opens the text in a new tab not backed by a file.
+func (a *App) openBuffer(title, text string) {
+	a.s.tabs = append(a.s.tabs, &Tab{title: title, readOnly: true})
+	a.s.switchTab(len(a.s.tabs) - 1)
+	if err := a.s.loadSource(strings.NewReader(text)); err != nil {
+		a.showError(err.Error())
+		return
+	}
+	a.draw()
+}
+
+// isFile reports whether the tab is of the file at the absolute path.
+func (t *Tab) isFile(path string) bool {
+	if t.filename == "" {
+		return false
+	}
+	abs, err := filepath.Abs(t.filename)
+	return err == nil && abs == path
+}
+
+// findFile returns the index of the tab of the file at the absolute path, or -1.
+func (st *State) findFile(path string) int {
+	return slices.IndexFunc(st.tabs, func(t *Tab) bool { return t.isFile(path) })
+}
+
+// findBuffer returns the index of the tab opened by openBuffer with the title, or -1.
+func (st *State) findBuffer(title string) int {
+	return slices.IndexFunc(st.tabs, func(t *Tab) bool { return t.readOnly && t.title == title })
+}
+
+// setText replaces the lines of the tab with the text.
+func (t *Tab) setText(text string) {
+	t.lines = newLineBuffer()
+	for _, line := range strings.Split(text, "\n") {
+		t.lines.PushBack([]rune(line))
+	}
+	t.edits++
+	t.ansi = nil
+}
+
+// text returns the lines of the tab joined by newlines.
+func (t *Tab) text() string {
+	var b strings.Builder
+	for e := t.lines.Front(); e != nil; e = e.Next() {
+		b.WriteString(string(e.Value))
+		if e.Next() != nil {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// updateBuffer replaces the text of the tab opened by openBuffer with the title if any,
+// opening one if open is true. It returns whether the tab exists.
+func (a *App) updateBuffer(title, text string, open bool) bool {
+	i := a.s.findBuffer(title)
+	if i < 0 {
+		if open {
+			a.openBuffer(title, text)
+		}
+		return open
+	}
+	t := a.s.tabs[i]
+	t.setText(text)
+	if a.s.ansiColors {
+		t.interpretANSI(0)
+	}
+	if open && t != a.s.Tab {
+		a.s.switchTab(i)
+		a.draw()
+	}
+	if t == a.s.Tab {
+		a.jump(min(a.s.row, a.s.lines.Len()-1), 0)
+		a.drawEditor()
+	}
+	return true
+}
+
 // closeTab closes the tab at the specified index and adjusts the current tab selection.
 // It handles edge cases for tab index management and ensures a valid tab remains active.
 func (st *State) closeTab(index int) {
 	if index < 0 || index >= len(st.tabs) {
 		return
 	}
+	if stop := st.tabs[index].tailStop; stop != nil {
+		stop()
+	}
+	st.tabs[index].unlock()
+	if share := st.tabs[index].share; share != nil {
+		share.stop()
+	}
+	if st.lsp != nil {
+		st.lsp.close(st.tabs[index])
+	}
+	if t := st.tabs[index]; t.filename != "" && !t.readOnly {
+		st.closedTabs = append(st.closedTabs, closedTab{filename: t.filename, row: t.row, col: t.col, top: t.top})
+		if len(st.closedTabs) > maxClosedTabs {
+			st.closedTabs = st.closedTabs[1:]
+		}
+	}
 
 	st.tabs = slices.Delete(st.tabs, index, index+1)
 	if len(st.tabs) == 0 {
@@ -1185,6 +1782,36 @@ func (st *State) closeTab(index int) {
 	}
 }
 
+// maxClosedTabs is the number of closed tabs remembered to reopen.
+const maxClosedTabs = 20
+
+// closedTab is where a closed file tab was.
+type closedTab struct {
+	filename      string
+	row, col, top int
+}
+
+// reopenTab opens the most recently closed file tab again at the cursor position it had.
+func (a *App) reopenTab() {
+	n := len(a.s.closedTabs)
+	if n == 0 {
+		a.showMessage("No closed tab")
+		return
+	}
+	closed := a.s.closedTabs[n-1]
+	a.s.closedTabs = a.s.closedTabs[:n-1]
+	a.handleCommand(">open " + closed.filename)
+	if a.s.filename != closed.filename {
+		return
+	}
+	a.s.focus = focusEditor
+	row := min(closed.row, a.s.lines.Len()-1)
+	a.s.top = min(closed.top, row)
+	a.jump(row, min(closed.col, len(a.s.line(row).Value)))
+	a.drawEditor()
+	a.syncCursor()
+}
+
 // handleCommand processes a command string and performs actions based on its prefix.
 func (a *App) handleCommand(cmd string) {
 	// this function is called outside the main goroutine,
@@ -1214,9 +1841,20 @@ func (a *App) handleCommand(cmd string) {
 			}
 
 			file, err := os.Open(filename)
+			if errors.Is(err, fs.ErrNotExist) {
+				// create the file on save
+				a.s.tabs = append(a.s.tabs, &Tab{filename: filename, lines: newLineBuffer()})
+				a.s.switchTab(len(a.s.tabs) - 1)
+				if err := a.s.loadNewFile(); err != nil {
+					a.showError(err.Error())
+				}
+				a.draw()
+				a.showMessage("New file " + filename)
+				return
+			}
 			if err != nil {
 				log.Print(err)
-				a.status.draw([]rune(err.Error()))
+				a.showError(err.Error())
 				return
 			}
 			defer file.Close()
@@ -1225,12 +1863,23 @@ func (a *App) handleCommand(cmd string) {
 			err = a.s.loadSource(file)
 			if err != nil {
 				log.Print(err)
-				a.status.draw([]rune(err.Error()))
+				a.showError(err.Error())
 				return
 			}
+			a.s.statFile()
 			a.draw()
+			if a.s.lockFiles {
+				if err := a.s.lock(); err != nil {
+					a.showError(err.Error())
+				}
+			}
 			return
 		case "save":
+			if a.s.onSave != nil {
+				last := a.s.lines.Back().Value
+				a.s.onSave(a, a.s.textIn(0, 0, a.s.lines.Len()-1, len(last)))
+				return
+			}
 			if len(c) == 1 || len(c[1]) == 0 {
 				a.setConsole(">save ", "filename")
 				a.s.focus = focusConsole
@@ -1240,40 +1889,139 @@ func (a *App) handleCommand(cmd string) {
 			filename := c[1]
 			lines := make([]string, 0, a.s.lines.Len()+1)
 			for e := a.s.lines.Front(); e != nil; e = e.Next() {
-				lines = append(lines, string(e.Value.([]rune)))
+				lines = append(lines, string(e.Value))
 			}
 			// ensure a single newline at the end of file
 			if len(lines) == 0 || lines[len(lines)-1] != "" {
 				lines = append(lines, "")
 			}
 			src := []byte(strings.Join(lines, "\n"))
+			if a.s.lockedBy != "" && filename == a.s.filename {
+				if err := a.s.lock(); err != nil {
+					// warned once, saving again overwrites
+					a.s.lockedBy = ""
+					a.showError(err.Error() + ", save again to overwrite")
+					return
+				}
+			}
 			// format on save
-			if filepath.Ext(filename) == ".go" {
+			if filepath.Ext(filename) == ".go" && a.s.boolVariable("formatonsave", a.s.formatOnSave) {
+				t := a.s.tasks.start("formatting", 0)
 				bs, err := format.Source(src)
+				canceled := t.ctx.Err() != nil
+				t.finish()
+				if canceled {
+					a.showMessage("Save canceled")
+					return
+				}
 				if err != nil {
-					a.status.draw([]rune(err.Error()))
+					a.showError(err.Error())
 					log.Print(err)
 				} else {
 					src = bs
 				}
 			}
-			err := os.WriteFile(filename, src, 0644)
+			t := a.s.tasks.start("saving "+filepath.Base(filename), int64(len(src)))
+			err := writeFile(t, filename, src)
+			t.finish()
 			if err != nil {
 				log.Printf("Failed to save file %s: %v", filename, err)
-				a.status.draw([]rune("Failed to save file: " + err.Error()))
+				a.showError("Failed to save file: " + err.Error())
 			} else {
-				a.status.draw([]rune("File saved as: " + filename))
+				a.showMessage("File saved as: " + filename)
+				if filename != a.s.filename {
+					a.s.unlock()
+				}
 				a.s.filename = filename // update current tab
+				a.s.markSaved()
+				a.s.statFile()
+				if a.s.lockFiles {
+					a.s.lock()
+				}
+				if a.s.scratch {
+					// saved scratch becomes a normal file
+					a.s.scratch = false
+					a.s.title = ""
+				}
 				a.drawTabs()
 				a.s.focus = focusEditor
+				before := a.s.text()
 				if err := a.s.loadSource(bytes.NewReader(src)); err != nil {
-					a.status.draw([]rune(err.Error()))
+					a.showError(err.Error())
 					return
 				}
+				if a.s.share != nil {
+					// the formatting is an edit too
+					a.s.share.local(diffOps([]rune(before), []rune(a.s.text())))
+				}
+				if a.s.lsp != nil {
+					a.s.lsp.save(a.s.Tab)
+				}
 				a.s.row = min(a.s.row, a.s.lines.Len()-1)
 				a.s.col = 0
 				a.drawEditor()
 				a.syncCursor()
+				a.lintFile(filename, false)
+			}
+		case "json", "yaml":
+			op := ""
+			if len(c) > 1 {
+				op = c[1]
+			}
+			a.dataCommand(c[0], op)
+		case "bench":
+			pattern := ""
+			if len(c) > 1 {
+				pattern = c[1]
+			}
+			a.runBench(pattern)
+		case "pprof":
+			kind := ""
+			if len(c) > 1 {
+				kind = c[1]
+			}
+			a.showProfile(kind)
+		case "reload":
+			postFunc(a.reloadFile)
+		case "suspend":
+			postFunc(a.suspend)
+		case "reopen":
+			a.reopenTab()
+		case "man":
+			a.showMan(strings.Join(c[1:], " "))
+		case "wq":
+			a.saveAndQuit()
+		case "count":
+			a.showCount()
+		case "keys":
+			a.viewKeys()
+		case "share", "join":
+			addr := ""
+			if len(c) > 1 {
+				addr = c[1]
+			}
+			if c[0] == "share" {
+				a.toggleShare(addr)
+			} else {
+				a.joinShare(addr)
+			}
+		case "tail":
+			a.toggleTail()
+		case "todos":
+			a.listTodos()
+		case "testgen":
+			a.generateTest()
+		case "extractvar", "extractfunc":
+			name := ""
+			if len(c) > 1 {
+				name = c[1]
+			}
+			a.extract(c[0] == "extractfunc", name)
+		case "alt":
+			a.openAlt()
+		case "lint":
+			if !a.lintFile(a.s.filename, true) {
+				a.showMessage("No linter installed for the file, shellcheck for shell scripts and hadolint for Dockerfiles")
 			}
 		case "linenumber":
 			// toogle line number display
@@ -1282,6 +2030,256 @@ func (a *App) handleCommand(cmd string) {
 			a.s.focus = focusEditor
 			a.jump(a.s.row, a.s.col)
 			a.drawEditor()
+		case "ambiwidth", "emojiwidth":
+			if len(c) == 1 || (c[1] != "1" && c[1] != "2") {
+				a.showError("usage: >" + c[0] + " 1|2")
+				return
+			}
+			n, _ := strconv.Atoi(c[1])
+			if c[0] == "ambiwidth" {
+				ambiguousWidth = n
+			} else {
+				emojiWidth = n
+			}
+			updateWidthCondition()
+			a.s.focus = focusEditor
+			a.draw()
+			screen.Sync()
+		case "replace", "replaceall":
+			a.replace(strings.TrimPrefix(cmd[1:], c[0]), c[0] == "replaceall")
+		case "findall":
+			keyword := a.s.lastSearch
+			if len(c) > 1 {
+				keyword = []rune(strings.Join(c[1:], " "))
+			}
+			a.s.focus = focusEditor
+			if n := a.selectMatches(keyword); n == 0 {
+				a.showMessage("No match found")
+			}
+		case "grep":
+			a.grep([]rune(strings.Join(c[1:], " ")))
+		case "selectnext":
+			a.selectNext()
+		case "split", "vsplit":
+			a.split(c[0] == "vsplit")
+		case "closepane":
+			a.closePane()
+		case "movetab":
+			a.s.focus = focusEditor
+			a.moveTab()
+		case "nextpane":
+			a.s.focus = focusEditor
+			if len(a.panes) > 0 {
+				a.focusPane((a.paneIdx + 1) % len(a.panes))
+			}
+		case "resizepane":
+			if len(c) < 2 {
+				c = append(c, "")
+			}
+			a.resizePane(c[1])
+		case "let":
+			a.let(strings.TrimPrefix(cmd[1:], c[0]))
+		case "unlet":
+			a.s.focus = focusEditor
+			if len(c) < 2 || !a.s.unsetVariable(c[1]) {
+				a.showMessage("Usage: >unlet name, of a variable set")
+				break
+			}
+			a.showMessage("Removed " + c[1])
+		case "findtabs":
+			a.findInTabs([]rune(strings.Join(c[1:], " ")))
+		case "virtualspace":
+			a.s.virtualSpace = !a.s.virtualSpace
+			a.s.focus = focusEditor
+			a.jump(a.s.row, a.s.col)
+		case "overwrite":
+			a.s.focus = focusEditor
+			a.toggleOverwrite()
+		case "vim":
+			a.s.vim = !a.s.vim
+			a.s.vimMode = vimNormal
+			a.s.focus = focusEditor
+			a.unselect()
+			a.updateCursorStyle()
+			a.syncCursor()
+		case "repeat":
+			a.s.focus = focusEditor
+			a.repeatEdit()
+		case "indent", "unindent":
+			a.s.focus = focusEditor
+			if !a.editable() {
+				return
+			}
+			levels := 1
+			if len(c) > 1 {
+				n, err := strconv.Atoi(c[1])
+				if err != nil || n < 1 {
+					a.showError("Invalid count: " + c[1])
+					return
+				}
+				levels = n
+			}
+			if c[0] == "unindent" {
+				levels = -levels
+			}
+			a.shiftSelected(levels)
+		case "generate":
+			a.runCommand("generate", "go generate ./...")
+		case "make":
+			a.runCommand(strings.Join(c, " "), strings.Join(c, " "))
+		case "task":
+			a.runTask(strings.Join(c[1:], " "))
+		case "nexterror":
+			a.jumpQuickfix(1)
+		case "preverror":
+			a.jumpQuickfix(-1)
+		case "debug":
+			a.debugCommand(strings.Join(c[1:], " "))
+		case "lsp":
+			a.lspCommand(strings.Join(c[1:], " "))
+		case "hover":
+			a.hover()
+		case "definition":
+			a.definition()
+		case "references":
+			a.references()
+		case "breakpoint":
+			a.s.focus = focusEditor
+			a.toggleBreakpoint(a.s.row)
+		case "selectline":
+			a.s.focus = focusEditor
+			a.selectLine()
+		case "changes":
+			a.showChanges()
+		case "reselect":
+			a.reselect()
+		case "expand":
+			a.expandSelection()
+		case "shrink":
+			a.shrinkSelection()
+		case "undosave":
+			a.s.focus = focusEditor
+			if !a.editable() {
+				return
+			}
+			if err := a.s.undoSave(); err != nil {
+				a.showError(err.Error())
+				return
+			}
+			a.jump(min(a.s.row, a.s.lines.Len()-1), 0)
+			a.drawTabs()
+			a.drawEditor()
+			a.showMessage("Reverted to the last save")
+		case "nextparagraph":
+			a.s.focus = focusEditor
+			a.jumpParagraph(1)
+		case "prevparagraph":
+			a.s.focus = focusEditor
+			a.jumpParagraph(-1)
+		case "nextdecl":
+			a.s.focus = focusEditor
+			a.jumpDecl(1)
+		case "prevdecl":
+			a.s.focus = focusEditor
+			a.jumpDecl(-1)
+		case "declup":
+			a.moveDecl(-1)
+		case "decldown":
+			a.moveDecl(1)
+		case "paste":
+			a.s.focus = focusEditor
+			if a.s.clipboard != "" {
+				a.paste(a.s.clipboard)
+			}
+		case "pasteindent":
+			a.s.focus = focusEditor
+			a.pasteIndent()
+		case "scratch":
+			n := 1
+			for _, t := range a.s.tabs {
+				if t.scratch {
+					n++
+				}
+			}
+			a.s.tabs = append(a.s.tabs, &Tab{title: fmt.Sprintf("scratch %d", n), scratch: true, lines: newLineBuffer()})
+			a.s.switchTab(len(a.s.tabs) - 1)
+			a.s.focus = focusEditor
+			a.draw()
+		case "copyappend":
+			a.s.focus = focusEditor
+			a.copyAppend()
+		case "settings":
+			a.openSettings()
+		case "theme":
+			a.setTheme(strings.Join(c[1:], ""))
+		case "new":
+			a.newTab()
+		case "menu":
+			a.openMenu()
+		case "easymotion":
+			a.startHints()
+		case "mark":
+			name := []rune(strings.Join(c[1:], ""))
+			if len(name) != 1 {
+				a.showError("Usage: >mark <a-z|A-Z>")
+				return
+			}
+			a.setMark(name[0])
+		case "marks":
+			a.openBuffer("marks", a.s.listMarks())
+		case "date":
+			a.s.focus = focusEditor
+			a.paste(formatTime(time.Now(), strings.Join(c[1:], " ")))
+		case "uuid":
+			a.s.focus = focusEditor
+			a.paste(newUUID())
+		case "mouse":
+			if len(c) == 1 || (c[1] != "on" && c[1] != "off") {
+				a.showError("usage: >mouse on|off")
+				return
+			}
+			a.s.mouse = c[1] == "on"
+			if a.s.mouse {
+				screen.EnableMouse()
+			} else {
+				screen.DisableMouse()
+			}
+			a.s.focus = focusEditor
+		case "scrollbind":
+			a.s.scrollBind = !a.s.scrollBind
+			a.s.focus = focusEditor
+			if a.s.scrollBind {
+				a.showMessage("Scroll bound")
+			} else {
+				a.showMessage("Scroll unbound")
+			}
+		case "help":
+			a.openBuffer("help", helpText())
+		case "messages":
+			var b strings.Builder
+			for _, m := range a.s.messages {
+				b.WriteString(m.time.Format(time.TimeOnly))
+				if m.error {
+					b.WriteString(" error: ")
+				} else {
+					b.WriteString(" ")
+				}
+				b.WriteString(m.text)
+				b.WriteString("\n")
+			}
+			a.openBuffer("messages", b.String())
+		case "calls":
+			a.showCalls(len(c) > 1 && c[1] == "out")
+		case "inlayhints":
+			a.s.inlayHints = !a.s.inlayHints
+			a.drawEditor()
+		case "smartcase":
+			a.s.smartCase = !a.s.smartCase
+			if a.s.smartCase {
+				a.showMessage("Smart case on")
+			} else {
+				a.showMessage("Smart case off")
+			}
 		case "back":
 			a.s.focus = focusEditor
 			a.goBack()
@@ -1289,25 +2287,42 @@ func (a *App) handleCommand(cmd string) {
 			a.s.focus = focusEditor
 			a.goForward()
 		default:
-			a.status.draw([]rune("unknown command: " + cmd))
+			a.showError("unknown command: " + cmd)
 		}
-	case ':': // go to line
-		a.s.focus = focusEditor
+	case '=': // evaluate expression
 		defer a.syncCursor()
-		n, err := strconv.Atoi(cmd[1:])
+		a.setConsole(cmd) // keep the expression for editing
+		v, err := evalExpr(cmd[1:])
 		if err != nil {
-			a.status.draw([]rune("Invalid line number"))
+			a.showError(err.Error())
 			return
 		}
-		if n > a.s.lines.Len() {
-			a.status.draw([]rune("Line number out of range"))
+		result := formatValue(v)
+		if v.Kind() == constant.Int && constant.Sign(v) >= 0 {
+			result += fmt.Sprintf(" (%#x)", constant.Val(v))
+		}
+		a.showMessage(cmd[1:] + " = " + result)
+	case '\'': // go to mark
+		name := []rune(cmd[1:])
+		if len(name) != 1 {
+			a.showError("Invalid mark")
+			return
+		}
+		a.jumpMark(name[0])
+	case ':': // go to line
+		a.s.focus = focusEditor
+		defer a.syncCursor()
+		row, col, ok := a.s.parseGoto(cmd[1:])
+		if !ok {
+			a.rangeCommand(cmd[1:])
 			return
 		}
-		if n < 0 {
-			// input -1, scroll to bottom line
-			n = a.s.lines.Len()
+		a.recordPositon(a.s.row, a.s.col)
+		if col < 0 {
+			a.jumpVertically(row)
+		} else {
+			a.jump(row, col)
 		}
-		a.jump(n-1, 0)
 	case '@': // go to symbol
 		name := cmd[1:]
 		var receiver string
@@ -1321,16 +2336,27 @@ func (a *App) handleCommand(cmd string) {
 				matched = symbol
 			}
 		}
-		a.recordPositon(a.s.row, a.s.col)
-		a.jump(matched.Line-1, matched.Column-1)
 		a.s.focus = focusEditor
 		a.s.command = nil
+		a.goToSymbol(matched)
 		a.draw()
 	case '#': // find
 		keyword := []rune(cmd[1:])
 		if len(keyword) == 0 {
 			return
 		}
+		if pattern, template, ok := parseSubstitute(string(keyword)); ok {
+			a.substituteAll(pattern, template)
+			return
+		}
+		a.s.lastSearch = keyword
+		s, err := newSearcher(keyword, a.s.smartCase)
+		if err != nil {
+			// likely incomplete while typing
+			a.setConsole(cmd)
+			a.syncCursor()
+			return
+		}
 		row := a.s.row
 		col := a.s.col
 		var reverse bool
@@ -1349,15 +2375,15 @@ func (a *App) handleCommand(cmd string) {
 				a.syncCursor()
 				return
 			}
-			line := string(e.Value.([]rune))
-			if i := strings.Index(strings.ToLower(line[col:]), strings.ToLower(string(keyword))); i >= 0 {
+			line := e.Value
+			if start, end := s.index(line, col); start >= 0 {
 				a.recordPositon(a.s.row, a.s.col)
-				a.jump(row, col+i+len(keyword))
+				a.jump(row, end)
 				a.s.selection = &Selection{
 					startRow: row,
 					endRow:   row,
-					startCol: col + i,
-					endCol:   col + i + len(keyword),
+					startCol: start,
+					endCol:   end,
 				}
 				a.setConsole(cmd) // incremental search
 				a.draw()
@@ -1369,7 +2395,48 @@ func (a *App) handleCommand(cmd string) {
 	}
 }
 
+// indexRunes returns the index of the first instance of sub in s, or -1 if sub is not present.
+func indexRunes(s, sub []rune, caseSensitive bool) int {
+	for i := 0; i+len(sub) <= len(s); i++ {
+		j := 0
+		for ; j < len(sub); j++ {
+			r1, r2 := s[i+j], sub[j]
+			if r1 != r2 && (caseSensitive || unicode.ToLower(r1) != unicode.ToLower(r2)) {
+				break
+			}
+		}
+		if j == len(sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// writeFile writes data to the named file in chunks, reporting the progress to the task.
+func writeFile(t *task, filename string, data []byte) error {
+	f, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	const chunkSize = 1 << 20
+	for len(data) > 0 {
+		n := min(len(data), chunkSize)
+		if _, err := f.Write(data[:n]); err != nil {
+			f.Close()
+			return err
+		}
+		data = data[n:]
+		t.add(int64(n))
+	}
+	return f.Close()
+}
+
 func (a *App) commandLoop() {
+	defer func() {
+		if err := recover(); err != nil {
+			a.crash(err)
+		}
+	}()
 	for {
 		select {
 		case cmd := <-a.cmdCh:
@@ -1381,8 +2448,123 @@ func (a *App) commandLoop() {
 	}
 }
 
+// cleanup releases what the editor holds outside the process before exiting.
+func (a *App) cleanup() {
+	for _, t := range a.s.tabs {
+		t.unlock()
+	}
+	if a.s.debug != nil {
+		a.s.debug.kill()
+	}
+	if a.s.benchProfiles != "" {
+		os.RemoveAll(a.s.benchProfiles)
+	}
+}
+
+// watchTerminate exits on SIGTERM or SIGHUP, like a closed SSH connection,
+// restoring the terminal and dumping the edited tabs as crash does.
+func (a *App) watchTerminate() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		sig := <-c
+		postFunc(func() {
+			screen.Fini()
+			for _, path := range dumpTabs(a.s.tabs) {
+				fmt.Fprintln(os.Stderr, "Recovered unsaved changes to", path)
+			}
+			a.cleanup()
+			code := 1
+			if s, ok := sig.(syscall.Signal); ok {
+				code = 128 + int(s)
+			}
+			os.Exit(code)
+		})
+	}()
+}
+
+// crash dumps the edited tabs before re-panicking with the recovered err,
+// so that a crash never destroys unsaved work.
+func (a *App) crash(err any) {
+	if screen != nil {
+		screen.Fini()
+	}
+	for _, path := range dumpTabs(a.s.tabs) {
+		fmt.Fprintln(os.Stderr, "Recovered unsaved changes to", path)
+	}
+	panic(err)
+}
+
+// dumpTabs writes edited tabs to <file>.recovered-<timestamp>, untitled-<n> for the
+// untitled tab n, and returns the paths written.
+func dumpTabs(tabs []*Tab) []string {
+	var paths []string
+	suffix := ".recovered-" + time.Now().Format("20060102150405")
+	for i, t := range tabs {
+		if !t.dirty() || t.scratch {
+			continue
+		}
+		path := t.filename
+		if path == "" {
+			path = fmt.Sprintf("untitled-%d", i+1)
+		}
+		path += suffix
+		if err := dumpTab(t, path); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to recover %s: %v\n", t.name(), err)
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func dumpTab(t *Tab, path string) (err error) {
+	defer func() {
+		// the buffer may be corrupted by the panic
+		if e := recover(); e != nil {
+			err = fmt.Errorf("%v", e)
+		}
+	}()
+	var b bytes.Buffer
+	for e := t.lines.Front(); e != nil; e = e.Next() {
+		b.WriteString(string(e.Value))
+		if e.Next() != nil {
+			b.WriteByte('\n')
+		}
+	}
+	return os.WriteFile(path, b.Bytes(), 0600)
+}
+
+type message struct {
+	time  time.Time
+	text  string
+	error bool
+}
+
+// messageDuration is the minimum time a message stays in the status bar,
+// error messages stay until dismissed.
+const messageDuration = 2 * time.Second
+
+// showMessage shows the message in the status bar and keeps it in the history.
+func (a *App) showMessage(text string) {
+	a.s.messages = append(a.s.messages, message{time: time.Now(), text: text})
+	a.s.message = &a.s.messages[len(a.s.messages)-1]
+	a.status.draw([]rune(text))
+}
+
+// showError shows the error message in the status bar until dismissed by Escape.
+func (a *App) showError(text string) {
+	a.s.messages = append(a.s.messages, message{time: time.Now(), text: text, error: true})
+	a.s.message = &a.s.messages[len(a.s.messages)-1]
+	a.status.drawTexts([]textStyle{{text: []rune(text), style: styleError}})
+}
+
 // syncCursor sync cursor position and show it.
 func (a *App) syncCursor() {
+	if a.tooSmall {
+		screen.HideCursor()
+		return
+	}
 	switch a.s.focus {
 	case focusEditor:
 		if a.s.row < a.s.top || a.s.row > (a.s.top+len(a.editor)-1) {
@@ -1397,7 +2579,7 @@ func (a *App) syncCursor() {
 			return
 		}
 
-		line := lineElement.Value.([]rune)
+		line := lineElement.Value
 		screenCol := columnToScreenWidth(line, a.s.col) - a.s.left
 		x := a.editor[0].x + a.s.lineNumLen() + screenCol
 		y := a.editor[0].y + a.s.row - a.s.top
@@ -1406,23 +2588,62 @@ func (a *App) syncCursor() {
 			return
 		}
 		screen.ShowCursor(x, y)
-		a.status.draw([]rune(fmt.Sprintf("Line %d, Column %d ", a.s.row+1, screenCol+1)))
+		if m := a.s.message; m != nil {
+			if m.error || time.Since(m.time) < messageDuration {
+				return
+			}
+			a.s.message = nil
+		}
+		status := fmt.Sprintf("Line %d, Column %d ", a.s.row+1, screenCol+1)
+		if a.s.overwrite {
+			status += "OVR "
+		}
+		if a.s.vim {
+			status += "-- " + vimModeNames[a.s.vimMode] + " -- "
+		}
+		if stats, ok := a.s.selectionStats(); ok {
+			status += "(" + stats.String() + ") "
+		}
+		if msg, ok := a.s.lineErrors[a.s.row]; ok {
+			a.status.drawTexts([]textStyle{{text: []rune(status + " ")}, {text: []rune(msg), style: styleError}})
+			return
+		}
+		if sig := a.s.signatureHelp(); sig != nil {
+			a.status.drawTexts(append([]textStyle{{text: []rune(status + " ")}}, sig...))
+			return
+		}
+		a.status.draw([]rune(status))
 	case focusConsole:
 		// Calculate visual width of console text up to cursor
 		consoleRunes := []rune(a.s.command)
 		if a.s.commandCursor > len(consoleRunes) {
 			a.s.commandCursor = len(consoleRunes)
 		}
-		consoleWidth := 0
-		for i := 0; i < a.s.commandCursor && i < len(consoleRunes); i++ {
-			consoleWidth += runewidth.RuneWidth(consoleRunes[i])
-		}
+		consoleWidth := runewidth.StringWidth(string(consoleRunes[:a.s.commandCursor]))
 		screen.ShowCursor(a.console.x+consoleWidth, a.console.y)
 	default:
 		screen.HideCursor()
 	}
 }
 
+// toggleOverwrite switches between insert and overwrite mode,
+// the cursor is a block in overwrite mode.
+func (a *App) toggleOverwrite() {
+	a.s.overwrite = !a.s.overwrite
+	a.updateCursorStyle()
+	a.syncCursor()
+}
+
+// updateCursorStyle shows a block cursor in overwrite mode and vim normal mode,
+// otherwise a bar.
+func (a *App) updateCursorStyle() {
+	if a.s.overwrite || (a.s.vim && a.s.vimMode != vimInsert) {
+		screen.SetCursorStyle(tcell.CursorStyleBlinkingBlock, cursorColor)
+	} else {
+		screen.SetCursorStyle(tcell.CursorStyleBlinkingBar, cursorColor)
+	}
+}
+
 func leadingWhitespaces(line []rune) int {
 	for i, r := range line {
 		if r != ' ' && r != '\t' {
@@ -1432,16 +2653,46 @@ func leadingWhitespaces(line []rune) int {
 	return len(line)
 }
 
-var timeLastKey time.Time
+// jumpVertically goes to the row at the screen column the cursor had before moving
+// vertically, kept across up/down, page up/down and going to a line.
+func (a *App) jumpVertically(row int) {
+	if a.s.upDownCol < 0 {
+		a.s.upDownCol = columnToScreenWidth(a.s.line(a.s.row).Value, a.s.col)
+	}
+	a.jump(row, a.s.columnAt(a.s.line(row).Value, a.s.upDownCol))
+}
 
 func (a *App) editorEvent(ev *tcell.EventKey) {
 	defer func() {
 		a.syncCursor()
-		if ev.Key() != tcell.KeyUp && ev.Key() != tcell.KeyDown {
+		switch ev.Key() {
+		case tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn:
+		default:
 			a.s.upDownCol = -1
 		}
-		timeLastKey = time.Now()
 	}()
+	if isEditKey(ev) && !a.editable() {
+		return
+	}
+	if len(a.s.cursors) > 0 && a.editCursors(ev) {
+		return
+	}
+	if e := a.s.line(a.s.row); e != nil && a.s.col > len(e.Value) {
+		// the cursor is in virtual space
+		switch ev.Key() {
+		case tcell.KeyLeft, tcell.KeyRight, tcell.KeyUp, tcell.KeyDown, tcell.KeyPgUp, tcell.KeyPgDn,
+			tcell.KeyHome, tcell.KeyEnd, tcell.KeyCtrlA, tcell.KeyCtrlE, tcell.KeyEscape:
+		case tcell.KeyBackspace, tcell.KeyBackspace2:
+			if a.s.selected() == nil {
+				a.jump(a.s.row, a.s.col-1)
+				return
+			}
+		case tcell.KeyRune, tcell.KeyEnter, tcell.KeyTAB, tcell.KeyCtrlV:
+			a.s.fillVirtualSpace()
+		default:
+			a.s.col = len(e.Value)
+		}
+	}
 	switch ev.Key() {
 	case tcell.KeyCtrlU:
 		// delete to line start
@@ -1449,7 +2700,7 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 		if e == nil {
 			return
 		}
-		line := e.Value.([]rune)
+		line := e.Value
 		if len(line) == 0 {
 			return
 		}
@@ -1463,9 +2714,14 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 		a.s.redo()
 		a.drawEditor()
 	case tcell.KeyRune:
+		if ev.Modifiers()&tcell.ModAlt != 0 && ev.Rune() == '.' {
+			a.repeatEdit()
+			return
+		}
 		defer func() {
 			a.s.setHint()
-			a.drawEditorLine(a.s.row, a.s.line(a.s.row).Value.([]rune))
+			a.drawEditorLine(a.s.row, a.s.line(a.s.row).Value)
+			a.requestCompletion()
 		}()
 		var line []rune
 		e := a.s.line(a.s.row)
@@ -1488,7 +2744,7 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 			a.s.selection = nil
 
 			// Insert the new rune
-			line = a.s.line(a.s.row).Value.([]rune)
+			line = a.s.line(a.s.row).Value
 			newText := string(ev.Rune())
 			line = slices.Insert(line, a.s.col, ev.Rune())
 			a.s.line(a.s.row).Value = line
@@ -1509,13 +2765,39 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 			return
 		}
 
+		line = e.Value
+		if a.s.overwrite && a.s.col < len(line) {
+			// replace the character under the cursor
+			next := nextGrapheme(line, a.s.col)
+			oldText := string(line[a.s.col:next])
+			e.Value = slices.Replace(line, a.s.col, next, ev.Rune())
+			a.s.recordChange(Change{
+				row:     a.s.row,
+				col:     a.s.col,
+				oldText: oldText,
+				newText: string(ev.Rune()),
+				kind:    editReplace,
+			})
+			a.jump(a.s.row, a.s.col+1)
+			return
+		}
+
+		if a.s.autoPair && typesOver(line, a.s.col, ev.Rune()) {
+			a.s.lastChange = nil
+			a.jump(a.s.row, a.s.col+1)
+			return
+		}
+
 		// No selection, insert rune normally
-		line = e.Value.([]rune)
-		e.Value = slices.Insert(line, a.s.col, ev.Rune())
+		text := []rune{ev.Rune()}
+		if closer := closerOf(line, a.s.col, ev.Rune()); a.s.autoPair && closer != 0 {
+			text = append(text, closer)
+		}
+		e.Value = slices.Insert(line, a.s.col, text...)
 		a.s.recordChange(Change{
 			row:     a.s.row,
 			col:     a.s.col,
-			newText: string(ev.Rune()),
+			newText: string(text),
 			kind:    editInsert,
 		})
 		a.jump(a.s.row, a.s.col+1)
@@ -1535,11 +2817,10 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 			return
 		}
 
-		// break the line
-		line := e.Value.([]rune)
-		e.Value = line[:a.s.col]
-		// no auto-indent for the Enter from clipboard
-		if a.s.col == 0 || time.Since(timeLastKey) < 10*time.Millisecond {
+		// break the line, capping the first part like insertText
+		line := e.Value
+		e.Value = line[:a.s.col:a.s.col]
+		if a.s.col == 0 {
 			a.s.lines.InsertAfter(line[a.s.col:], e)
 			a.s.recordChange(Change{newText: "\n", row: a.s.row, col: a.s.col, kind: editInsert})
 			a.jump(a.s.row+1, a.s.col)
@@ -1550,13 +2831,23 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 		// auto-indent
 		var inserted string
 		n := leadingWhitespaces(line[:a.s.col])
-		indent := make([]rune, 0, n)
-		for range n {
-			indent = append(indent, '\t')
+		indent := slices.Clone(line[:n])
+		if ev.Modifiers()&tcell.ModAlt == 0 {
+			// continue the comment or list, alt-enter to break the line only
+			leader, blank := fileTypeOf(a.s.filename).continuation(line, a.s.col)
+			if blank && a.s.col == len(line) {
+				// enter on an empty item ends the list instead
+				e.Value = line[:n]
+				a.s.recordChange(Change{oldText: string(line[n:]), row: a.s.row, col: n, kind: editDelete})
+				a.jump(a.s.row, n)
+				a.drawEditor()
+				return
+			}
+			indent = append(indent, []rune(leader)...)
 		}
 		if line[a.s.col-1] == '{' && a.s.col < len(line) && line[a.s.col] == '}' {
 			// Enter inside {}
-			indent = append(indent, '\t')
+			indent = append(indent, []rune(a.s.indent)...)
 			nextE := a.s.lines.InsertAfter(indent, e)
 			a.s.lines.InsertAfter(slices.Concat(indent[:n], line[a.s.col:]), nextE)
 			inserted = "\n" + string(indent) + "\n" + string(indent[:n])
@@ -1573,7 +2864,7 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 	case tcell.KeyBackspace, tcell.KeyBackspace2:
 		defer func() {
 			a.s.setHint()
-			a.drawEditorLine(a.s.row, a.s.line(a.s.row).Value.([]rune))
+			a.drawEditorLine(a.s.row, a.s.line(a.s.row).Value)
 		}()
 		// delete selection
 		if sel := a.s.selected(); sel != nil {
@@ -1588,7 +2879,7 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 			if sel.startRow != sel.endRow {
 				a.drawEditor() // Refresh full editor for multi-line changes
 			} else if line := a.s.line(a.s.row); line != nil {
-				a.drawEditorLine(a.s.row, line.Value.([]rune))
+				a.drawEditorLine(a.s.row, line.Value)
 			}
 			return
 		}
@@ -1601,8 +2892,8 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 
 			element := a.s.line(a.s.row)
 			prevElement := element.Prev()
-			prevLine := prevElement.Value.([]rune)
-			prevElement.Value = append(prevLine, element.Value.([]rune)...)
+			prevLine := prevElement.Value
+			prevElement.Value = append(prevLine, element.Value...)
 			a.s.lines.Remove(element)
 			a.s.recordChange(Change{
 				row:     a.s.row - 1,
@@ -1616,17 +2907,24 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 		}
 
 		element := a.s.line(a.s.row)
-		line := element.Value.([]rune)
-		deleted := line[a.s.col-1]
-		line = append(line[:a.s.col-1], line[a.s.col:]...)
+		line := element.Value
+		// delete the whole grapheme cluster, e.g. an emoji ZWJ sequence,
+		// or an indent unit of spaces
+		start := a.s.backspaceStart(line, a.s.col)
+		end := a.s.col
+		if a.s.autoPair && inPair(line, a.s.col) {
+			end++ // delete the closing character along
+		}
+		deleted := string(line[start:end])
+		line = append(line[:start], line[end:]...)
 		element.Value = line
 		a.s.recordChange(Change{
 			row:     a.s.row,
-			col:     a.s.col - 1,
-			oldText: string(deleted),
+			col:     start,
+			oldText: deleted,
 			kind:    editDelete,
 		})
-		a.s.col--
+		a.s.col = start
 		a.jump(a.s.row, a.s.col)
 		// a.drawEditorLine(a.s.row, line)
 	case tcell.KeyLeft:
@@ -1648,7 +2946,11 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 			a.jump(a.s.row-1, -1)
 			return
 		}
-		a.jump(a.s.row, a.s.col-1)
+		if line := a.s.line(a.s.row).Value; a.s.col <= len(line) {
+			a.jump(a.s.row, prevGrapheme(line, a.s.col))
+		} else {
+			a.jump(a.s.row, a.s.col-1)
+		}
 	case tcell.KeyRight:
 		a.s.lastChange = nil
 		// move cursor to the end of the selection
@@ -1663,9 +2965,13 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 		if lineItem == nil {
 			return
 		}
-		line := lineItem.Value.([]rune)
+		line := lineItem.Value
 		// middle of the line
 		if a.s.col < len(line) {
+			a.jump(a.s.row, nextGrapheme(line, a.s.col))
+			return
+		}
+		if a.s.virtualSpace {
 			a.jump(a.s.row, a.s.col+1)
 			return
 		}
@@ -1676,22 +2982,23 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 		a.jump(a.s.row+1, 0)
 	case tcell.KeyUp:
 		a.s.lastChange = nil
+		if a.s.selectingLines() && ev.Modifiers()&tcell.ModShift != 0 {
+			a.extendLines(-1)
+			return
+		}
 		a.unselect()
 
 		if a.s.row == 0 {
 			return // already at the top
 		}
 
-		lineE := a.s.line(a.s.row)
-		prevLineE := lineE.Prev()
-		if a.s.upDownCol < 0 {
-			a.s.upDownCol = columnToScreenWidth(lineE.Value.([]rune), a.s.col)
-		}
-		// moving up/down, keep previous column
-		col := columnFromScreenWidth(prevLineE.Value.([]rune), a.s.upDownCol)
-		a.jump(a.s.row-1, col)
+		a.jumpVertically(a.s.row - 1)
 	case tcell.KeyDown:
 		a.s.lastChange = nil
+		if a.s.selectingLines() && ev.Modifiers()&tcell.ModShift != 0 {
+			a.extendLines(1)
+			return
+		}
 		a.unselect()
 
 		if a.s.row == a.s.lines.Len()-1 {
@@ -1703,14 +3010,7 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 			return
 		}
 
-		lineE := a.s.line(a.s.row)
-		nextE := lineE.Next()
-		if a.s.upDownCol < 0 {
-			a.s.upDownCol = columnToScreenWidth(lineE.Value.([]rune), a.s.col)
-		}
-		// moving up/down, keep previous column
-		col := columnFromScreenWidth(nextE.Value.([]rune), a.s.upDownCol)
-		a.jump(a.s.row+1, col)
+		a.jumpVertically(a.s.row + 1)
 	case tcell.KeyHome, tcell.KeyCtrlA:
 		a.s.lastChange = nil
 		a.unselect()
@@ -1720,47 +3020,25 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 		if line == nil {
 			return
 		}
-		a.jump(a.s.row, leadingWhitespaces(line.Value.([]rune)))
+		a.jump(a.s.row, leadingWhitespaces(line.Value))
 	case tcell.KeyEnd, tcell.KeyCtrlE:
 		a.s.lastChange = nil
 		a.unselect()
 		a.jump(a.s.row, -1)
 	case tcell.KeyTAB:
 		// increase indent for selection
-		if sel := a.s.selected(); sel != nil {
-			a.s.selection = &Selection{
-				startRow: sel.startRow,
-				startCol: sel.startCol + 1,
-				endRow:   sel.endRow,
-				endCol:   sel.endCol + 1,
-			}
-			e := a.s.line(sel.startRow)
-			for row := sel.startRow; row <= sel.endRow; row++ {
-				if e == nil {
-					break
-				}
-				line := e.Value.([]rune)
-				newLine := make([]rune, 0, len(line)+1)
-				newLine = append(newLine, '\t')
-				newLine = append(newLine, line...)
-				e.Value = newLine
-				a.drawEditorLine(row, newLine)
-				a.s.recordChange(Change{row: row, col: 0, newText: "\t", kind: editInsert})
-				if row == a.s.row {
-					a.s.col++
-				}
-				e = e.Next()
-			}
+		if a.s.selected() != nil {
+			a.shiftSelected(1)
 			return
 		}
 
 		e := a.s.line(a.s.row)
 		if e == nil {
-			e = a.s.lines.PushBack([]rune{'\t'})
-			a.s.recordChange(Change{row: a.s.row, col: a.s.col, newText: string("\t"), kind: editInsert})
-			a.s.col++
+			e = a.s.lines.PushBack([]rune(a.s.indent))
+			a.s.recordChange(Change{row: a.s.row, col: a.s.col, newText: a.s.indent, kind: editInsert})
+			a.s.col += len(a.s.indent)
 		} else {
-			line := e.Value.([]rune)
+			line := e.Value
 			if a.s.hint != "" {
 				line = slices.Concat(line[:a.s.col-a.s.hintOff], []rune(a.s.hint), line[a.s.col:])
 				a.s.recordChange(Change{
@@ -1773,108 +3051,29 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 				a.s.col += len([]rune(a.s.hint)) - a.s.hintOff
 				a.s.hint = ""
 			} else {
-				line = slices.Insert(line, a.s.col, '\t')
-				a.s.recordChange(Change{row: a.s.row, col: a.s.col, newText: string("\t"), kind: editInsert})
-				a.s.col++
+				line = slices.Insert(line, a.s.col, []rune(a.s.indent)...)
+				a.s.recordChange(Change{row: a.s.row, col: a.s.col, newText: a.s.indent, kind: editInsert})
+				a.s.col += len(a.s.indent)
 			}
 			e.Value = line
 		}
-		a.drawEditorLine(a.s.row, e.Value.([]rune))
+		a.drawEditorLine(a.s.row, e.Value)
 	case tcell.KeyBacktab:
 		// decrease indent
-		unindent := func(row int, e *list.Element) {
-			if e == nil {
-				return
-			}
-			line := e.Value.([]rune)
-			if len(line) == 0 || line[0] != '\t' {
-				return
-			}
-			e.Value = line[1:]
-			a.drawEditorLine(row, line[1:])
-			if row == a.s.row {
-				a.s.col--
-			}
-			a.s.recordChange(Change{
-				row:     row,
-				col:     0,
-				oldText: "\t",
-				kind:    editDelete,
-			})
-		}
-		if sel := a.s.selected(); sel != nil {
-			a.s.selection = &Selection{
-				startRow: sel.startRow,
-				startCol: sel.startCol - 1,
-				endRow:   sel.endRow,
-				endCol:   sel.endCol - 1,
-			}
-			e := a.s.line(sel.startRow)
-			for row := sel.startRow; row <= sel.endRow; row++ {
-				unindent(row, e)
-				e = e.Next()
-			}
-			return
-		}
-
-		e := a.s.line(a.s.row)
-		unindent(a.s.row, e)
+		a.shiftSelected(-1)
 	case tcell.KeyPgUp:
 		a.unselect()
 		// go to previous page or the top of the page
-		a.s.row -= len(a.editor) - 2
-		if a.s.row < 0 {
-			a.s.row = 0
-		}
-		a.jump(a.s.row, a.s.col)
+		a.jumpVertically(max(a.s.row-max(len(a.editor)-2, 1), 0))
 	case tcell.KeyPgDn:
 		a.unselect()
 		// go to next page or the bottom of the page
-		a.s.row += len(a.editor) - 2
-		if a.s.row >= a.s.lines.Len() {
-			a.s.row = a.s.lines.Len() - 1
-		}
-		a.jump(a.s.row, a.s.col)
+		a.jumpVertically(min(a.s.row+max(len(a.editor)-2, 1), a.s.lines.Len()-1))
 	case tcell.KeyCtrlC:
-		if sel := a.s.selected(); sel != nil {
-			e := a.s.line(sel.startRow)
-			var copied []rune
-			if sel.startRow == sel.endRow {
-				// Single line selection
-				line := e.Value.([]rune)
-				copied = append(copied, line[sel.startCol:sel.endCol]...)
-			} else {
-				for i := sel.startRow; i <= sel.endRow && e != nil; i++ {
-					text := e.Value.([]rune)
-					switch i {
-					case sel.startRow:
-						copied = append(copied, text[sel.startCol:]...)
-						copied = append(copied, '\n')
-					case sel.endRow:
-						copied = append(copied, text[:sel.endCol]...)
-					default:
-						copied = append(copied, text...)
-						copied = append(copied, '\n')
-					}
-					e = e.Next()
-				}
-			}
-			a.s.clipboard = string(copied)
-			screen.SetClipboard([]byte(string(copied)))
-			return
-		}
-
-		// Copy the current e to clipboard
-		e := a.s.line(a.s.row)
-		if e == nil {
-			return
-		}
-		line := e.Value.([]rune)
-		if len(line) == 0 {
-			return
+		if copied := a.s.copiedText(); copied != "" {
+			a.s.clipboard = copied
+			screen.SetClipboard([]byte(copied))
 		}
-		a.s.clipboard = string(line)
-		screen.SetClipboard([]byte(string(line)))
 	case tcell.KeyCtrlX:
 		if sel := a.s.selected(); sel != nil {
 			// Cut the selected text
@@ -1891,7 +3090,7 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 			if sel.startRow != sel.endRow {
 				a.drawEditor() // Refresh full editor for multi-line changes
 			} else if line := a.s.line(a.s.row); line != nil {
-				a.drawEditorLine(a.s.row, line.Value.([]rune))
+				a.drawEditorLine(a.s.row, line.Value)
 			}
 			return
 		}
@@ -1901,7 +3100,7 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 		if e == nil {
 			return
 		}
-		line := e.Value.([]rune)
+		line := e.Value
 		if len(line) == 0 {
 			return
 		}
@@ -1919,60 +3118,35 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 		if a.s.clipboard == "" {
 			return
 		}
-		if sel := a.s.selected(); sel != nil {
-			deleted := a.s.deleteRange(sel.startRow, sel.startCol, sel.endRow, sel.endCol)
-			a.s.selection = nil
-			a.s.insertText([]rune(a.s.clipboard), sel.startRow, sel.startCol)
-			a.s.recordChange(Change{
-				row:     sel.startRow,
-				col:     sel.startCol,
-				oldText: deleted,
-				newText: a.s.clipboard,
-				kind:    editReplace,
-			})
-		} else {
-			row, col := a.s.row, a.s.col
-			a.s.insertText([]rune(a.s.clipboard), row, col)
-			a.s.recordChange(Change{
-				row:     row,
-				col:     col,
-				newText: a.s.clipboard,
-				kind:    editInsert,
-			})
-		}
-		a.drawEditor()
+		a.paste(a.s.clipboard)
 	case tcell.KeyCtrlUnderscore:
 		a.goBack()
+	case tcell.KeyInsert:
+		a.toggleOverwrite()
 	case tcell.KeyEscape:
 		a.s.selection = nil
 		a.s.hint = ""
+		a.s.message = nil
 		a.drawEditor()
 	case tcell.KeyCtrlB: // go to symbol under cursor
-		e := a.s.line(a.s.row)
-		if e == nil {
-			return
-		}
-		line := e.Value.([]rune)
-		start := a.s.col - 1
-		for start >= 0 && (unicode.IsLetter(line[start]) || unicode.IsDigit(line[start]) || line[start] == '_') {
-			start--
-		}
-		stop := a.s.col
-		for stop < len(line) && (unicode.IsLetter(line[stop]) || unicode.IsDigit(line[stop]) || line[stop] == '_') {
-			stop++
+		if a.s.lsp != nil && isGoTab(a.s.Tab) {
+			a.cmdCh <- ">definition"
+			return
 		}
-		word := string(line[start+1 : stop])
+		word := a.s.wordAtCursor()
 		if len(word) == 0 {
 			return
 		}
+		if isGoTab(a.s.Tab) && a.definitionElsewhere(word) {
+			return
+		}
 		symbols, ok := a.s.symbols[word]
 		if !ok {
 			return
 		}
 
 		if len(symbols) == 1 {
-			a.recordPositon(a.s.row, a.s.col)
-			a.jump(symbols[0].Line-1, symbols[0].Column-1)
+			a.goToSymbol(symbols[0])
 			return
 		}
 		// multiple symbols found, show options
@@ -1993,6 +3167,303 @@ func (a *App) editorEvent(ev *tcell.EventKey) {
 	}
 }
 
+// copiedText returns the selected text, or the current line if nothing selected.
+func (st *State) copiedText() string {
+	if sel := st.selected(); sel != nil {
+		return st.textIn(sel.startRow, sel.startCol, sel.endRow, sel.endCol)
+	}
+	if e := st.line(st.row); e != nil {
+		return string(e.Value)
+	}
+	return ""
+}
+
+// copyAppend appends the selected text or the current line to the clipboard,
+// separated by a newline.
+func (a *App) copyAppend() {
+	copied := a.s.copiedText()
+	if copied == "" {
+		return
+	}
+	if a.s.clipboard != "" && !strings.HasSuffix(a.s.clipboard, "\n") {
+		copied = "\n" + copied
+	}
+	if a.s.vimLinewise {
+		copied += "\n"
+	}
+	a.s.clipboard += copied
+	screen.SetClipboard([]byte(a.s.clipboard))
+	a.showMessage(fmt.Sprintf("Appended to clipboard, %d lines", strings.Count(strings.TrimSuffix(a.s.clipboard, "\n"), "\n")+1))
+}
+
+// reindent re-indents the lines of text after the first to the indent,
+// keeping their indentation relative to each other.
+func reindent(text, indent string) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		return text
+	}
+	// the common indent, the first line may be copied without its indent
+	var base *string
+	for i, line := range lines {
+		n := leadingWhitespaces([]rune(line))
+		if n == len([]rune(line)) || (i == 0 && n == 0) {
+			continue // blank
+		}
+		ws := string([]rune(line)[:n])
+		if base == nil {
+			base = &ws
+		} else {
+			*base = commonPrefix(*base, ws)
+		}
+	}
+	if base == nil {
+		return text
+	}
+	for i, line := range lines {
+		trimmed, ok := strings.CutPrefix(line, *base)
+		if !ok {
+			trimmed = strings.TrimLeft(line, " \t")
+		}
+		if i > 0 && strings.TrimSpace(trimmed) != "" {
+			trimmed = indent + trimmed
+		}
+		lines[i] = trimmed
+	}
+	return strings.Join(lines, "\n")
+}
+
+// commonPrefix returns the common prefix of a and b.
+func commonPrefix(a, b string) string {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return a[:i]
+}
+
+// pasteIndent pastes the clipboard, re-indenting the lines to the indentation at the cursor.
+func (a *App) pasteIndent() {
+	if a.s.clipboard == "" {
+		return
+	}
+	var indent []rune
+	if e := a.s.line(a.s.row); e != nil {
+		line := e.Value
+		indent = line[:min(a.s.col, leadingWhitespaces(line))]
+	}
+	a.paste(reindent(a.s.clipboard, string(indent)))
+}
+
+// wordAtCursor returns the word around the cursor, empty if none.
+func (st *State) wordAtCursor() string {
+	start, stop := st.wordRange()
+	if start == stop {
+		return ""
+	}
+	return string(st.line(st.row).Value[start:stop])
+}
+
+// isEditKey reports whether the key modifies the buffer in the editor.
+func isEditKey(ev *tcell.EventKey) bool {
+	switch ev.Key() {
+	case tcell.KeyRune:
+		return ev.Modifiers()&tcell.ModAlt == 0 || ev.Rune() == '.'
+	case tcell.KeyEnter, tcell.KeyTAB, tcell.KeyBacktab, tcell.KeyBackspace, tcell.KeyBackspace2,
+		tcell.KeyDelete, tcell.KeyCtrlU, tcell.KeyCtrlX, tcell.KeyCtrlV, tcell.KeyCtrlZ, tcell.KeyCtrlY:
+		return true
+	}
+	return false
+}
+
+// editable reports whether the current tab can be edited,
+// otherwise it tells the user the tab is read-only.
+func (a *App) editable() bool {
+	if a.s.readOnly {
+		a.showMessage("The tab is read-only")
+		return false
+	}
+	return true
+}
+
+// paste inserts the text at the cursor, replacing the selection if any,
+// and records it as a single change.
+func (a *App) paste(text string) {
+	if !a.editable() {
+		return
+	}
+	if len(a.s.cursors) > 0 {
+		a.pasteAtCursors(text)
+		return
+	}
+	a.s.fillVirtualSpace()
+	if sel := a.s.selected(); sel != nil {
+		deleted := a.s.deleteRange(sel.startRow, sel.startCol, sel.endRow, sel.endCol)
+		a.s.selection = nil
+		a.s.insertText([]rune(text), sel.startRow, sel.startCol)
+		a.s.recordChange(Change{
+			row:     sel.startRow,
+			col:     sel.startCol,
+			oldText: deleted,
+			newText: text,
+			kind:    editReplace,
+		})
+	} else {
+		row, col := a.s.row, a.s.col
+		a.s.insertText([]rune(text), row, col)
+		a.s.recordChange(Change{
+			row:     row,
+			col:     col,
+			newText: text,
+			kind:    editInsert,
+		})
+	}
+	a.jump(a.s.row, a.s.col)
+	a.drawEditor()
+}
+
+// columnAt converts screen width to column index in the line,
+// the column goes beyond the line end in virtual space mode.
+func (st *State) columnAt(line []rune, screenCol int) int {
+	col := columnFromScreenWidth(line, screenCol)
+	if st.virtualSpace && col == len(line) {
+		if w := columnToScreenWidth(line, col); screenCol > w {
+			col += screenCol - w
+		}
+	}
+	return col
+}
+
+// fillVirtualSpace pads the line with spaces up to the cursor in virtual space,
+// so that text can be inserted there.
+func (st *State) fillVirtualSpace() {
+	e := st.line(st.row)
+	if e == nil {
+		return
+	}
+	line := e.Value
+	if st.col <= len(line) {
+		return
+	}
+	padding := strings.Repeat(" ", st.col-len(line))
+	e.Value = append(line, []rune(padding)...)
+	st.recordChange(Change{row: st.row, col: len(line), newText: padding, kind: editInsert})
+}
+
+// hasCursor reports whether one of the multiple cursors is on the row.
+func (st *State) hasCursor(row int) bool {
+	for _, c := range st.cursors {
+		if c.startRow == row {
+			return true
+		}
+	}
+	return false
+}
+
+// selectMatches puts a cursor on every match of the keyword in the buffer,
+// so that typing replaces them all at once. It returns the number of matches.
+func (a *App) selectMatches(keyword []rune) int {
+	s, err := newSearcher(keyword, a.s.smartCase)
+	if err != nil {
+		return 0
+	}
+	var cursors []Selection
+	row := 0
+	for e := a.s.lines.Front(); e != nil; e = e.Next() {
+		line := e.Value
+		for col := 0; ; {
+			start, end := s.index(line, col)
+			if start < 0 {
+				break
+			}
+			cursors = append(cursors, Selection{startRow: row, startCol: start, endRow: row, endCol: end})
+			col = end
+		}
+		row++
+	}
+	if len(cursors) == 0 {
+		return 0
+	}
+	a.s.cursors = cursors
+	a.s.selection = nil
+	last := cursors[len(cursors)-1]
+	a.jump(last.endRow, last.endCol)
+	a.drawEditor()
+	a.syncCursor()
+	return len(cursors)
+}
+
+// editCursors applies the key event at every cursor.
+// It returns false if the key is not an edit, the multiple cursors are then dropped.
+func (a *App) editCursors(ev *tcell.EventKey) bool {
+	switch ev.Key() {
+	case tcell.KeyRune:
+		a.editAtCursors(func(int) string { return string(ev.Rune()) }, false)
+	case tcell.KeyBackspace, tcell.KeyBackspace2:
+		a.editAtCursors(nil, true)
+	case tcell.KeyCtrlV:
+		if a.s.clipboard != "" {
+			a.pasteAtCursors(a.s.clipboard)
+		}
+	case tcell.KeyEscape:
+		a.s.cursors = nil
+		a.drawEditor()
+	default:
+		a.s.cursors = nil
+		a.drawEditor()
+		return false
+	}
+	return true
+}
+
+// repeatEdit applies the last edit again at the cursor.
+// An insertion is inserted at the cursor, a deletion deletes the same amount of
+// text before the cursor, and a replacement replaces the text after the cursor.
+// If there is a selection, it is the text to be deleted or replaced.
+func (a *App) repeatEdit() {
+	if a.s.lastEdit == nil || a.s.line(a.s.row) == nil || !a.editable() {
+		return
+	}
+	edit := *a.s.lastEdit
+	a.s.lastChange = nil // do not coalesce with the previous edit
+	a.s.fillVirtualSpace()
+	if edit.kind == editInsert || (edit.kind == editReplace && a.s.selected() != nil) {
+		a.paste(edit.newText)
+		return
+	}
+
+	startRow, startCol := a.s.row, a.s.col
+	endRow, endCol := a.s.row, a.s.col
+	n := len([]rune(edit.oldText))
+	if sel := a.s.selected(); sel != nil {
+		startRow, startCol, endRow, endCol = sel.startRow, sel.startCol, sel.endRow, sel.endCol
+	} else if edit.kind == editDelete {
+		// count back across lines, a line break counts as one
+		for n > startCol {
+			if startRow == 0 {
+				return
+			}
+			n -= startCol + 1
+			startRow--
+			startCol = len(a.s.line(startRow).Value)
+		}
+		startCol -= n
+	} else {
+		endCol = min(startCol+n, len(a.s.line(startRow).Value))
+	}
+
+	a.s.selection = nil
+	deleted := a.s.deleteRange(startRow, startCol, endRow, endCol)
+	if edit.kind == editDelete {
+		a.s.recordChange(Change{row: startRow, col: startCol, oldText: deleted, kind: editDelete})
+	} else {
+		a.s.insertText([]rune(edit.newText), startRow, startCol)
+		a.s.recordChange(Change{row: startRow, col: startCol, oldText: deleted, newText: edit.newText, kind: editReplace})
+	}
+	a.jump(a.s.row, a.s.col)
+	a.drawEditor()
+}
+
 // recordPositon record the cursor position.
 // Do not make it into method App.jump, for not every jump worth going back
 func (a *App) recordPositon(row, col int) {
@@ -2031,11 +3502,12 @@ func (st *State) insertText(runes []rune, row, col int) {
 	if e == nil {
 		e = st.lines.PushBack([]rune{})
 	}
-	line := e.Value.([]rune)
+	line := e.Value
 	for _, r := range runes {
 		if r == '\n' {
-			// break the line
-			e.Value = line[:col]
+			// break the line, capping the first part so that inserting into it
+			// does not write over the new line sharing the array
+			e.Value = line[:col:col]
 			newLine := line[col:]
 			e = st.lines.InsertAfter(newLine, e)
 			row++
@@ -2053,6 +3525,7 @@ func (st *State) insertText(runes []rune, row, col int) {
 
 // unselect cancel the selection and redraws the affected lines.
 func (a *App) unselect() {
+	a.s.lineSelect = false
 	selection := a.s.selected()
 	if selection == nil {
 		return
@@ -2061,7 +3534,7 @@ func (a *App) unselect() {
 	a.s.selection = nil
 	line := a.s.line(selection.startRow)
 	for i := selection.startRow; i <= selection.endRow && line != nil; i++ {
-		a.drawEditorLine(i, line.Value.([]rune))
+		a.drawEditorLine(i, line.Value)
 		line = line.Next()
 	}
 }
@@ -2089,6 +3562,35 @@ func (st *State) selected() *Selection {
 	return &sel
 }
 
+// textIn returns the text in range [startRow:startCol, endRow:endCol).
+func (st *State) textIn(startRow, startCol, endRow, endCol int) string {
+	e := st.line(startRow)
+	if e == nil {
+		return ""
+	}
+	if startRow == endRow {
+		// Single line
+		line := e.Value
+		return string(line[startCol:endCol])
+	}
+	var text []rune
+	for i := startRow; i <= endRow && e != nil; i++ {
+		line := e.Value
+		switch i {
+		case startRow:
+			text = append(text, line[startCol:]...)
+			text = append(text, '\n')
+		case endRow:
+			text = append(text, line[:endCol]...)
+		default:
+			text = append(text, line...)
+			text = append(text, '\n')
+		}
+		e = e.Next()
+	}
+	return string(text)
+}
+
 // deleteRange deletes a range of text [startRow:startCol, endRow:endCol) from the editor
 // and move the cursor to the start of the deleted range.
 // It returns the deleted text as a string.
@@ -2097,7 +3599,7 @@ func (st *State) deleteRange(startRow, startCol, endRow, endCol int) string {
 	if startRow == endRow {
 		// single line
 		element := st.line(startRow)
-		line := element.Value.([]rune)
+		line := element.Value
 		deleted.WriteString(string(line[startCol:endCol]))
 		line = slices.Delete(line, startCol, endCol)
 		element.Value = line
@@ -2108,9 +3610,9 @@ func (st *State) deleteRange(startRow, startCol, endRow, endCol int) string {
 
 	// mutiple lines
 	element := st.line(startRow)
-	firstLineLeft := element.Value.([]rune)[:startCol]
+	firstLineLeft := element.Value[:startCol]
 	for i := startRow; i <= endRow && element != nil; i++ {
-		line := element.Value.([]rune)
+		line := element.Value
 		next := element.Next()
 		switch i {
 		case startRow:
@@ -2145,6 +3647,7 @@ type Change struct {
 	newText string
 	kind    int
 	time    time.Time
+	group   int // changes of the same non-zero group are undone and redone together
 }
 
 func reverse(c Change) Change {
@@ -2177,22 +3680,66 @@ func reverse(c Change) Change {
 }
 
 func (st *State) undo() {
-	if st.changeIndex < 0 {
+	if st.changeIndex < 0 || st.changeIndex >= len(st.changes) {
 		return
 	}
-	st.applyChange(reverse(st.changes[st.changeIndex]))
-	st.changeIndex--
+	st.lastChange = nil
+	group := st.changes[st.changeIndex].group
+	for {
+		st.applyChange(reverse(st.changes[st.changeIndex]))
+		st.changeIndex--
+		if group == 0 || st.changeIndex < 0 || st.changes[st.changeIndex].group != group {
+			return
+		}
+	}
+}
+
+// undoSave undoes or redoes the changes until the text is the last saved.
+func (st *State) undoSave() error {
+	if st.saved < 0 {
+		return errors.New("the saved state is no longer in the undo history")
+	}
+	st.undoTo(st.saved)
+	return nil
 }
 
 func (st *State) redo() {
 	if st.changeIndex >= len(st.changes)-1 {
 		return
 	}
-	st.changeIndex++
-	st.applyChange(st.changes[st.changeIndex])
+	st.lastChange = nil
+	group := st.changes[st.changeIndex+1].group
+	for {
+		st.changeIndex++
+		st.applyChange(st.changes[st.changeIndex])
+		if group == 0 || st.changeIndex >= len(st.changes)-1 || st.changes[st.changeIndex+1].group != group {
+			return
+		}
+	}
+}
+
+// beginGroup starts an undo group, the changes recorded until the matching endGroup
+// are undone and redone as one. Groups may nest, the outermost one takes effect.
+func (st *State) beginGroup() {
+	if st.groupDepth == 0 {
+		st.groupSeq++
+		st.lastChange = nil // do not coalesce with the change before the group
+	}
+	st.groupDepth++
+}
+
+// endGroup ends the undo group started by beginGroup.
+func (st *State) endGroup() {
+	st.groupDepth--
+	if st.groupDepth == 0 {
+		st.lastChange = nil // do not coalesce with the change after the group
+	}
 }
 
 func (st *State) applyChange(c Change) {
+	st.shiftMarks(c)
+	st.edits++
+	st.cursors = nil // left at the positions before the change
 	switch c.kind {
 	case editInsert:
 		st.insertText([]rune(c.newText), c.row, c.col)
@@ -2212,23 +3759,47 @@ func (st *State) applyChange(c Change) {
 		}
 		st.insertText([]rune(c.newText), c.row, c.col)
 	}
+	if st.share != nil {
+		st.share.local(changeOps(st.Tab, c))
+	}
+	if st.lsp != nil {
+		st.lsp.change(st.Tab, c)
+	}
 }
 
 // recordChange record change with intelligent coalescing.
 // It merges consecutive edits of the same type that occur within 1 second on the same row
 // to create more intuitive undo/redo behavior.
 func (st *State) recordChange(c Change) {
+	st.shiftMarks(c)
+	st.edits++
+	st.cursors = nil // left at the positions before the change
+	// the rows of the styles are no longer valid
+	st.ansi = nil
+	if st.share != nil {
+		st.share.local(changeOps(st.Tab, c))
+	}
+	if st.lsp != nil {
+		st.lsp.change(st.Tab, c)
+	}
+	defer func() {
+		edit := *st.lastChange
+		st.lastEdit = &edit
+	}()
+	if st.groupDepth > 0 {
+		c.group = st.groupSeq
+	}
 	now := time.Now()
-	if st.lastChange != nil && c.kind == st.lastChange.kind &&
+	if st.lastChange != nil && c.kind == st.lastChange.kind && c.group == st.lastChange.group &&
 		c.kind != editReplace && // Skip coalescing for replaces
 		c.row == st.lastChange.row && now.Sub(st.lastChange.time) < time.Second {
-		if c.kind == editInsert && st.lastChange.col+len(st.lastChange.newText) == c.col {
+		if c.kind == editInsert && st.lastChange.col+len([]rune(st.lastChange.newText)) == c.col {
 			st.lastChange.newText += c.newText
 			st.lastChange.time = now
 			return
 		}
 
-		if c.kind == editDelete && c.col == st.lastChange.col-len(c.oldText) {
+		if c.kind == editDelete && c.col == st.lastChange.col-len([]rune(c.oldText)) {
 			st.lastChange.oldText = c.oldText + st.lastChange.oldText
 			st.lastChange.col = c.col
 			st.lastChange.time = now
@@ -2237,6 +3808,10 @@ func (st *State) recordChange(c Change) {
 	}
 
 	c.time = now
+	if st.saved > st.applied() {
+		// the saved state is in the redo stack to be cleared
+		st.saved = -1
+	}
 	if st.changeIndex < len(st.changes) {
 		// clear redo stack on new change
 		st.changes = st.changes[:st.changeIndex+1]
@@ -2264,6 +3839,7 @@ type Symbol struct {
 	Line     int        // line number
 	Column   int        // optional, for precision
 	Receiver string     // for method: struct name, for field: struct name
+	Params   []string   // for func: parameters, e.g. "row int"
 }
 
 // ParseSymbol parses Go source code and extracts symbols such as functions,
@@ -2294,6 +3870,16 @@ func ParseSymbol(filename string, src any) (map[string][]Symbol, error) {
 					}
 				}
 			}
+			var params []string
+			for _, field := range node.Type.Params.List {
+				typ := types.ExprString(field.Type)
+				if len(field.Names) == 0 {
+					params = append(params, typ)
+				}
+				for _, name := range field.Names {
+					params = append(params, name.Name+" "+typ)
+				}
+			}
 			sym := Symbol{
 				Name:     node.Name.Name,
 				Kind:     SymbolFunc,
@@ -2301,6 +3887,7 @@ func ParseSymbol(filename string, src any) (map[string][]Symbol, error) {
 				Line:     pos.Line,
 				Column:   pos.Column,
 				Receiver: receiver,
+				Params:   params,
 			}
 			index[sym.Name] = append(index[sym.Name], sym)
 
@@ -2367,15 +3954,17 @@ var (
 	styleComment   = styleBase.Foreground(tcell.ColorGray)
 	styleNumber    = styleBase.Foreground(tcell.ColorBrown)
 	styleHighlight = styleBase.Background(tcell.ColorLightSteelBlue)
+	styleError     = styleComment.Foreground(tcell.ColorRed)
 
-	cursorColor = tcell.ColorBlack
+	colorSelection  = tcell.ColorLightSteelBlue
+	colorLineNumber = tcell.ColorLightGray
+	cursorColor     = tcell.ColorBlack
 )
 
 // highlight Go syntax
 func highlightGoLine(line []rune) []textStyle {
 	var parts []textStyle
 
-	var inString bool
 	var word strings.Builder
 	flushWord := func() {
 		if word.Len() > 0 {
@@ -2391,31 +3980,21 @@ func highlightGoLine(line []rune) []textStyle {
 		}
 	}
 
-	for i, c := range line {
+	for i := 0; i < len(line); i++ {
+		c := line[i]
 		// Line comment
-		if !inString && c == '/' && i+1 < len(line) && line[i+1] == '/' {
+		if c == '/' && i+1 < len(line) && line[i+1] == '/' {
 			flushWord()
 			parts = append(parts, textStyle{text: line[i:], style: styleComment})
 			return parts
 		}
 
-		// Strings
-		if c == '"' {
-			if inString {
-				word.WriteRune(c)
-				parts = append(parts, textStyle{text: []rune(word.String()), style: styleString})
-				word.Reset()
-				inString = false
-			} else {
-				flushWord()
-				word.WriteRune(c)
-				inString = true
-			}
-			continue
-		}
-
-		if inString {
-			word.WriteRune(c)
+		// Interpreted and raw strings, and rune literals, to the closing quote or the line end
+		if c == '"' || c == '`' || c == '\'' {
+			flushWord()
+			end := quotedEnd(line, i)
+			parts = append(parts, textStyle{text: line[i:end], style: styleString})
+			i = end - 1
 			continue
 		}
 
@@ -2432,10 +4011,30 @@ func highlightGoLine(line []rune) []textStyle {
 	return parts
 }
 
+// quotedEnd returns the index after the literal quoted from line[start] to the closing quote,
+// or the line end if unclosed. Backslash escapes the next rune except in raw strings.
+func quotedEnd(line []rune, start int) int {
+	quote := line[start]
+	for i := start + 1; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			return i + 1
+		}
+	}
+	return len(line)
+}
+
+// largeSourceSize is the size of source indexed in the background.
+const largeSourceSize = 1 << 20
+
 // loadSource reads lines from r and puts them to current tab's buffer.
 // If the file is a Go source file, it also parses and indexes its symbols.
 func (st *State) loadSource(r io.Reader) error {
-	var lines list.List
+	var lines lineBuffer
 	var buf bytes.Buffer
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
@@ -2444,7 +4043,7 @@ func (st *State) loadSource(r io.Reader) error {
 		buf.WriteByte('\n')
 	}
 	back := lines.Back()
-	if back == nil || len(back.Value.([]rune)) != 0 {
+	if back == nil || len(back.Value) != 0 {
 		// append newline
 		lines.PushBack([]rune{})
 	}
@@ -2453,40 +4052,76 @@ func (st *State) loadSource(r io.Reader) error {
 		return err
 	}
 	st.lines = &lines
+	st.edits++
+	st.ansi = nil
+	if st.ansiColors && (st.readOnly || isLogFile(st.filename)) {
+		st.interpretANSI(0)
+	}
 
 	if !strings.HasSuffix(st.filename, ".go") {
 		return nil
 	}
-	symbols, err := ParseSymbol(st.filename, buf.Bytes())
-	if err != nil {
-		log.Printf("parse symbol: %s", err.Error())
+	if st.lsp != nil {
+		st.lsp.open(st.Tab)
+	}
+	if buf.Len() < largeSourceSize {
+		symbols, err := ParseSymbol(st.filename, buf.Bytes())
+		if err != nil {
+			log.Printf("parse symbol: %s", err.Error())
+			return nil
+		}
+		st.symbols = symbols
 		return nil
 	}
-	st.symbols = symbols
+
+	// index large source in the background
+	tab := st.Tab
+	filename := tab.filename
+	t := st.tasks.start("indexing symbols", 0)
+	go func() {
+		defer t.finish()
+		symbols, err := ParseSymbol(filename, buf.Bytes())
+		if err != nil {
+			log.Printf("parse symbol: %s", err.Error())
+			return
+		}
+		if t.ctx.Err() != nil {
+			return
+		}
+		// the tab is read on the main goroutine
+		postFunc(func() { tab.symbols = symbols })
+	}()
 	return nil
 }
 
 func (st *State) setHint() {
-	if len(st.symbols) == 0 {
-		return
-	}
 	e := st.line(st.row)
 	if e == nil {
 		return
 	}
-	line := e.Value.([]rune)
+	line := e.Value
 	if st.col != len(line) {
 		// only show hint when cursor is at the end of the line
 		st.hint = ""
 		return
 	}
 
+	ft := fileTypeOf(st.filename)
 	i := st.col - 1
-	for i >= 0 && (unicode.IsLetter(line[i]) || unicode.IsDigit(line[i]) || line[i] == '_') {
+	for i >= 0 && ft.isWordChar(line[i]) {
 		i--
 	}
 	word := string(line[i+1 : st.col])
-	if len(word) < 2 {
+	if ft.complete != nil {
+		st.setHintFrom(ft.complete(line, i+1, st.col), word)
+		return
+	}
+	if members, ok := st.completions(i + 1); ok {
+		// the fields and methods of the type
+		st.setHintFrom(members, word)
+		return
+	}
+	if len(word) < 2 || len(st.symbols) == 0 {
 		st.hint = ""
 		return
 	}
@@ -2501,15 +4136,171 @@ func (st *State) setHint() {
 	st.hint = ""
 }
 
-// showOptions draw options in the status line
+// setHintFrom sets the hint to the first of the candidates completing the word.
+func (st *State) setHintFrom(candidates []string, word string) {
+	st.hint = ""
+	for _, c := range candidates {
+		if c != word && strings.HasPrefix(strings.ToLower(c), strings.ToLower(word)) {
+			st.hint = c
+			st.hintOff = len(word)
+			return
+		}
+	}
+}
+
+// showOptions draw options in the status line, or in a dropdown above it if they do not fit,
+// scrolled to the selected one.
 func (a *App) showOptions() {
-	ts := make([]textStyle, 0, len(a.s.options))
-	for i, opt := range a.s.options {
-		if i == a.s.optionIdx {
-			ts = append(ts, textStyle{text: []rune(opt + " "), style: styleHighlight})
-		} else {
-			ts = append(ts, textStyle{text: []rune(opt + " ")})
+	if a.s.optionIdx < 0 {
+		a.s.optionTop = 0
+	}
+	if rows := a.dropdownSize(); rows > 0 {
+		if a.s.optionIdx >= 0 {
+			a.s.optionTop = max(min(a.s.optionTop, a.s.optionIdx), a.s.optionIdx-rows+1)
+		}
+		a.s.optionTop = max(0, min(a.s.optionTop, len(a.s.options)-rows))
+	} else {
+		a.s.optionTop, _ = optionWindow(a.optionWidths(), a.s.optionTop, a.s.optionIdx, a.optionsWidth())
+	}
+	a.drawOptions()
+}
+
+// optionItem returns the texts of the option shown in the status line.
+func (a *App) optionItem(i int) []textStyle {
+	var ts []textStyle
+	opt := a.s.options[i]
+	if len(a.s.command) > 0 && a.s.command[0] == '@' {
+		// the kind indicator
+		if indicator, ok := symbolIndicators[a.s.symbolKindOf(opt)]; ok {
+			ts = append(ts, indicator, textStyle{text: []rune{' '}})
+		}
+	}
+	if i == a.s.optionIdx {
+		return append(ts, textStyle{text: []rune(opt + " "), style: styleHighlight})
+	}
+	return append(ts, textStyle{text: []rune(opt + " ")})
+}
+
+// optionWidths returns the screen widths of the options shown in the status line.
+func (a *App) optionWidths() []int {
+	widths := make([]int, len(a.s.options))
+	for i := range a.s.options {
+		for _, ts := range a.optionItem(i) {
+			widths[i] += runewidth.StringWidth(string(ts.text))
+		}
+	}
+	return widths
+}
+
+// optionCount returns the indicator of the selected option and the number of options, like "3/41".
+func (a *App) optionCount() string {
+	if a.s.optionIdx < 0 {
+		return fmt.Sprintf(" %d", len(a.s.options))
+	}
+	return fmt.Sprintf(" %d/%d", a.s.optionIdx+1, len(a.s.options))
+}
+
+// optionsWidth returns the width of the status line for the options, before the count.
+func (a *App) optionsWidth() int {
+	return a.status.w - len(a.optionCount())
+}
+
+// optionWindow returns the range of the options of the widths shown from top in the width,
+// moving top to show the selected option if sel is not negative.
+func optionWindow(widths []int, top, sel, width int) (int, int) {
+	top = max(0, min(top, len(widths)-1))
+	if sel >= 0 && sel < len(widths) {
+		top = min(top, sel)
+		used := 0
+		for _, w := range widths[top : sel+1] {
+			used += w
+		}
+		for top < sel && used > width {
+			used -= widths[top]
+			top++
+		}
+	}
+	end, used := top, 0
+	for end < len(widths) && (end == top || used+widths[end] <= width) {
+		used += widths[end]
+		end++
+	}
+	return top, end
+}
+
+// drawOptions draws the options from optionTop with the count at the right.
+func (a *App) drawOptions() {
+	if rows := a.dropdownSize(); rows != a.dropdownRows {
+		// redraw the rows covered before
+		a.dropdownRows = rows
+		a.drawEditor()
+	} else if rows > 0 {
+		a.drawDropdown()
+	}
+	if len(a.s.options) == 0 {
+		a.status.drawTexts(nil)
+		return
+	}
+	width := a.optionsWidth()
+	if a.dropdownRows > 0 {
+		a.status.drawTexts([]textStyle{
+			{text: []rune(strings.Repeat(" ", max(0, width)))},
+			{text: []rune(a.optionCount()), style: styleComment},
+		})
+		return
+	}
+	top, end := optionWindow(a.optionWidths(), a.s.optionTop, -1, width)
+	var ts []textStyle
+	used := 0
+	for i := top; i < end; i++ {
+		for _, t := range a.optionItem(i) {
+			ts = append(ts, t)
+			used += runewidth.StringWidth(string(t.text))
 		}
 	}
+	if used < width {
+		ts = append(ts, textStyle{text: []rune(strings.Repeat(" ", width-used))})
+	}
+	ts = append(ts, textStyle{text: []rune(a.optionCount()), style: styleComment})
 	a.status.drawTexts(ts)
 }
+
+// optionAt returns the index of the option shown at the column of the status line, -1 if none.
+func (a *App) optionAt(x int) int {
+	if a.dropdownRows > 0 {
+		return -1
+	}
+	widths := a.optionWidths()
+	top, end := optionWindow(widths, a.s.optionTop, -1, a.optionsWidth())
+	col := a.status.x
+	for i := top; i < end; i++ {
+		col += widths[i]
+		if x < col {
+			return i
+		}
+	}
+	return -1
+}
+
+// optionClick selects the option clicked, or accepts it if already selected.
+func (a *App) optionClick(i int) {
+	if i < 0 || a.s.focus != focusConsole {
+		return
+	}
+	if i == a.s.optionIdx {
+		a.consoleEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+		return
+	}
+	a.s.optionIdx = i
+	if len(a.s.command) > 0 && a.s.command[0] == '>' {
+		a.fillCompletion()
+	}
+	a.showOptions()
+	a.syncCursor()
+}
+
+// scrollOptions scrolls the options of the status line or the dropdown by n.
+func (a *App) scrollOptions(n int) {
+	a.s.optionTop = max(0, min(a.s.optionTop+n, len(a.s.options)-max(a.dropdownRows, 1)))
+	a.drawOptions()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// newTestApp returns an app editing the text on a simulation screen.
+func newTestApp(t *testing.T, text string) *App {
+	s := tcell.NewSimulationScreen("UTF-8")
+	if err := s.Init(); err != nil {
+		t.Fatal(err)
+	}
+	s.SetSize(80, 20)
+	screen = s
+	a := &App{s: &State{tabs: []*Tab{{}}, indent: "\t"}}
+	a.s.Tab = a.s.tabs[0]
+	a.s.loadSource(strings.NewReader(text))
+	a.resize()
+	a.draw()
+	return a
+}
+
+func TestReindent(t *testing.T) {
+	tests := []struct {
+		text   string
+		indent string
+		want   string
+	}{
+		{"foo()", "\t\t", "foo()"},
+		{"if x {\n\t\tfoo()\n\t}", "\t", "if x {\n\t\tfoo()\n\t}"},
+		{"\tif x {\n\t\tfoo()\n\t}\n", "\t\t", "if x {\n\t\t\tfoo()\n\t\t}\n"},
+		{"a\n\n    b\n  c", "\t", "a\n\n\t  b\n\tc"},
+		{"\t\ta\n\tb", "", "\ta\nb"},
+	}
+	for _, tt := range tests {
+		if got := reindent(tt.text, tt.indent); got != tt.want {
+			t.Errorf("reindent(%q, %q) = %q, want %q", tt.text, tt.indent, got, tt.want)
+		}
+	}
+}
+
+func TestHighlightGoLine(t *testing.T) {
+	tests := []struct {
+		line     string
+		strings  []string
+		comments []string
+	}{
+		{`s := "a" + "b"`, []string{`"a"`, `"b"`}, nil},
+		{`s := "\"" // quote`, []string{`"\""`}, []string{"// quote"}},
+		{`s := "a\\" + b`, []string{`"a\\"`}, nil},
+		{"re := `\\d+\"` // raw", []string{"`\\d+\"`"}, []string{"// raw"}},
+		{"url := `http://x` + y", []string{"`http://x`"}, nil},
+		{`r := '\'' + '"'`, []string{`'\''`, `'"'`}, nil},
+		{`r := '\n' // newline`, []string{`'\n'`}, []string{"// newline"}},
+		{`s := "unclosed // not a comment`, []string{`"unclosed // not a comment`}, nil},
+		{"s := `raw starts", []string{"`raw starts"}, nil},
+	}
+	for _, tt := range tests {
+		parts := highlightGoLine([]rune(tt.line))
+		if got := styledWords(parts, styleString); !reflect.DeepEqual(got, tt.strings) {
+			t.Errorf("highlightGoLine(%q) strings = %q, want %q", tt.line, got, tt.strings)
+		}
+		if got := styledWords(parts, styleComment); !reflect.DeepEqual(got, tt.comments) {
+			t.Errorf("highlightGoLine(%q) comments = %q, want %q", tt.line, got, tt.comments)
+		}
+		var text string
+		for _, p := range parts {
+			text += string(p.text)
+		}
+		if text != tt.line {
+			t.Errorf("highlightGoLine(%q) text = %q", tt.line, text)
+		}
+	}
+}
+
+func TestOptionWindow(t *testing.T) {
+	widths := []int{4, 4, 4, 4, 4, 4}
+	tests := []struct {
+		top, sel, width int
+		start, end      int
+	}{
+		{0, -1, 10, 0, 2},
+		{0, 0, 10, 0, 2},
+		{0, 3, 10, 2, 4},
+		{4, 1, 10, 1, 3},
+		{2, 3, 10, 2, 4},
+		{9, -1, 10, 5, 6},
+		{0, 5, 30, 0, 6},
+		{0, 2, 3, 2, 3}, // wider than the line, shown alone
+	}
+	for _, tt := range tests {
+		start, end := optionWindow(widths, tt.top, tt.sel, tt.width)
+		if start != tt.start || end != tt.end {
+			t.Errorf("optionWindow(top %d, sel %d, width %d) = %d, %d, want %d, %d", tt.top, tt.sel, tt.width, start, end, tt.start, tt.end)
+		}
+	}
+	if start, end := optionWindow(nil, 3, -1, 10); start != 0 || end != 0 {
+		t.Errorf("optionWindow of no options = %d, %d, want 0, 0", start, end)
+	}
+}
+
+func TestDumpTabsUntitled(t *testing.T) {
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	var tabs []*Tab
+	for _, text := range []string{"one", "two"} {
+		lines := newLineBuffer()
+		lines.PushBack([]rune(text))
+		tabs = append(tabs, &Tab{lines: lines, saved: -1})
+	}
+	paths := dumpTabs(tabs)
+	if len(paths) != 2 || paths[0] == paths[1] {
+		t.Fatalf("dumpTabs = %q, want two paths", paths)
+	}
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != string(tabs[i].lines.Front().Value) {
+			t.Errorf("%s = %q, %v", path, data, err)
+		}
+	}
+}
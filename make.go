@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// makeRule matches the targets of a Makefile rule like "build test: deps",
+// not variable assignments like "GO := go".
+var makeRule = regexp.MustCompile(`^([^\s:#=][^:#=]*):(?:[^=]|$)`)
+
+// makeTargets returns the targets of the Makefile text in order, except special ones like ".PHONY".
+func makeTargets(text string) []string {
+	var targets []string
+	for _, line := range strings.Split(text, "\n") {
+		m := makeRule.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		for _, target := range strings.Fields(m[1]) {
+			if !strings.HasPrefix(target, ".") && !strings.Contains(target, "%") && !slices.Contains(targets, target) {
+				targets = append(targets, target)
+			}
+		}
+	}
+	return targets
+}
+
+// completeCommand shows the completions of the console command, the make targets for now.
+// Tab then fills the command with the chosen completion.
+func (a *App) completeCommand() {
+	cmd := string(a.s.command)
+	prefix, ok := strings.CutPrefix(cmd, ">make ")
+	if !ok {
+		a.s.options = nil
+		return
+	}
+	data, err := os.ReadFile("Makefile")
+	if err != nil {
+		a.s.options = nil
+		return
+	}
+	var options []string
+	for _, target := range makeTargets(string(data)) {
+		if strings.HasPrefix(target, prefix) {
+			options = append(options, target)
+		}
+	}
+	a.s.options = options
+	a.s.optionIdx = -1
+	a.showOptions()
+}
+
+// fillCompletion replaces the argument of the console command with the chosen completion.
+func (a *App) fillCompletion() {
+	cmd := string(a.s.command)
+	i := strings.LastIndex(cmd, " ")
+	if i < 0 || a.s.optionIdx < 0 {
+		return
+	}
+	a.s.command = []rune(cmd[:i+1] + a.s.options[a.s.optionIdx])
+	a.s.commandCursor = len(a.s.command)
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMakeTargets(t *testing.T) {
+	makefile := `GO := go
+VERSION = 1.0
+.PHONY: build test
+build: deps
+	$(GO) build ./...
+test lint:
+	$(GO) test ./...
+%.o: %.c
+build:
+`
+	want := []string{"build", "test", "lint"}
+	if got := makeTargets(makefile); !reflect.DeepEqual(got, want) {
+		t.Errorf("makeTargets() = %q, want %q", got, want)
+	}
+}
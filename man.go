@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// SymbolSection is the kind of the section headings of a man page.
+const SymbolSection SymbolKind = "section"
+
+// parseOverstrike removes the overstrikes of the line formatted for terminals by man,
+// "c\bc" for bold and "_\bc" for underline, and returns the spans of the styles.
+func parseOverstrike(line []rune) ([]rune, []ansiSpan) {
+	var text []rune
+	var spans []ansiSpan
+	for i := 0; i < len(line); i++ {
+		if i+2 >= len(line) || line[i+1] != '\b' {
+			text = append(text, line[i])
+			continue
+		}
+		style := styleBase
+		switch {
+		case line[i] == line[i+2]:
+			style = style.Bold(true)
+		case line[i] == '_':
+			style = style.Underline(true)
+		}
+		col := len(text)
+		text = append(text, line[i+2])
+		i += 2
+		if style == styleBase {
+			continue
+		}
+		if n := len(spans); n > 0 && spans[n-1].end == col && spans[n-1].style == style {
+			spans[n-1].end++
+		} else {
+			spans = append(spans, ansiSpan{start: col, end: col + 1, style: style})
+		}
+	}
+	return text, spans
+}
+
+// manSections returns the section headings of the man page, the lines starting
+// at the first column except the header and the footer.
+func manSections(lines []string) []Symbol {
+	first, last := -1, -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	var sections []Symbol
+	for i := first + 1; i < last; i++ {
+		line := lines[i]
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		sections = append(sections, Symbol{Name: strings.TrimSpace(line), Kind: SymbolSection, Line: i + 1, Column: 1})
+	}
+	return sections
+}
+
+// showMan shows the man page of the topic in a read-only tab, the section headings are
+// symbols to go to by alt-up/down and @.
+func (a *App) showMan(topic string) {
+	if topic == "" {
+		a.showError("Usage: >man [section] topic")
+		return
+	}
+	title := "man " + topic
+	if i := a.s.findBuffer(title); i >= 0 {
+		a.s.switchTab(i)
+		a.draw()
+		return
+	}
+	width := a.editor[0].w - a.s.lineNumLen() - 1
+	t := a.s.tasks.start(title, 0)
+	go func() {
+		defer t.finish()
+		cmd := exec.CommandContext(t.ctx, "man", append([]string{"-P", "cat"}, strings.Fields(topic)...)...)
+		// overstrikes instead of the escape sequences of groff
+		cmd.Env = append(os.Environ(), "MANWIDTH="+strconv.Itoa(width), "MAN_KEEP_FORMATTING=1", "GROFF_NO_SGR=1")
+		out, err := cmd.Output()
+		if t.ctx.Err() != nil {
+			return
+		}
+		postFunc(func() {
+			if err != nil {
+				var ee *exec.ExitError
+				if errors.As(err, &ee) && len(ee.Stderr) > 0 {
+					err = errors.New(strings.TrimSpace(string(ee.Stderr)))
+				}
+				a.showError(err.Error())
+				return
+			}
+			lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+			styles := make([][]ansiSpan, len(lines))
+			for i, line := range lines {
+				text, spans := parseOverstrike([]rune(line))
+				lines[i], styles[i] = string(text), spans
+			}
+			a.openBuffer(title, strings.Join(lines, "\n"))
+			a.s.ansi = styles
+			a.s.symbols = make(map[string][]Symbol)
+			for _, sym := range manSections(lines) {
+				a.s.symbols[sym.Name] = append(a.s.symbols[sym.Name], sym)
+			}
+			a.drawEditor()
+		})
+	}()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseOverstrike(t *testing.T) {
+	text, spans := parseOverstrike([]rune("N\bNA\bAM\bME\bE ls _\bf_\bi_\bl_\be x"))
+	if string(text) != "NAME ls file x" {
+		t.Errorf("text = %q", string(text))
+	}
+	want := []ansiSpan{{0, 4, styleBase.Bold(true)}, {8, 12, styleBase.Underline(true)}}
+	if !reflect.DeepEqual(spans, want) {
+		t.Errorf("spans = %v, want %v", spans, want)
+	}
+}
+
+func TestManSections(t *testing.T) {
+	lines := []string{
+		"LS(1)          User Commands          LS(1)",
+		"",
+		"NAME",
+		"       ls - list directory contents",
+		"",
+		"DESCRIPTION",
+		"   Exit status:",
+		"",
+		"GNU coreutils 9.1     2022     LS(1)",
+	}
+	var names []string
+	var rows []int
+	for _, sym := range manSections(lines) {
+		names = append(names, sym.Name)
+		rows = append(rows, sym.Line)
+	}
+	if !reflect.DeepEqual(names, []string{"NAME", "DESCRIPTION"}) || !reflect.DeepEqual(rows, []int{3, 6}) {
+		t.Errorf("sections = %q at %v", names, rows)
+	}
+}
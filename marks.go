@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// mark is a named position. Marks a-z belong to a tab,
+// marks A-Z are global and reopen the file if its tab was closed.
+type mark struct {
+	tab      *Tab // nil if the tab of a global mark is closed
+	filename string
+	row      int
+	col      int
+}
+
+// textEnd returns the position after the text inserted at row and col.
+func textEnd(text string, row, col int) (int, int) {
+	n := strings.Count(text, "\n")
+	if n == 0 {
+		return row, col + len([]rune(text))
+	}
+	return row + n, len([]rune(text[strings.LastIndex(text, "\n")+1:]))
+}
+
+// shift returns the position after applying the change to the text,
+// so that a position keeps pointing to the same text as lines shift.
+func (c Change) shift(row, col int) (int, int) {
+	if row < c.row || (row == c.row && col < c.col) {
+		return row, col
+	}
+	oldRow, oldCol := textEnd(c.oldText, c.row, c.col)
+	newRow, newCol := textEnd(c.newText, c.row, c.col)
+	if row < oldRow || (row == oldRow && col < oldCol) {
+		// the text was deleted
+		return c.row, c.col
+	}
+	if row == oldRow {
+		return newRow, newCol + col - oldCol
+	}
+	return row + newRow - oldRow, col
+}
+
+// shiftMarks moves the marks and gutter annotations of current tab according to the change.
+func (st *State) shiftMarks(c Change) {
+	st.shiftAnnotations(c)
+	for _, marks := range []map[rune]*mark{st.Tab.marks, st.marks} {
+		for _, m := range marks {
+			if m.tab == st.Tab {
+				m.row, m.col = c.shift(m.row, m.col)
+			}
+		}
+	}
+}
+
+// setMark puts the named mark at the cursor.
+func (a *App) setMark(name rune) {
+	m := &mark{tab: a.s.Tab, filename: a.s.filename, row: a.s.row, col: a.s.col}
+	switch {
+	case name >= 'a' && name <= 'z':
+		if a.s.Tab.marks == nil {
+			a.s.Tab.marks = make(map[rune]*mark)
+		}
+		a.s.Tab.marks[name] = m
+	case name >= 'A' && name <= 'Z':
+		if a.s.marks == nil {
+			a.s.marks = make(map[rune]*mark)
+		}
+		a.s.marks[name] = m
+	default:
+		a.showError(fmt.Sprintf("Invalid mark %q, use a-z for the tab or A-Z for global", name))
+		return
+	}
+	a.drawEditor() // show the mark in the gutter
+	a.showMessage(fmt.Sprintf("Mark %c set", name))
+}
+
+// jumpMark moves the cursor to the named mark, switching to its tab if global.
+// It runs in the command loop since it may open a file.
+func (a *App) jumpMark(name rune) {
+	m := a.s.Tab.marks[name]
+	if unicode.IsUpper(name) {
+		m = a.s.marks[name]
+	}
+	if m == nil {
+		a.showError(fmt.Sprintf("Mark %c not set", name))
+		return
+	}
+	a.recordPositon(a.s.row, a.s.col)
+	if m.tab != a.s.Tab {
+		if i := slices.Index(a.s.tabs, m.tab); m.tab != nil && i >= 0 {
+			a.s.switchTab(i)
+		} else {
+			if m.filename == "" {
+				a.showError(fmt.Sprintf("Mark %c is in a closed tab", name))
+				return
+			}
+			a.handleCommand(">open " + m.filename)
+			if a.s.filename != m.filename {
+				return
+			}
+			m.tab = a.s.Tab
+		}
+		a.draw()
+	}
+	a.s.focus = focusEditor
+	row := min(m.row, a.s.lines.Len()-1)
+	a.jump(row, min(m.col, len(a.s.line(row).Value)))
+	a.syncCursor()
+}
+
+// listMarks returns the marks of current tab and global marks, one per line.
+func (st *State) listMarks() string {
+	var b strings.Builder
+	for _, marks := range []map[rune]*mark{st.Tab.marks, st.marks} {
+		names := make([]rune, 0, len(marks))
+		for name := range marks {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			m := marks[name]
+			fmt.Fprintf(&b, "%c %s:%d:%d\n", name, m.filename, m.row+1, m.col+1)
+		}
+	}
+	return b.String()
+}
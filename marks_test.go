@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestChangeShift(t *testing.T) {
+	tests := []struct {
+		name     string
+		change   Change
+		row, col int
+		wantRow  int
+		wantCol  int
+	}{
+		{"before", Change{row: 2, col: 0, newText: "a\nb"}, 1, 3, 1, 3},
+		{"lines inserted above", Change{row: 0, col: 0, newText: "a\nb\n"}, 3, 1, 5, 1},
+		{"text inserted before on the line", Change{row: 3, col: 0, newText: "ab"}, 3, 1, 3, 3},
+		{"line break inserted before", Change{row: 3, col: 0, newText: "\n"}, 3, 1, 4, 1},
+		{"lines deleted above", Change{row: 0, col: 2, oldText: "a\nb\nc"}, 4, 0, 2, 0},
+		{"deleted", Change{row: 1, col: 0, oldText: "a\nbcd"}, 2, 1, 1, 0},
+		{"joined lines", Change{row: 1, col: 3, oldText: "\n"}, 2, 1, 1, 4},
+		{"replaced", Change{row: 0, col: 1, oldText: "abc", newText: "x\ny"}, 0, 5, 1, 2},
+	}
+	for _, tt := range tests {
+		row, col := tt.change.shift(tt.row, tt.col)
+		if row != tt.wantRow || col != tt.wantCol {
+			t.Errorf("%s: shift(%d, %d) = %d, %d, want %d, %d", tt.name, tt.row, tt.col, row, col, tt.wantRow, tt.wantCol)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"slices"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/mattn/go-runewidth"
+)
+
+// labelMenu is the button at the right of the tabbar opening the menu.
+const labelMenu = " ≡ "
+
+// menuItem is an entry of the dropdown menu, the action runs in the main goroutine.
+type menuItem struct {
+	label  string
+	keys   string
+	action func(a *App)
+}
+
+var menuItems = []menuItem{
+	{"New tab", "ctrl-t", func(a *App) { a.cmdCh <- ">new" }},
+	{"Open", "ctrl-o", func(a *App) {
+		a.s.focus = focusConsole
+		a.setConsole(">open ")
+	}},
+	{"Save", "ctrl-s", func(a *App) { a.cmdCh <- ">save " + a.s.filename }},
+	{"Scratch", "", func(a *App) { a.cmdCh <- ">scratch" }},
+	{"Settings", "", func(a *App) { a.cmdCh <- ">settings" }},
+	{"Help", "f1", func(a *App) { a.cmdCh <- ">help" }},
+	{"Quit", "ctrl-q", func(a *App) { close(a.done) }},
+}
+
+// menuView returns the view of the i-th menu item, below the menu button.
+func (a *App) menuView(i int) *View {
+	w := 0
+	for _, item := range menuItems {
+		w = max(w, runewidth.StringWidth(item.label)+len(item.keys)+3)
+	}
+	return &View{a.tabbar.x + a.tabbar.w - w, a.tabbar.y + 1 + i, w, 1, styleBase.Reverse(true)}
+}
+
+// openMenu shows the dropdown menu and focuses on it.
+func (a *App) openMenu() {
+	a.s.focus = focusMenu
+	a.s.menuIdx = 0
+	a.drawMenu()
+	a.syncCursor()
+}
+
+// closeMenu hides the dropdown menu, redrawing the editor under it.
+func (a *App) closeMenu() {
+	a.s.focus = focusEditor
+	a.drawEditor()
+	a.syncCursor()
+}
+
+func (a *App) drawMenu() {
+	for i, item := range menuItems {
+		v := a.menuView(i)
+		style := v.style
+		if i == a.s.menuIdx {
+			style = styleHighlight
+		}
+		padding := v.w - runewidth.StringWidth(item.label) - len(item.keys) - 2
+		text := " " + item.label + string(slices.Repeat([]rune{' '}, padding)) + item.keys + " "
+		v.drawTexts([]textStyle{{text: []rune(text), style: style}})
+	}
+}
+
+// menuEvent navigates the menu by keyboard.
+func (a *App) menuEvent(ev *tcell.EventKey) {
+	switch ev.Key() {
+	case tcell.KeyUp:
+		a.s.menuIdx = (a.s.menuIdx + len(menuItems) - 1) % len(menuItems)
+	case tcell.KeyDown, tcell.KeyTab:
+		a.s.menuIdx = (a.s.menuIdx + 1) % len(menuItems)
+	case tcell.KeyEnter:
+		a.closeMenu()
+		menuItems[a.s.menuIdx].action(a)
+		a.syncCursor()
+		return
+	case tcell.KeyEscape:
+		a.closeMenu()
+		return
+	}
+	a.drawMenu()
+}
+
+// menuClick runs the menu item clicked, or closes the menu if clicked outside.
+// It returns false if the click is not on the menu.
+func (a *App) menuClick(x, y int) bool {
+	for i := range menuItems {
+		if a.menuView(i).contains(x, y) {
+			a.closeMenu()
+			menuItems[i].action(a)
+			a.syncCursor()
+			return true
+		}
+	}
+	a.closeMenu()
+	return false
+}
+
+// newTab opens an empty tab after the current one.
+func (a *App) newTab() {
+	a.s.tabs = slices.Insert(a.s.tabs, a.s.tabIdx+1, &Tab{filename: "", lines: newLineBuffer()})
+	a.s.switchTab(a.s.tabIdx + 1)
+	a.s.focus = focusEditor
+	a.draw()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// isBlank reports whether the line has only white spaces.
+func isBlank(line []rune) bool {
+	return strings.TrimSpace(string(line)) == ""
+}
+
+// paragraphRow returns the row of the blank line separating the block of the row
+// from the next block (dir > 0) or previous block (dir < 0),
+// or the last or first row if there is none.
+func (st *State) paragraphRow(row, dir int) int {
+	e := st.line(row)
+	if e == nil {
+		return row
+	}
+	next := func(e *lineElem) *lineElem {
+		if dir > 0 {
+			return e.Next()
+		}
+		return e.Prev()
+	}
+	// skip the blank lines, then the block
+	for _, blank := range []bool{true, false} {
+		for e != nil && isBlank(e.Value) == blank {
+			if n := next(e); n != nil {
+				row += dir
+				e = n
+				continue
+			}
+			return row
+		}
+	}
+	return row
+}
+
+// declRow returns the row of the next (dir > 0) or previous (dir < 0) top-level declaration
+// according to the symbol index, it returns false if there is none.
+func (st *State) declRow(row, dir int) (int, bool) {
+	var rows []int
+	for _, symbols := range st.symbols {
+		for _, sym := range symbols {
+			if sym.Kind == SymbolImport || sym.Kind == SymbolField {
+				continue
+			}
+			rows = append(rows, sym.Line-1)
+		}
+	}
+	slices.Sort(rows)
+	if dir > 0 {
+		for _, r := range rows {
+			if r > row {
+				return r, true
+			}
+		}
+		return 0, false
+	}
+	for i := len(rows) - 1; i >= 0; i-- {
+		if rows[i] < row {
+			return rows[i], true
+		}
+	}
+	return 0, false
+}
+
+// jumpParagraph moves the cursor to the next (dir > 0) or previous (dir < 0) paragraph.
+func (a *App) jumpParagraph(dir int) {
+	row := a.s.paragraphRow(a.s.row, dir)
+	if row == a.s.row {
+		return
+	}
+	a.recordPositon(a.s.row, a.s.col)
+	a.jump(row, 0)
+}
+
+// jumpDecl moves the cursor to the next (dir > 0) or previous (dir < 0) top-level declaration.
+func (a *App) jumpDecl(dir int) {
+	row, ok := a.s.declRow(a.s.row, dir)
+	if !ok {
+		return
+	}
+	a.recordPositon(a.s.row, a.s.col)
+	a.jump(row, 0)
+}
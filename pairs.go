@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// pairs maps the opening characters to the closing ones inserted along.
+var pairs = map[rune]rune{'(': ')', '[': ']', '{': '}', '"': '"', '\'': '\'', '`': '`'}
+
+// isCloser reports whether the rune closes a pair.
+func isCloser(r rune) bool {
+	return strings.ContainsRune(")]}\"'`", r)
+}
+
+// closerOf returns the closing character to insert along the rune typed at col, or 0 if none.
+// The pair is only closed before spaces or closing characters, and a quote is not
+// closed after a word, as the apostrophe in "it's".
+func closerOf(line []rune, col int, r rune) rune {
+	closer, ok := pairs[r]
+	if !ok {
+		return 0
+	}
+	if col < len(line) && !unicode.IsSpace(line[col]) && !isCloser(line[col]) {
+		return 0
+	}
+	if closer == r && col > 0 {
+		if prev := line[col-1]; prev == r || unicode.IsLetter(prev) || unicode.IsDigit(prev) {
+			return 0
+		}
+	}
+	return closer
+}
+
+// typesOver reports whether the rune typed at col moves over the same closing character
+// instead of inserting another one.
+func typesOver(line []rune, col int, r rune) bool {
+	return isCloser(r) && col < len(line) && line[col] == r
+}
+
+// inPair reports whether col is between an empty pair like "()".
+func inPair(line []rune, col int) bool {
+	if col == 0 || col >= len(line) {
+		return false
+	}
+	closer, ok := pairs[line[col-1]]
+	return ok && line[col] == closer
+}
+
+// backspaceStart returns the column the backspace at col deletes from. In the indentation
+// of spaces it deletes back to the previous indent level, otherwise the previous grapheme.
+func (st *State) backspaceStart(line []rune, col int) int {
+	start := prevGrapheme(line, col)
+	if st.indent == "\t" || col > leadingWhitespaces(line) || line[col-1] != ' ' {
+		return start
+	}
+	level := (col - 1) / len(st.indent) * len(st.indent)
+	for start > level && line[start-1] == ' ' {
+		start--
+	}
+	return start
+}
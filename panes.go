@@ -0,0 +1,287 @@
+package main
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// pane is a part of the editor area showing a tab, when the area is split.
+// The focused pane keeps its cursor and scroll in its tab as usual, the others keep them here.
+type pane struct {
+	tab                 *Tab
+	row, col, top, left int
+	size                int // rows or columns of the pane, scaled to fit the editor area
+	views               []*View
+}
+
+// layoutPanes divides the editor area at the position among the panes, side by side if
+// vertical, otherwise stacked, with a separator between. The focused pane becomes a.editor.
+func (a *App) layoutPanes(x, y, w, h int) {
+	a.separators = nil
+	total := h
+	if a.splitVertical {
+		total = w
+	}
+	avail := total - (len(a.panes) - 1)
+	if avail < len(a.panes) {
+		// no room, only the focused pane is shown
+		for _, p := range a.panes {
+			p.views = nil
+		}
+		a.panes[a.paneIdx].views = editorViews(x, y, w, h)
+		a.editor = a.panes[a.paneIdx].views
+		return
+	}
+	sum := 0
+	for _, p := range a.panes {
+		sum += max(p.size, 1)
+	}
+	off, used := 0, 0
+	for i, p := range a.panes {
+		n := max(avail*max(p.size, 1)/sum, 1)
+		if i == len(a.panes)-1 {
+			n = avail - used
+		}
+		used += n
+		if a.splitVertical {
+			p.views = editorViews(x+off, y, n, h)
+			if i > 0 {
+				for r := range h {
+					a.separators = append(a.separators, &View{x + off - 1, y + r, 1, 1, styleComment})
+				}
+			}
+		} else {
+			p.views = editorViews(x, y+off, w, n)
+			if i > 0 {
+				a.separators = append(a.separators, &View{x, y + off - 1, w, 1, styleComment})
+			}
+		}
+		off += n + 1
+	}
+	a.editor = a.panes[a.paneIdx].views
+}
+
+// editorViews returns the line views of an editor area.
+func editorViews(x, y, w, h int) []*View {
+	views := make([]*View, h)
+	for i := range views {
+		views[i] = &View{x, y + i, w, 1, tcell.StyleDefault}
+	}
+	return views
+}
+
+// drawPanes draws the panes other than the focused one and the separators,
+// only those showing the tab of the focused pane unless all is true. Panes of tabs bound
+// by >scrollbind with the focused one follow its scroll.
+func (a *App) drawPanes(all bool) {
+	if len(a.panes) == 0 {
+		return
+	}
+	for i, p := range a.panes {
+		if i == a.paneIdx {
+			p.tab = a.s.Tab
+			continue
+		}
+		if !slices.Contains(a.s.tabs, p.tab) {
+			// the tab is closed
+			p.tab, p.row, p.col, p.top, p.left = a.s.Tab, a.s.row, a.s.col, a.s.top, a.s.left
+		}
+		bound := a.s.scrollBind && p.tab.scrollBind
+		if bound {
+			p.top = min(a.s.top, max(0, p.tab.lines.Len()-1))
+		}
+		if all || bound || p.tab == a.s.Tab {
+			a.inPane(p, a.drawLines)
+		}
+	}
+	if !all {
+		return
+	}
+	for _, v := range a.separators {
+		if v.w == 1 {
+			v.draw([]rune{'│'})
+		} else {
+			v.draw([]rune(strings.Repeat("─", v.w)))
+		}
+	}
+}
+
+// inPane runs f as if the pane were focused, to draw it.
+func (a *App) inPane(p *pane, f func()) {
+	tab, editor := a.s.Tab, a.editor
+	saved := [4]int{tab.row, tab.col, tab.top, tab.left}
+	other := [4]int{p.tab.row, p.tab.col, p.tab.top, p.tab.left}
+	last := max(p.tab.lines.Len()-1, 0)
+	a.s.Tab, a.editor = p.tab, p.views
+	a.s.row, a.s.col, a.s.top, a.s.left = min(p.row, last), p.col, min(p.top, last), p.left
+	f()
+	p.tab.row, p.tab.col, p.tab.top, p.tab.left = other[0], other[1], other[2], other[3]
+	a.s.Tab, a.editor = tab, editor
+	tab.row, tab.col, tab.top, tab.left = saved[0], saved[1], saved[2], saved[3]
+}
+
+// focusPane moves the focus to the pane i, keeping the cursor and scroll of the one left.
+func (a *App) focusPane(i int) {
+	if i == a.paneIdx || i < 0 || i >= len(a.panes) {
+		return
+	}
+	cur := a.panes[a.paneIdx]
+	cur.tab, cur.row, cur.col, cur.top, cur.left = a.s.Tab, a.s.row, a.s.col, a.s.top, a.s.left
+	p := a.panes[i]
+	if !slices.Contains(a.s.tabs, p.tab) {
+		p.tab = a.s.Tab
+	}
+	a.paneIdx = i
+	a.s.switchTab(slices.Index(a.s.tabs, p.tab))
+	last := max(a.s.lines.Len()-1, 0)
+	a.s.row, a.s.col, a.s.top, a.s.left = min(p.row, last), p.col, min(p.top, last), p.left
+	a.s.cursors = nil
+	a.editor = p.views
+	a.draw()
+}
+
+// paneAt returns the index of the pane at the screen position, -1 if none.
+func (a *App) paneAt(x, y int) int {
+	for i, p := range a.panes {
+		if len(p.views) > 0 && p.views[0].x <= x && x < p.views[0].x+p.views[0].w &&
+			p.views[0].y <= y && y < p.views[0].y+len(p.views) {
+			return i
+		}
+	}
+	return -1
+}
+
+// split splits the focused pane in two showing its tab, side by side if vertical,
+// otherwise stacked, and focuses the new one. All the panes split the same way.
+func (a *App) split(vertical bool) {
+	a.s.focus = focusEditor
+	if len(a.panes) == 0 {
+		a.panes = []*pane{{tab: a.s.Tab}}
+		a.paneIdx = 0
+	}
+	if len(a.panes) == 1 || vertical != a.splitVertical {
+		// sizes are counted in the new direction
+		for _, p := range a.panes {
+			p.size = 2
+		}
+	}
+	a.splitVertical = vertical
+	cur := a.panes[a.paneIdx]
+	cur.tab, cur.row, cur.col, cur.top, cur.left = a.s.Tab, a.s.row, a.s.col, a.s.top, a.s.left
+	p := *cur
+	p.size = max(cur.size/2, 1)
+	cur.size = max(cur.size-p.size, 1)
+	a.panes = slices.Insert(a.panes, a.paneIdx+1, &p)
+	a.paneIdx++
+	a.resize()
+	a.draw()
+}
+
+// closePane closes the focused pane and focuses the next, its room goes to the neighbor.
+func (a *App) closePane() {
+	a.s.focus = focusEditor
+	if len(a.panes) < 2 {
+		a.showMessage("No other pane")
+		return
+	}
+	i := a.paneIdx
+	neighbor := min(i+1, len(a.panes)-1)
+	if neighbor == i {
+		neighbor = i - 1
+	}
+	a.panes[neighbor].size += a.panes[i].size
+	a.focusPane(neighbor)
+	a.panes = slices.Delete(a.panes, i, i+1)
+	if neighbor > i {
+		a.paneIdx--
+	}
+	if len(a.panes) == 1 {
+		a.panes = nil
+		a.paneIdx = 0
+	}
+	a.resize()
+	a.draw()
+}
+
+// resizePane grows the focused pane by n rows or columns taken from the next pane,
+// or the previous for the last, shrinking it if n is negative.
+func (a *App) resizePane(arg string) {
+	a.s.focus = focusEditor
+	n, err := strconv.Atoi(arg)
+	if err != nil {
+		a.showMessage("Usage: >resizepane +N|-N")
+		return
+	}
+	if len(a.panes) < 2 {
+		a.showMessage("No other pane")
+		return
+	}
+	// sizes are scaled, use the actual ones
+	for _, p := range a.panes {
+		if a.splitVertical && len(p.views) > 0 {
+			p.size = p.views[0].w
+		} else {
+			p.size = len(p.views)
+		}
+	}
+	cur := a.panes[a.paneIdx]
+	other := a.panes[min(a.paneIdx+1, len(a.panes)-1)]
+	if other == cur {
+		other = a.panes[a.paneIdx-1]
+	}
+	n = max(min(n, other.size-1), 1-cur.size)
+	cur.size += n
+	other.size -= n
+	a.resize()
+	a.draw()
+}
+
+// adjacentPane returns the index of the pane after the focused one, or before for the last.
+func (a *App) adjacentPane() int {
+	if a.paneIdx+1 < len(a.panes) {
+		return a.paneIdx + 1
+	}
+	return a.paneIdx - 1
+}
+
+// toSide focuses the adjacent pane showing the tab of the focused one, splitting the editor
+// side by side if not split, so that what opens next leaves the tab in view.
+func (a *App) toSide() {
+	a.s.focus = focusEditor
+	if len(a.panes) < 2 {
+		a.split(true)
+		return
+	}
+	tab := a.s.Tab
+	a.focusPane(a.adjacentPane())
+	if a.s.Tab != tab {
+		a.s.switchTab(slices.Index(a.s.tabs, tab))
+		a.draw()
+	}
+}
+
+// moveTab moves the tab of the focused pane to the adjacent pane, splitting the editor side
+// by side if not split, and focuses it there. The pane left shows the tab the other one
+// showed, or the tab before in the tabbar if the same.
+func (a *App) moveTab() {
+	tab := a.s.Tab
+	var shown *Tab
+	if len(a.panes) >= 2 {
+		shown = a.panes[a.adjacentPane()].tab
+	}
+	from := a.paneIdx
+	a.toSide()
+	if shown == nil || shown == tab || !slices.Contains(a.s.tabs, shown) {
+		i := slices.Index(a.s.tabs, tab)
+		shown = a.s.tabs[max(i-1, 0)]
+		if shown == tab && len(a.s.tabs) > 1 {
+			shown = a.s.tabs[1]
+		}
+	}
+	p := a.panes[from]
+	p.tab, p.row, p.col, p.top, p.left = shown, shown.row, shown.col, shown.top, shown.left
+	a.draw()
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// identRefs appends the identifiers of the name in the Go source of the file as results,
+// leaving out the comments and strings that grep would match.
+func identRefs(items []resultItem, path, group string, src []byte, name string) []resultItem {
+	if !bytes.Contains(src, []byte(name)) {
+		return items
+	}
+	fset := token.NewFileSet()
+	file := fset.AddFile(path, -1, len(src))
+	var s scanner.Scanner
+	s.Init(file, src, nil, 0)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok != token.IDENT || lit != name {
+			continue
+		}
+		off := file.Offset(pos)
+		start := bytes.LastIndexByte(src[:off], '\n') + 1
+		end := bytes.IndexByte(src[off:], '\n')
+		if end < 0 {
+			end = len(src) - off
+		}
+		items = append(items, resultItem{
+			file:  path,
+			group: group,
+			row:   file.Line(pos) - 1,
+			col:   utf8.RuneCount(src[start:off]),
+			text:  string(src[start : off+end]),
+		})
+	}
+	return items
+}
+
+// goFileRefs appends the identifiers of the name in the Go file under the root,
+// skipping large files.
+func goFileRefs(items []resultItem, root, name, ident string) []resultItem {
+	path := filepath.Join(root, name)
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > maxGrepFileSize {
+		return items
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return items
+	}
+	return identRefs(items, path, name, src, ident)
+}
+
+// references lists the references of the identifier under the cursor in the "references"
+// tab, by gopls if running for the tab. Otherwise it searches the files of quick open in
+// the background, the Go identifiers of Go files for a Go tab, whole words for others.
+func (a *App) references() {
+	a.s.focus = focusEditor
+	word := a.s.wordAtCursor()
+	if word == "" {
+		a.showMessage("No identifier under the cursor")
+		return
+	}
+	if a.s.lsp != nil && isGoTab(a.s.Tab) {
+		a.lspReferences(word)
+		return
+	}
+	root, err := filepath.Abs(".")
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	goOnly := strings.HasSuffix(a.s.filename, ".go")
+	s := &searcher{re: regexp.MustCompile(`\b` + regexp.QuoteMeta(word) + `\b`)}
+	t := a.s.tasks.start("indexing files", 0)
+	exclude := a.s.excludeDirs
+	go func() {
+		files, err := indexFiles(root, exclude, t)
+		canceled := t.ctx.Err() != nil
+		t.finish()
+		if canceled {
+			return
+		}
+		if err != nil {
+			postFunc(func() { a.showError(err.Error()) })
+			return
+		}
+		t = a.s.tasks.start("references", int64(len(files)))
+		defer t.finish()
+		var items []resultItem
+		for _, name := range files {
+			if t.ctx.Err() != nil {
+				return
+			}
+			switch {
+			case goOnly && strings.HasSuffix(name, ".go"):
+				items = goFileRefs(items, root, name, word)
+			case !goOnly:
+				items = grepFile(items, root, name, s)
+			}
+			t.add(1)
+			if len(items) >= maxGrepMatches {
+				break
+			}
+		}
+		postFunc(func() {
+			a.s.files = files
+			a.showReferences(word, items)
+		})
+	}()
+}
+
+// showReferences lists the references of the word.
+func (a *App) showReferences(word string, items []resultItem) {
+	if len(items) == 0 {
+		a.showMessage("No reference of " + word + " found")
+		return
+	}
+	a.showResults("references", fmt.Sprintf("%d references of %s", len(items), word), items)
+}
+
+// lspReferences asks gopls for the references of the identifier at the cursor,
+// the declaration included. It runs in the command loop.
+func (a *App) lspReferences(word string) {
+	t := a.s.tasks.start("references", 0)
+	defer t.finish()
+	params := a.s.lspPosition()
+	params["context"] = map[string]any{"includeDeclaration": true}
+	var locs []lspLocation
+	if err := a.s.lsp.client.request(t.ctx, "textDocument/references", params, &locs); err != nil {
+		if t.ctx.Err() == nil {
+			a.showError(err.Error())
+		}
+		return
+	}
+	root, _ := filepath.Abs(".")
+	items := locationItems(locs, root)
+	postFunc(func() { a.showReferences(word, items) })
+}
+
+// locationItems returns the locations as results grouped by file relative to the root,
+// with the text of their lines.
+func locationItems(locs []lspLocation, root string) []resultItem {
+	lines := make(map[string][]string)
+	var items []resultItem
+	for _, loc := range locs {
+		path := uriPath(loc.URI)
+		if path == "" {
+			continue
+		}
+		if _, ok := lines[path]; !ok {
+			data, _ := os.ReadFile(path)
+			lines[path] = strings.Split(string(data), "\n")
+		}
+		group := path
+		if rel, err := filepath.Rel(root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			group = rel
+		}
+		item := resultItem{file: path, group: group, row: loc.Range.Start.Line}
+		if item.row < len(lines[path]) {
+			item.text = lines[path][item.row]
+			item.col = runeColumn([]rune(item.text), loc.Range.Start.Character)
+		}
+		items = append(items, item)
+	}
+	// sorted by file so that the results group them
+	slices.SortStableFunc(items, func(x, y resultItem) int {
+		return cmp.Or(strings.Compare(x.group, y.group), x.row-y.row, x.col-y.col)
+	})
+	return items
+}
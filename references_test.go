@@ -0,0 +1,47 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIdentRefs(t *testing.T) {
+	src := "package p\n\n// foo in a comment\nfunc foo() string {\n\treturn \"foo\" + é(foo2, foo)\n}"
+	items := identRefs(nil, "/p.go", "p.go", []byte(src), "foo")
+	want := []struct{ row, col int }{{3, 5}, {4, 24}}
+	if len(items) != len(want) {
+		t.Fatalf("references = %+v, want %v", items, want)
+	}
+	for i, w := range want {
+		if items[i].row != w.row || items[i].col != w.col || items[i].group != "p.go" {
+			t.Errorf("reference %d = %+v, want at %d:%d", i, items[i], w.row, w.col)
+		}
+	}
+	if items[1].text != "\treturn \"foo\" + é(foo2, foo)" {
+		t.Errorf("text = %q", items[1].text)
+	}
+}
+
+func TestLocationItems(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "b.go")
+	if err := os.WriteFile(path, []byte("package b\n\nvar 😀, x = 1, 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	locs := []lspLocation{
+		{URI: fileURI(path), Range: lspRange{Start: lspPosition{Line: 2, Character: 8}}},
+		{URI: fileURI(path), Range: lspRange{Start: lspPosition{Line: 0, Character: 0}}},
+		{URI: "untitled:x"},
+	}
+	items := locationItems(locs, root)
+	if len(items) != 2 {
+		t.Fatalf("items = %+v, want 2", items)
+	}
+	if it := items[1]; it.group != "b.go" || it.row != 2 || it.col != 7 || it.text != "var 😀, x = 1, 2" {
+		t.Errorf("item = %+v, want x at 2:7", it)
+	}
+	if items[0].row != 0 {
+		t.Errorf("first item at row %d, want sorted by position", items[0].row)
+	}
+}
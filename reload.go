@@ -0,0 +1,338 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// fileWatchInterval is how often the file of current tab is checked for changes by other programs.
+const fileWatchInterval = 2 * time.Second
+
+var (
+	styleHunkHeader = styleBase.Bold(true)
+	styleDeleted    = styleBase.Foreground(tcell.ColorDarkRed)
+	styleInserted   = styleBase.Foreground(tcell.ColorDarkGreen)
+	styleDropped    = styleComment.StrikeThrough(true)
+	colorWordDelete = tcell.ColorLightPink
+	colorWordInsert = tcell.ColorPaleGreen
+)
+
+// statFile records the modification time of the file of the tab, to tell changes by others.
+func (t *Tab) statFile() {
+	if info, err := os.Stat(t.filename); err == nil {
+		t.modTime = info.ModTime()
+	}
+}
+
+// splitLines splits the text into lines as loadSource does, ending with an empty line.
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{""}
+	}
+	lines := strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return append(lines, "")
+}
+
+// lineStrings returns the lines of the tab.
+func (t *Tab) lineStrings() []string {
+	lines := make([]string, 0, t.lines.Len())
+	for e := t.lines.Front(); e != nil; e = e.Next() {
+		lines = append(lines, string(e.Value))
+	}
+	return lines
+}
+
+// savedLines returns the lines of the tab when last saved, by undoing or redoing on a copy,
+// false if the saved state is no longer in the undo history.
+func (t *Tab) savedLines() ([]string, bool) {
+	if t.saved < 0 {
+		return nil, false
+	}
+	lines := newLineBuffer()
+	for e := t.lines.Front(); e != nil; e = e.Next() {
+		lines.PushBack(slices.Clone(e.Value))
+	}
+	st := &State{Tab: &Tab{lines: lines}}
+	for i := t.applied() - 1; i >= t.saved; i-- {
+		st.applyChange(reverse(t.changes[i]))
+	}
+	for i := t.applied(); i < t.saved; i++ {
+		st.applyChange(t.changes[i])
+	}
+	return st.lineStrings(), true
+}
+
+// watchFiles checks the file of current tab for changes by other programs periodically.
+func (a *App) watchFiles() {
+	go func() {
+		ticker := time.NewTicker(fileWatchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-a.done:
+				return
+			case <-ticker.C:
+				postFunc(a.checkFile)
+			}
+		}
+	}()
+}
+
+// checkFile reloads the file of current tab if changed by another program since loaded or saved.
+func (a *App) checkFile() {
+	t := a.s.Tab
+	if t == nil || t.filename == "" || t.modTime.IsZero() || t.tailStop != nil {
+		return
+	}
+	info, err := os.Stat(t.filename)
+	if err != nil || info.ModTime().Equal(t.modTime) {
+		return
+	}
+	t.modTime = info.ModTime()
+	a.reloadFile()
+}
+
+// reloadFile replaces the text of current tab with its file as an undoable change.
+// If the tab has unsaved changes, the differences are shown to pick from instead.
+func (a *App) reloadFile() {
+	if a.s.filename == "" {
+		a.showMessage("No file to reload")
+		return
+	}
+	data, err := os.ReadFile(a.s.filename)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	a.s.statFile()
+	disk := splitLines(string(data))
+	buffer := a.s.lineStrings()
+	if slices.Equal(buffer, disk) {
+		a.s.markSaved()
+		return
+	}
+	if a.s.dirty() {
+		a.showMerge(a.s.Tab, buffer, disk)
+		return
+	}
+	row, col := a.s.row, a.s.col
+	a.replaceLines(0, buffer, disk)
+	a.s.markSaved()
+	row = min(row, a.s.lines.Len()-1)
+	a.jump(row, min(col, len(a.s.line(row).Value)))
+	a.draw()
+	a.showMessage("Reloaded " + filepath.Base(a.s.filename) + " changed on disk")
+}
+
+// mergeHunk is a difference between the buffer and the disk, with the side taken.
+type mergeHunk struct {
+	hunk
+	changed  string // "on disk", "in the buffer" or "in both" since the last save
+	takeDisk bool
+	row      int // row of the header in the merge view
+}
+
+// touchedLines returns whether each line of b is inserted since a, and whether lines
+// of a are deleted before each line of b, and the end.
+func touchedLines(a, b []string) (inserted, deletedAt []bool) {
+	inserted = make([]bool, len(b))
+	deletedAt = make([]bool, len(b)+1)
+	for _, op := range diff(a, b) {
+		switch op.kind {
+		case diffInsert:
+			inserted[op.j] = true
+		case diffDelete:
+			deletedAt[op.j] = true
+		}
+	}
+	return inserted, deletedAt
+}
+
+// touched reports whether the lines in [start, end) were changed.
+func touched(inserted, deletedAt []bool, start, end int) bool {
+	return slices.Contains(inserted[start:end], true) || slices.Contains(deletedAt[start:end+1], true)
+}
+
+// mergeHunks returns the differences of the buffer and the disk, telling by the saved text
+// which side changed each. A hunk changed only on disk takes the disk, others keep the buffer.
+func mergeHunks(saved []string, savedOK bool, buffer, disk []string) []mergeHunk {
+	var hunks []mergeHunk
+	var bufIns, bufDel, diskIns, diskDel []bool
+	if savedOK {
+		bufIns, bufDel = touchedLines(saved, buffer)
+		diskIns, diskDel = touchedLines(saved, disk)
+	}
+	for _, h := range diffHunks(diff(buffer, disk)) {
+		m := mergeHunk{hunk: h, changed: "in both"}
+		if savedOK {
+			inBuffer := touched(bufIns, bufDel, h.aStart, h.aEnd)
+			onDisk := touched(diskIns, diskDel, h.bStart, h.bEnd)
+			switch {
+			case !inBuffer:
+				m.changed = "on disk"
+				m.takeDisk = true
+			case !onDisk:
+				m.changed = "in the buffer"
+			}
+		}
+		hunks = append(hunks, m)
+	}
+	return hunks
+}
+
+// mergeView shows the hunks of a tab whose file changed on disk while edited.
+type mergeView struct {
+	tab          *Tab
+	buffer, disk []string
+	hunks        []mergeHunk
+}
+
+// render returns the text of the view and its styles by row, setting the header rows of the hunks.
+func (v *mergeView) render() (string, [][]ansiSpan) {
+	lines := []string{
+		fmt.Sprintf("%s changed on disk while edited. Tab switches the side of the hunk at the cursor, ctrl-s applies", filepath.Base(v.tab.filename)),
+		"",
+	}
+	styles := [][]ansiSpan{nil, nil}
+	add := func(line string, spans ...ansiSpan) {
+		lines = append(lines, line)
+		styles = append(styles, spans)
+	}
+	for i := range v.hunks {
+		h := &v.hunks[i]
+		h.row = len(lines)
+		side := "keep buffer"
+		if h.takeDisk {
+			side = "take disk"
+		}
+		header := fmt.Sprintf("@@ line %d, changed %s: %s", h.aStart+1, h.changed, side)
+		add(header, ansiSpan{0, len([]rune(header)), styleHunkHeader})
+		deleted, inserted := styleDeleted, styleInserted
+		if h.takeDisk {
+			deleted = styleDropped
+		} else {
+			inserted = styleDropped
+		}
+		// the words changed in a line changed as a whole
+		paired := h.aEnd-h.aStart == h.bEnd-h.bStart
+		for j := h.aStart; j < h.aEnd; j++ {
+			spans := []ansiSpan{{0, len([]rune(v.buffer[j])) + 2, deleted}}
+			if paired {
+				words, _ := wordChanges([]rune(v.buffer[j]), []rune(v.disk[h.bStart+j-h.aStart]))
+				spans = append(spans, wordSpans(words, deleted.Background(colorWordDelete))...)
+			}
+			add("- "+v.buffer[j], spans...)
+		}
+		for j := h.bStart; j < h.bEnd; j++ {
+			spans := []ansiSpan{{0, len([]rune(v.disk[j])) + 2, inserted}}
+			if paired {
+				_, words := wordChanges([]rune(v.buffer[h.aStart+j-h.bStart]), []rune(v.disk[j]))
+				spans = append(spans, wordSpans(words, inserted.Background(colorWordInsert))...)
+			}
+			add("+ "+v.disk[j], spans...)
+		}
+		add("")
+	}
+	return strings.Join(lines, "\n"), styles
+}
+
+// wordSpans returns the spans of the word ranges after the "- " or "+ " prefix.
+func wordSpans(ranges [][2]int, style tcell.Style) []ansiSpan {
+	var spans []ansiSpan
+	for _, r := range ranges {
+		spans = append(spans, ansiSpan{r[0] + 2, r[1] + 2, style})
+	}
+	return spans
+}
+
+// hunkAt returns the index of the hunk shown at the row, -1 if none.
+func (v *mergeView) hunkAt(row int) int {
+	for i := len(v.hunks) - 1; i >= 0; i-- {
+		if row >= v.hunks[i].row {
+			return i
+		}
+	}
+	return -1
+}
+
+// merged returns the lines of the buffer with the hunks taking the disk replaced.
+func (v *mergeView) merged() []string {
+	var lines []string
+	prev := 0
+	for _, h := range v.hunks {
+		lines = append(lines, v.buffer[prev:h.aStart]...)
+		if h.takeDisk {
+			lines = append(lines, v.disk[h.bStart:h.bEnd]...)
+		} else {
+			lines = append(lines, v.buffer[h.aStart:h.aEnd]...)
+		}
+		prev = h.aEnd
+	}
+	return append(lines, v.buffer[prev:]...)
+}
+
+// showMerge opens the view of the differences of the tab and its changed file,
+// saving the view applies the picked hunks to the tab.
+func (a *App) showMerge(tab *Tab, buffer, disk []string) {
+	saved, ok := tab.savedLines()
+	v := &mergeView{tab: tab, buffer: buffer, disk: disk}
+	v.hunks = mergeHunks(saved, ok, buffer, disk)
+	title := "reload " + filepath.Base(tab.filename)
+	if i := a.s.findBuffer(title); i >= 0 {
+		a.s.closeTab(i)
+	}
+	text, styles := v.render()
+	a.openBuffer(title, text)
+	a.s.ansi = styles
+	if len(v.hunks) > 0 {
+		a.jump(v.hunks[0].row, 0)
+	}
+	a.drawEditor()
+	a.showError(fmt.Sprintf("%s changed on disk, %d hunks to pick", filepath.Base(tab.filename), len(v.hunks)))
+	a.s.onKey = func(a *App, ev *tcell.EventKey) bool {
+		if ev.Key() != tcell.KeyTab {
+			return false
+		}
+		i := v.hunkAt(a.s.row)
+		if i < 0 {
+			return true
+		}
+		v.hunks[i].takeDisk = !v.hunks[i].takeDisk
+		text, styles := v.render()
+		a.s.setText(text)
+		a.s.ansi = styles
+		a.drawEditor()
+		return true
+	}
+	a.s.onSave = func(a *App, _ string) {
+		i := slices.Index(a.s.tabs, tab)
+		if i < 0 {
+			a.showError("The tab is closed")
+			return
+		}
+		if !slices.Equal(tab.lineStrings(), v.buffer) {
+			a.showError("The tab changed since, >reload to compare again")
+			return
+		}
+		a.s.closeTab(a.s.tabIdx)
+		a.s.switchTab(slices.Index(a.s.tabs, tab))
+		merged := v.merged()
+		row := a.s.row
+		a.replaceLines(0, v.buffer, merged)
+		if slices.Equal(merged, v.disk) {
+			a.s.markSaved()
+		}
+		a.jump(min(row, a.s.lines.Len()-1), 0)
+		a.draw()
+		a.showMessage("Applied the picked hunks")
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitLines(t *testing.T) {
+	for text, want := range map[string][]string{
+		"a\nb\n": {"a", "b", ""},
+		"a\r\nb": {"a", "b", ""},
+		"":       {""},
+	} {
+		if got := splitLines(text); !reflect.DeepEqual(got, want) {
+			t.Errorf("splitLines(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
+
+func TestMergeHunks(t *testing.T) {
+	saved := strings.Fields("a b c d e")
+	buffer := strings.Fields("a B c d e") // b edited in the buffer
+	disk := strings.Fields("a b c D e f") // d edited and f added on disk
+	hunks := mergeHunks(saved, true, buffer, disk)
+	var changed []string
+	var takeDisk []bool
+	for _, h := range hunks {
+		changed = append(changed, h.changed)
+		takeDisk = append(takeDisk, h.takeDisk)
+	}
+	if !reflect.DeepEqual(changed, []string{"in the buffer", "on disk", "on disk"}) ||
+		!reflect.DeepEqual(takeDisk, []bool{false, true, true}) {
+		t.Errorf("mergeHunks = %q, %v", changed, takeDisk)
+	}
+
+	v := &mergeView{buffer: buffer, disk: disk, hunks: hunks}
+	if got := strings.Join(v.merged(), " "); got != "a B c D e f" {
+		t.Errorf("merged = %q", got)
+	}
+
+	hunks = mergeHunks(nil, false, buffer, disk)
+	if len(hunks) != 3 || hunks[0].changed != "in both" || hunks[1].takeDisk {
+		t.Errorf("mergeHunks without saved text = %+v", hunks)
+	}
+}
+
+func TestSavedLines(t *testing.T) {
+	st := &State{Tab: &Tab{}}
+	st.loadSource(strings.NewReader("one\ntwo\n"))
+	st.markSaved()
+	st.insertText([]rune("new "), 1, 0)
+	st.recordChange(Change{row: 1, col: 0, newText: "new ", kind: editInsert})
+	lines, ok := st.savedLines()
+	if !ok || !reflect.DeepEqual(lines, []string{"one", "two", ""}) {
+		t.Errorf("savedLines = %q, %v", lines, ok)
+	}
+	if got := st.lineStrings(); !reflect.DeepEqual(got, []string{"one", "new two", ""}) {
+		t.Errorf("lines changed to %q", got)
+	}
+}
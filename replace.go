@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// parseReplaceArgs parses the arguments of >replace, the text to find and its replacement,
+// each a word or a Go string literal to have spaces. The replacement defaults to empty.
+func parseReplaceArgs(s string) (old, repl string, ok bool) {
+	var args []string
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		var arg string
+		if s[0] == '"' || s[0] == '`' {
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return "", "", false
+			}
+			arg, _ = strconv.Unquote(quoted)
+			s = s[len(quoted):]
+		} else {
+			arg, s, _ = strings.Cut(s, " ")
+		}
+		args = append(args, arg)
+	}
+	if len(args) == 0 || len(args) > 2 || args[0] == "" {
+		return "", "", false
+	}
+	if len(args) == 2 {
+		repl = args[1]
+	}
+	return args[0], repl, true
+}
+
+// findFrom returns the position of the next match of the keyword from the position,
+// wrapping around to the start.
+func (st *State) findFrom(row, col int, keyword []rune, caseSensitive bool) (int, int, bool) {
+	for n := 0; n <= st.lines.Len(); n++ {
+		line := st.line(row).Value
+		if col <= len(line) {
+			if i := indexRunes(line[col:], keyword, caseSensitive); i >= 0 {
+				return row, col + i, true
+			}
+		}
+		row, col = (row+1)%st.lines.Len(), 0
+	}
+	return 0, 0, false
+}
+
+// replaceAt replaces n runes of the line at the position with the text as a change.
+func (st *State) replaceAt(row, col, n int, text []rune) {
+	e := st.line(row)
+	line := e.Value
+	old := string(line[col : col+n])
+	e.Value = slices.Concat(line[:col], text, line[col+n:])
+	st.recordChange(Change{row: row, col: col, oldText: old, newText: string(text), kind: editReplace})
+}
+
+// replaceAll replaces every match of old with repl as one undoable change, and returns the number replaced.
+func (st *State) replaceAll(old, repl []rune) int {
+	caseSensitive := st.smartCase && slices.ContainsFunc(old, unicode.IsUpper)
+	st.beginGroup()
+	defer st.endGroup()
+	n := 0
+	row := 0
+	for e := st.lines.Front(); e != nil; e = e.Next() {
+		for col := 0; ; {
+			i := indexRunes(e.Value[col:], old, caseSensitive)
+			if i < 0 {
+				break
+			}
+			col += i
+			st.replaceAt(row, col, len(old), repl)
+			col += len(repl)
+			n++
+		}
+		row++
+	}
+	return n
+}
+
+// replace replaces the selected match of the text to find, or the next one from the cursor,
+// or every match if all is true. Matching follows smart case as the find of "#".
+func (a *App) replace(args string, all bool) {
+	a.s.focus = focusEditor
+	oldText, replText, ok := parseReplaceArgs(args)
+	if !ok {
+		a.showError("Usage: >replace old [new], quote them as Go strings to have spaces")
+		return
+	}
+	if !a.editable() {
+		return
+	}
+	old, repl := []rune(oldText), []rune(replText)
+	if all {
+		row := a.s.row
+		n := a.s.replaceAll(old, repl)
+		a.s.selection = nil
+		a.jump(row, min(a.s.col, len(a.s.line(row).Value)))
+		a.draw()
+		a.showMessage(fmt.Sprintf("Replaced %d matches", n))
+		return
+	}
+	caseSensitive := a.s.smartCase && slices.ContainsFunc(old, unicode.IsUpper)
+	row, col := a.s.row, a.s.col
+	if sel := a.s.selected(); sel != nil && sel.startRow == sel.endRow && sel.endCol-sel.startCol == len(old) {
+		// the match found by "#"
+		row, col = sel.startRow, sel.startCol
+	}
+	row, col, ok = a.s.findFrom(row, col, old, caseSensitive)
+	if !ok {
+		a.showMessage("No match found")
+		return
+	}
+	a.s.lastSearch = old
+	a.s.selection = nil
+	a.recordPositon(a.s.row, a.s.col)
+	a.s.replaceAt(row, col, len(old), repl)
+	a.jump(row, col+len(repl))
+	a.draw()
+}
+
+// parseSubstitute parses s/pattern/replacement/ of "#", a slash in either escaped as \/.
+func parseSubstitute(s string) (pattern, repl string, ok bool) {
+	if !strings.HasPrefix(s, "s/") || !strings.HasSuffix(s, "/") {
+		return "", "", false
+	}
+	var parts []string
+	var b strings.Builder
+	for i := 2; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && s[i+1] == '/':
+			b.WriteByte('/')
+			i++
+		case s[i] == '/':
+			parts = append(parts, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// substitute replaces every non-empty match of the regexp with the template expanded,
+// $1 or ${name} for the groups, as one undoable change, and returns the number replaced.
+func (st *State) substitute(re *regexp.Regexp, template string) int {
+	st.beginGroup()
+	defer st.endGroup()
+	n := 0
+	row := 0
+	for e := st.lines.Front(); e != nil; e = e.Next() {
+		line := string(e.Value)
+		matches := re.FindAllStringSubmatchIndex(line, -1)
+		// from the last so that the columns of the preceding stay valid
+		for i := len(matches) - 1; i >= 0; i-- {
+			m := matches[i]
+			if m[0] == m[1] {
+				continue
+			}
+			text := re.ExpandString(nil, template, line, m)
+			col := utf8.RuneCountInString(line[:m[0]])
+			st.replaceAt(row, col, utf8.RuneCountInString(line[m[0]:m[1]]), []rune(string(text)))
+			n++
+		}
+		row++
+	}
+	return n
+}
+
+// substituteAll runs #s/pattern/replacement/ on the tab, the pattern following smart case
+// as the find of "#".
+func (a *App) substituteAll(pattern, template string) {
+	a.s.focus = focusEditor
+	if !a.editable() {
+		return
+	}
+	keyword := []rune("/" + pattern + "/")
+	s, err := newSearcher(keyword, a.s.smartCase)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	a.s.lastSearch = keyword
+	row := a.s.row
+	n := a.s.substitute(s.re, template)
+	a.s.selection = nil
+	a.jump(row, min(a.s.col, len(a.s.line(row).Value)))
+	a.draw()
+	if n == 0 {
+		a.showMessage("No match found")
+		return
+	}
+	a.showMessage(fmt.Sprintf("Replaced %d matches", n))
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestParseReplaceArgs(t *testing.T) {
+	tests := []struct {
+		s         string
+		old, repl string
+		ok        bool
+	}{
+		{"foo bar", "foo", "bar", true},
+		{" foo  bar ", "foo", "bar", true},
+		{"foo", "foo", "", true},
+		{`"a b" "c d"`, "a b", "c d", true},
+		{"`a\\b` x", `a\b`, "x", true},
+		{`"tab\t" ""`, "tab\t", "", true},
+		{"", "", "", false},
+		{`""`, "", "", false},
+		{"a b c", "", "", false},
+		{`"open`, "", "", false},
+	}
+	for _, tt := range tests {
+		old, repl, ok := parseReplaceArgs(tt.s)
+		if ok != tt.ok || ok && (old != tt.old || repl != tt.repl) {
+			t.Errorf("parseReplaceArgs(%q) = %q, %q, %v, want %q, %q, %v", tt.s, old, repl, ok, tt.old, tt.repl, tt.ok)
+		}
+	}
+}
+
+func TestReplaceAll(t *testing.T) {
+	st := &State{Tab: &Tab{}, smartCase: true}
+	st.loadSource(strings.NewReader("foo Foo foofoo\nbar\nfoo"))
+	if n := st.replaceAll([]rune("foo"), []rune("x")); n != 5 {
+		t.Errorf("replaced %d, want 5", n)
+	}
+	if got, want := st.text(), "x x xx\nbar\nx\n"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+	if n := st.replaceAll([]rune("X"), []rune("y")); n != 0 {
+		t.Errorf("replaced %d of upper case, want 0", n)
+	}
+	st.undo()
+	if got, want := st.text(), "foo Foo foofoo\nbar\nfoo\n"; got != want {
+		t.Errorf("text after undo = %q, want %q", got, want)
+	}
+}
+
+func TestParseSubstitute(t *testing.T) {
+	tests := []struct {
+		s, pattern, repl string
+		ok               bool
+	}{
+		{`s/(\w+)_test/$1/`, `(\w+)_test`, "$1", true},
+		{`s/a\/b/c/`, "a/b", "c", true},
+		{`s/a//`, "a", "", true},
+		{`s//x/`, "", "", false},
+		{`s/a/b`, "", "", false},
+		{`s/a/b/c/`, "", "", false},
+		{`foo`, "", "", false},
+	}
+	for _, tt := range tests {
+		pattern, repl, ok := parseSubstitute(tt.s)
+		if pattern != tt.pattern || repl != tt.repl || ok != tt.ok {
+			t.Errorf("parseSubstitute(%q) = %q, %q, %v, want %q, %q, %v", tt.s, pattern, repl, ok, tt.pattern, tt.repl, tt.ok)
+		}
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	st := &State{Tab: &Tab{}}
+	st.loadSource(strings.NewReader("é foo_test, bar_test\nbaz"))
+	if n := st.substitute(regexp.MustCompile(`(\w+)_test`), "${1}Test"); n != 2 {
+		t.Errorf("replaced %d, want 2", n)
+	}
+	if got, want := st.text(), "é fooTest, barTest\nbaz\n"; got != want {
+		t.Errorf("text = %q, want %q", got, want)
+	}
+	st.undo()
+	if got, want := st.text(), "é foo_test, bar_test\nbaz\n"; got != want {
+		t.Errorf("text after undo = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// resultItem is a match listed in a results tab.
+type resultItem struct {
+	tab      *Tab   // the tab of the match, nil for a file not open
+	file     string // the absolute path of the file of the match, empty for a buffer
+	group    string // the tab or file the match is listed under
+	row, col int
+	text     string
+}
+
+// resultsText renders the items under the header, grouped by consecutive tab or file
+// with their count, a match per line. It returns the index of the item of each line, -1 if none.
+func resultsText(header string, items []resultItem) (string, []int) {
+	var b strings.Builder
+	b.WriteString(header)
+	rows := []int{-1}
+	for i := 0; i < len(items); {
+		j := i + 1
+		for j < len(items) && items[j].group == items[i].group {
+			j++
+		}
+		fmt.Fprintf(&b, "\n%s (%d)", items[i].group, j-i)
+		rows = append(rows, -1)
+		for ; i < j; i++ {
+			fmt.Fprintf(&b, "\n  %d:%d: %s", items[i].row+1, items[i].col+1, strings.TrimSpace(items[i].text))
+			rows = append(rows, i)
+		}
+	}
+	return b.String(), rows
+}
+
+// showResults lists the items in the tab of the title, replacing the one shown before,
+// enter on a match goes to it.
+func (a *App) showResults(title, header string, items []resultItem) {
+	text, rows := resultsText(header+", enter goes to the match, alt-enter in the adjacent pane", items)
+	if i := a.s.findBuffer(title); i >= 0 {
+		a.s.closeTab(i)
+	}
+	a.openBuffer(title, text)
+	a.jump(min(2, a.s.lines.Len()-1), 0)
+	a.drawEditor()
+	a.s.onKey = func(a *App, ev *tcell.EventKey) bool {
+		if ev.Key() != tcell.KeyEnter || a.s.row >= len(rows) || rows[a.s.row] < 0 {
+			return false
+		}
+		if ev.Modifiers()&tcell.ModAlt != 0 {
+			a.toSide()
+		}
+		a.goToResult(items, rows[a.s.row])
+		return true
+	}
+}
+
+// goToResult goes to the item i, in its tab if still open, otherwise in its file.
+// The matches in files become the quickfix list so that f4 goes on to the next.
+func (a *App) goToResult(items []resultItem, i int) {
+	item := items[i]
+	if j := slices.Index(a.s.tabs, item.tab); j >= 0 {
+		a.recordPositon(a.s.row, a.s.col)
+		a.s.switchTab(j)
+		row := min(item.row, a.s.lines.Len()-1)
+		a.jump(row, min(item.col, len(a.s.line(row).Value)))
+		a.draw()
+		return
+	}
+	if item.file == "" {
+		a.showError("The tab is closed")
+		return
+	}
+	a.s.quickfix = nil
+	a.s.quickfixIdx = -1
+	for j, item := range items {
+		if item.file == "" {
+			continue
+		}
+		if j == i {
+			a.s.quickfixIdx = len(a.s.quickfix) - 1
+		}
+		a.s.quickfix = append(a.s.quickfix, quickfixItem{file: item.file, row: item.row, col: item.col, text: item.text})
+	}
+	a.cmdCh <- ">nexterror"
+}
+
+// tabMatches returns the matches of the searcher in the tabs, other than results tabs.
+func tabMatches(tabs []*Tab, s *searcher) []resultItem {
+	var items []resultItem
+	wd, _ := os.Getwd()
+	for _, t := range tabs {
+		if t.readOnly && t.title == "matches" {
+			continue
+		}
+		group := t.name()
+		var file string
+		if t.filename != "" {
+			file, _ = filepath.Abs(t.filename)
+			if rel, err := filepath.Rel(wd, file); err == nil && !strings.HasPrefix(rel, "..") {
+				group = rel
+			}
+		}
+		row := 0
+		for e := t.lines.Front(); e != nil; e = e.Next() {
+			for col := 0; ; {
+				start, end := s.index(e.Value, col)
+				if start < 0 {
+					break
+				}
+				items = append(items, resultItem{tab: t, file: file, group: group, row: row, col: start, text: string(e.Value)})
+				col = end
+			}
+			row++
+		}
+	}
+	return items
+}
+
+// findInTabs lists the matches of the keyword, or the last search, in all open tabs.
+func (a *App) findInTabs(keyword []rune) {
+	a.s.focus = focusEditor
+	if len(keyword) == 0 {
+		keyword = a.s.lastSearch
+	}
+	if len(keyword) == 0 {
+		a.showMessage("Usage: >findtabs text")
+		return
+	}
+	s, err := newSearcher(keyword, a.s.smartCase)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	a.s.lastSearch = keyword
+	items := tabMatches(a.s.tabs, s)
+	if len(items) == 0 {
+		a.showMessage("No match found")
+		return
+	}
+	tabs := 0
+	for i, item := range items {
+		if i == 0 || item.tab != items[i-1].tab {
+			tabs++
+		}
+	}
+	a.showResults("matches", fmt.Sprintf("%d matches of %s in %d tabs", len(items), string(keyword), tabs), items)
+}
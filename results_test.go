@@ -0,0 +1,38 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestResultsText(t *testing.T) {
+	items := []resultItem{
+		{group: "main.go", row: 1, col: 4, text: "\tfoo()"},
+		{group: "main.go", row: 9, col: 0, text: "foo := 1"},
+		{group: "untitled", row: 0, col: 2, text: "a foo"},
+	}
+	text, rows := resultsText("3 matches", items)
+	want := "3 matches\nmain.go (2)\n  2:5: foo()\n  10:1: foo := 1\nuntitled (1)\n  1:3: a foo"
+	if text != want {
+		t.Errorf("text = %q, want %q", text, want)
+	}
+	if want := []int{-1, -1, 0, 1, -1, 2}; !slices.Equal(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+}
+
+func TestTabMatches(t *testing.T) {
+	a := &Tab{title: "a", lines: newLineBuffer()}
+	a.setText("foo bar\nbaz foo foo")
+	b := &Tab{title: "b", lines: newLineBuffer()}
+	b.setText("nothing")
+	s, _ := newSearcher([]rune("foo"), true)
+	items := tabMatches([]*Tab{a, b}, s)
+	var got [][2]int
+	for _, item := range items {
+		got = append(got, [2]int{item.row, item.col})
+	}
+	if want := [][2]int{{0, 0}, {1, 4}, {1, 8}}; !slices.Equal(got, want) {
+		t.Errorf("matches = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// tasksFile is the file of project tasks in the workspace root, with "name = command" lines
+// parsed by parseTasks.
+const tasksFile = ".tinotext-tasks"
+
+// projectTask is a shell command of the project run by ">task name".
+type projectTask struct {
+	name    string
+	command string
+}
+
+// defaultTasks are the tasks of Go modules without a tasks file.
+var defaultTasks = []projectTask{
+	{"build", "go build ./..."},
+	{"test", "go test ./..."},
+	{"lint", "go vet ./..."},
+	{"run", "go run ."},
+}
+
+// parseTasks parses the "name = command" lines, ignoring blank lines and comments starting with '#'.
+// The command is taken verbatim, or unquoted if it is a Go quoted string.
+func parseTasks(text string) ([]projectTask, error) {
+	var tasks []projectTask
+	for row, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, command, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: want name = command", tasksFile, row+1)
+		}
+		name, command = strings.TrimSpace(name), strings.TrimSpace(command)
+		if unquoted, err := strconv.Unquote(command); err == nil {
+			command = unquoted
+		}
+		tasks = append(tasks, projectTask{name, command})
+	}
+	return tasks, nil
+}
+
+// loadTasks returns the tasks of the tasks file, or the default tasks in a Go module.
+func loadTasks() ([]projectTask, error) {
+	data, err := os.ReadFile(tasksFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		if _, err := os.Stat("go.mod"); err == nil {
+			return defaultTasks, nil
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseTasks(string(data))
+}
+
+// quickfixItem is a location reported by a task, such as a compile error.
+type quickfixItem struct {
+	file string
+	row  int
+	col  int
+	text string
+}
+
+// quickfixLine matches "file:line:col: message" or "file:line: message".
+var quickfixLine = regexp.MustCompile(`^([^\s:]+\.\w+):(\d+)(?::(\d+))?: (.*)$`)
+
+// parseQuickfix returns the locations in the output, relative paths are joined to the dir.
+func parseQuickfix(output, dir string) []quickfixItem {
+	var items []quickfixItem
+	for _, line := range strings.Split(output, "\n") {
+		m := quickfixLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		row, _ := strconv.Atoi(m[2])
+		col, _ := strconv.Atoi(m[3])
+		file := m[1]
+		if !filepath.IsAbs(file) {
+			file = filepath.Join(dir, file)
+		}
+		items = append(items, quickfixItem{file: filepath.Clean(file), row: row - 1, col: max(col-1, 0), text: m[4]})
+	}
+	return items
+}
+
+// runTask runs the named task in the background, its output goes to the "task" tab
+// and the reported locations to the quickfix list. Without a name it lists the tasks.
+func (a *App) runTask(name string) {
+	tasks, err := loadTasks()
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	if name == "" {
+		var b strings.Builder
+		w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+		for _, t := range tasks {
+			fmt.Fprintf(w, "%s\t%s\n", t.name, t.command)
+		}
+		w.Flush()
+		a.openBuffer("tasks", b.String())
+		return
+	}
+	i := slices.IndexFunc(tasks, func(t projectTask) bool { return t.name == name })
+	if i < 0 {
+		a.showError(fmt.Sprintf("No task %s in %s", name, tasksFile))
+		return
+	}
+	a.runCommand(name, tasks[i].command)
+}
+
+// runCommand runs the shell command as a task in the background, see runTask.
+func (a *App) runCommand(name, command string) {
+	t := a.s.tasks.start(name, 0)
+	a.showMessage("Running " + command)
+	go func() {
+		defer t.finish()
+		cmd := exec.CommandContext(t.ctx, "sh", "-c", command)
+		out, err := cmd.CombinedOutput()
+		canceled := t.ctx.Err() != nil
+		dir, _ := os.Getwd()
+		postFunc(func() {
+			output := fmt.Sprintf("$ %s\n%s", command, out)
+			a.s.quickfix = parseQuickfix(string(out), dir)
+			a.s.quickfixIdx = -1
+			a.drawEditor()
+			switch {
+			case canceled:
+				a.updateBuffer("task", output+"canceled\n", false)
+				a.showMessage("Task " + name + " canceled")
+			case err != nil:
+				a.updateBuffer("task", output+err.Error()+"\n", true)
+				a.showError(fmt.Sprintf("Task %s failed, %d locations, f4 to go to the next", name, len(a.s.quickfix)))
+			default:
+				a.updateBuffer("task", output, false)
+				a.showMessage(fmt.Sprintf("Task %s done, %d locations", name, len(a.s.quickfix)))
+			}
+		})
+	}()
+}
+
+// quickfixKey returns the command of f4 and shift-f4 going through the quickfix list.
+func quickfixKey(ev *tcell.EventKey) (string, bool) {
+	if ev.Key() != tcell.KeyF4 {
+		return "", false
+	}
+	if ev.Modifiers()&tcell.ModShift != 0 {
+		return ">preverror", true
+	}
+	return ">nexterror", true
+}
+
+// jumpQuickfix goes to the next location of the quickfix list, or the previous if dir is -1.
+// It runs in the command loop since it may open a file.
+func (a *App) jumpQuickfix(dir int) {
+	if len(a.s.quickfix) == 0 {
+		a.showMessage("No location, run a task first")
+		return
+	}
+	a.s.quickfixIdx = (a.s.quickfixIdx + dir + len(a.s.quickfix)) % len(a.s.quickfix)
+	item := a.s.quickfix[a.s.quickfixIdx]
+	a.recordPositon(a.s.row, a.s.col)
+	if !a.switchToFile(item.file) {
+		return
+	}
+	a.s.focus = focusEditor
+	row := min(item.row, a.s.lines.Len()-1)
+	a.jump(row, min(item.col, len(a.s.line(row).Value)))
+	a.drawEditor()
+	a.showError(fmt.Sprintf("[%d/%d] %s", a.s.quickfixIdx+1, len(a.s.quickfix), item.text))
+	a.syncCursor()
+}
+
+// switchToFile switches to the tab of the file at the absolute path, opening it if needed.
+// It reports false if the file cannot be opened.
+func (a *App) switchToFile(path string) bool {
+	if a.s.isFile(path) {
+		return true
+	}
+	if i := a.s.findFile(path); i >= 0 {
+		a.s.switchTab(i)
+	} else {
+		a.handleCommand(">open " + path)
+		if !a.s.isFile(path) {
+			return false
+		}
+	}
+	a.draw()
+	return true
+}
+
+var styleQuickfixGutter = styleBase.Foreground(tcell.ColorOrange).Bold(true)
+
+// quickfixGutter marks the rows of current tab in the quickfix list.
+func quickfixGutter(st *State, row int) (gutterMarker, bool) {
+	if len(st.quickfix) == 0 || st.filename == "" {
+		return gutterMarker{}, false
+	}
+	for _, item := range st.quickfix {
+		if item.row == row && st.isFile(item.file) {
+			return gutterMarker{char: '!', style: styleQuickfixGutter}, true
+		}
+	}
+	return gutterMarker{}, false
+}
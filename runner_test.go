@@ -0,0 +1,38 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseQuickfix(t *testing.T) {
+	output := `# tino
+./main.go:12:5: undefined: x
+--- FAIL: TestFoo (0.00s)
+    foo_test.go:8: got 1, want 2
+/abs/bar.go:3: syntax error
+FAIL
+`
+	want := []quickfixItem{
+		{file: "/w/main.go", row: 11, col: 4, text: "undefined: x"},
+		{file: "/w/foo_test.go", row: 7, col: 0, text: "got 1, want 2"},
+		{file: "/abs/bar.go", row: 2, col: 0, text: "syntax error"},
+	}
+	if got := parseQuickfix(output, "/w"); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseQuickfix() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseTasks(t *testing.T) {
+	tasks, err := parseTasks("# tasks\nbuild = go build ./...\n\nlint = \"golangci-lint run\"\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []projectTask{{"build", "go build ./..."}, {"lint", "golangci-lint run"}}
+	if !reflect.DeepEqual(tasks, want) {
+		t.Errorf("parseTasks() = %+v, want %+v", tasks, want)
+	}
+	if _, err := parseTasks("build"); err == nil {
+		t.Error("parseTasks(\"build\") want error")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"unicode"
+	"unicode/utf8"
+)
+
+// searcher finds the keyword of "#" in lines: a plain text, or a regexp written as /pattern/.
+type searcher struct {
+	text          []rune
+	caseSensitive bool
+	re            *regexp.Regexp
+}
+
+// newSearcher returns the searcher of the keyword, case sensitive only with smart case on
+// and upper case letters in the keyword, not counting escapes like \W in a regexp.
+// It fails if the regexp is invalid.
+func newSearcher(keyword []rune, smartCase bool) (*searcher, error) {
+	pattern, ok := regexpKeyword(keyword)
+	if !ok {
+		caseSensitive := smartCase && slices.ContainsFunc(keyword, unicode.IsUpper)
+		return &searcher{text: keyword, caseSensitive: caseSensitive}, nil
+	}
+	if !smartCase || !hasUpper(pattern) {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &searcher{re: re}, nil
+}
+
+// regexpKeyword returns the pattern of a keyword written as /pattern/.
+func regexpKeyword(keyword []rune) (string, bool) {
+	if len(keyword) <= 2 || keyword[0] != '/' || keyword[len(keyword)-1] != '/' {
+		return "", false
+	}
+	return string(keyword[1 : len(keyword)-1]), true
+}
+
+// hasUpper reports whether the pattern has upper case letters other than escapes.
+func hasUpper(pattern string) bool {
+	escaped := false
+	for _, r := range pattern {
+		if !escaped && unicode.IsUpper(r) {
+			return true
+		}
+		escaped = !escaped && r == '\\'
+	}
+	return false
+}
+
+// index returns the start and end of the first match in the line at or after col,
+// or -1, -1 if none. Anchors of a regexp match the start and end of the line,
+// and its empty matches are skipped.
+func (s *searcher) index(line []rune, col int) (int, int) {
+	if col > len(line) {
+		return -1, -1
+	}
+	if s.re == nil {
+		if len(s.text) == 0 {
+			return -1, -1
+		}
+		i := indexRunes(line[col:], s.text, s.caseSensitive)
+		if i < 0 {
+			return -1, -1
+		}
+		return col + i, col + i + len(s.text)
+	}
+	str := string(line)
+	// byte offset of col
+	off := len(string(line[:col]))
+	for _, m := range s.re.FindAllStringIndex(str, -1) {
+		if m[0] >= off && m[1] > m[0] {
+			start := col + utf8.RuneCountInString(str[off:m[0]])
+			return start, start + utf8.RuneCountInString(str[m[0]:m[1]])
+		}
+	}
+	return -1, -1
+}
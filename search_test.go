@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestSearcherIndex(t *testing.T) {
+	tests := []struct {
+		keyword    string
+		line       string
+		col        int
+		start, end int
+	}{
+		{"foo", "a foo", 0, 2, 5},
+		{"Foo", "a foo Foo", 0, 6, 9},
+		{"//", "x // y", 0, 2, 4},
+		{"/f.o/", "a fxo", 0, 2, 5},
+		{"/^func \\w+/", "func main() {", 0, 0, 9},
+		{"/^func/", "xfunc", 0, -1, -1},
+		{"/^a/", "aa", 1, -1, -1},
+		{"/a$/", "aba", 0, 2, 3},
+		{"/[0-9]+/", "é 12", 0, 2, 4},
+		{"/[0-9]+/", "1 é 23", 1, 4, 6},
+		{"/x*/", "ab x", 0, 3, 4},
+		{"/abc/", "ABC", 0, 0, 3},
+		{"/A\\w/", "ab Ab", 0, 3, 5},
+	}
+	for _, tt := range tests {
+		s, err := newSearcher([]rune(tt.keyword), true)
+		if err != nil {
+			t.Fatalf("newSearcher(%q): %v", tt.keyword, err)
+		}
+		start, end := s.index([]rune(tt.line), tt.col)
+		if start != tt.start || end != tt.end {
+			t.Errorf("%q in %q from %d = %d, %d, want %d, %d", tt.keyword, tt.line, tt.col, start, end, tt.start, tt.end)
+		}
+	}
+	if _, err := newSearcher([]rune("/a(/"), true); err == nil {
+		t.Error("want error for invalid regexp")
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+// selectionRange returns a copy of the selection of the tab, nil if nothing is selected.
+func (t *Tab) selectionRange() *Selection {
+	if t.selection == nil || (t.selection.startRow == t.selection.endRow && t.selection.startCol == t.selection.endCol) {
+		return nil
+	}
+	sel := *t.selection
+	return &sel
+}
+
+// trackSelection runs before each event. It keeps the selection of the tab as the last one
+// when it is dropped or replaced by another, extending it does not count, and the selection
+// when the console takes the focus, for restoring them.
+func (a *App) trackSelection() {
+	sel := a.s.selectionRange()
+	if a.seenTab == a.s.Tab && a.seenSelection != nil &&
+		(sel == nil || sel.startRow != a.seenSelection.startRow || sel.startCol != a.seenSelection.startCol) {
+		a.s.lastSelect = a.seenSelection
+	}
+	a.seenTab, a.seenSelection = a.s.Tab, sel
+
+	if a.s.focus == focusConsole && a.seenFocus != focusConsole {
+		a.consoleSelection, a.consoleTab = sel, a.s.Tab
+	}
+	a.seenFocus = a.s.focus
+}
+
+// restoreConsoleSelection restores the selection the editor had when the console took
+// the focus, if the console left the tab without selection.
+func (a *App) restoreConsoleSelection() {
+	sel := a.consoleSelection
+	a.consoleSelection = nil
+	if sel == nil || a.consoleTab != a.s.Tab || a.s.selectionRange() != nil || !a.s.validSelection(*sel) {
+		return
+	}
+	a.s.selection = sel
+	a.drawEditor()
+}
+
+// validSelection reports whether the rows and columns of the selection are in the text.
+func (st *State) validSelection(sel Selection) bool {
+	for _, p := range [][2]int{{sel.startRow, sel.startCol}, {sel.endRow, sel.endCol}} {
+		if p[0] < 0 || p[0] >= st.lines.Len() || p[1] < 0 || p[1] > len(st.line(p[0]).Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// reselect selects again the last selection of the tab dropped or replaced, which becomes
+// the last one in turn, and moves the cursor to its end.
+func (a *App) reselect() {
+	a.s.focus = focusEditor
+	last := a.s.lastSelect
+	if last == nil {
+		a.showMessage("No selection to restore")
+		return
+	}
+	if !a.s.validSelection(*last) {
+		a.s.lastSelect = nil
+		a.showMessage("The last selection is out of the text")
+		return
+	}
+	a.s.lastSelect = a.s.selectionRange()
+	a.s.cursors = nil
+	a.jump(last.endRow, last.endCol)
+	a.s.selection = last
+	a.drawEditor()
+}
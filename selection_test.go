@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrackSelection(t *testing.T) {
+	tab := &Tab{}
+	a := &App{s: &State{Tab: tab, tabs: []*Tab{tab}}}
+	a.s.loadSource(strings.NewReader("hello world\nfoo bar"))
+
+	a.s.selection = &Selection{0, 0, 0, 2}
+	a.trackSelection()
+	a.s.selection = &Selection{0, 0, 0, 5} // extended
+	a.trackSelection()
+	if a.s.lastSelect != nil {
+		t.Errorf("last selection = %+v after extending, want none", a.s.lastSelect)
+	}
+
+	a.s.focus = focusConsole
+	a.s.selection = &Selection{1, 4, 1, 7} // replaced, like by a search
+	a.trackSelection()
+	if want := (Selection{0, 0, 0, 5}); a.s.lastSelect == nil || *a.s.lastSelect != want {
+		t.Errorf("last selection = %+v, want %+v", a.s.lastSelect, want)
+	}
+	if want := (Selection{1, 4, 1, 7}); a.consoleSelection == nil || *a.consoleSelection != want {
+		t.Errorf("console selection = %+v, want %+v", a.consoleSelection, want)
+	}
+
+	a.s.selection = nil
+	a.trackSelection()
+	if want := (Selection{1, 4, 1, 7}); *a.s.lastSelect != want {
+		t.Errorf("last selection = %+v after dropping, want %+v", a.s.lastSelect, want)
+	}
+}
+
+func TestValidSelection(t *testing.T) {
+	st := &State{Tab: &Tab{}}
+	st.loadSource(strings.NewReader("hello\nfoo"))
+	for _, tt := range []struct {
+		sel  Selection
+		want bool
+	}{
+		{Selection{0, 0, 1, 3}, true},
+		{Selection{1, 3, 0, 5}, true},
+		{Selection{0, 0, 0, 6}, false},
+		{Selection{0, 0, 3, 0}, false},
+		{Selection{-1, 0, 0, 1}, false},
+	} {
+		if got := st.validSelection(tt.sel); got != tt.want {
+			t.Errorf("validSelection(%+v) = %v, want %v", tt.sel, got, tt.want)
+		}
+	}
+}
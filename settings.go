@@ -0,0 +1,468 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// setting is an option of the editor, listed in the settings tab.
+type setting struct {
+	name string
+	desc string
+	get  func(st *State) string
+	// set validates and applies the value, the screen is refreshed afterwards
+	set func(st *State, value string) error
+}
+
+// parseBool accepts true/false and on/off.
+func parseBool(value string) (bool, error) {
+	switch value {
+	case "on":
+		return true, nil
+	case "off":
+		return false, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, errors.New("want true or false")
+	}
+	return b, nil
+}
+
+// boolSetting returns the setting of the boolean field.
+func boolSetting(name, desc string, field func(st *State) *bool) setting {
+	return setting{
+		name: name,
+		desc: desc,
+		get:  func(st *State) string { return strconv.FormatBool(*field(st)) },
+		set: func(st *State, value string) error {
+			b, err := parseBool(value)
+			if err != nil {
+				return err
+			}
+			*field(st) = b
+			return nil
+		},
+	}
+}
+
+// widthSetting returns the setting of the character width variable, 0 means default.
+func widthSetting(name, desc string, width *int) setting {
+	return setting{
+		name: name,
+		desc: desc,
+		get:  func(*State) string { return strconv.Itoa(*width) },
+		set: func(_ *State, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 || n > 2 {
+				return errors.New("want 1, 2 or 0 for default")
+			}
+			*width = n
+			return nil
+		},
+	}
+}
+
+var settings = []setting{
+	boolSetting("linenumber", "show line numbers", func(st *State) *bool { return &st.lineNumber }),
+	boolSetting("mouse", "handle mouse events, turn off to select text with the terminal", func(st *State) *bool { return &st.mouse }),
+	boolSetting("smartcase", "search case sensitively only if the keyword has upper case letters", func(st *State) *bool { return &st.smartCase }),
+	boolSetting("virtualspace", "move the cursor beyond line ends", func(st *State) *bool { return &st.virtualSpace }),
+	boolSetting("overwrite", "typed characters replace the character under the cursor", func(st *State) *bool { return &st.overwrite }),
+	{
+		name: "vim",
+		desc: "vim-style modal editing",
+		get:  func(st *State) string { return strconv.FormatBool(st.vim) },
+		set: func(st *State, value string) error {
+			b, err := parseBool(value)
+			if err != nil {
+				return err
+			}
+			if b != st.vim {
+				st.vim = b
+				st.vimMode = vimNormal
+			}
+			return nil
+		},
+	},
+	{
+		name: "indent",
+		desc: `indent unit inserted by Tab, "tab" or the number of spaces`,
+		get: func(st *State) string {
+			if st.indent == "\t" {
+				return "tab"
+			}
+			return strconv.Itoa(len(st.indent))
+		},
+		set: func(st *State, value string) error {
+			if value == "tab" {
+				st.indent = "\t"
+				return nil
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 8 {
+				return errors.New(`want "tab" or 1 to 8 spaces`)
+			}
+			st.indent = strings.Repeat(" ", n)
+			return nil
+		},
+	},
+	boolSetting("formatonsave", "format Go source on save", func(st *State) *bool { return &st.formatOnSave }),
+	{
+		name: "license",
+		desc: "file of the license header put at the top of new Go files, empty for none",
+		get:  func(st *State) string { return strconv.Quote(st.licenseFile) },
+		set: func(st *State, value string) error {
+			st.licenseFile = value
+			return nil
+		},
+	},
+	{
+		name: "lockfiles",
+		desc: `hold a ".#name" lock file next to each open file, warning when another editor holds it`,
+		get:  func(st *State) string { return strconv.FormatBool(st.lockFiles) },
+		set: func(st *State, value string) error {
+			b, err := parseBool(value)
+			if err != nil {
+				return err
+			}
+			st.lockFiles = b
+			for _, t := range st.tabs {
+				if b {
+					t.lock()
+				} else {
+					t.unlock()
+				}
+			}
+			return nil
+		},
+	},
+	boolSetting("ansicolors", "show the ANSI colors of .log files and generated tabs instead of the escape sequences", func(st *State) *bool { return &st.ansiColors }),
+	boolSetting("inlayhints", "show parameter names before the arguments of calls in Go files", func(st *State) *bool { return &st.inlayHints }),
+	boolSetting("autopair", "insert the closing bracket or quote along the opening one", func(st *State) *bool { return &st.autoPair }),
+	{
+		name: "exclude",
+		desc: "directories not listed by quick open, besides hidden ones",
+		get: func(st *State) string {
+			quoted := make([]string, len(st.excludeDirs))
+			for i, dir := range st.excludeDirs {
+				quoted[i] = strconv.Quote(dir)
+			}
+			return "[" + strings.Join(quoted, ", ") + "]"
+		},
+		set: func(st *State, value string) error {
+			value = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(value, "["), "]"))
+			var dirs []string
+			for _, dir := range strings.Split(value, ",") {
+				if dir = strings.Trim(strings.TrimSpace(dir), `"'`); dir != "" {
+					dirs = append(dirs, dir)
+				}
+			}
+			st.excludeDirs = dirs
+			return nil
+		},
+	},
+	{
+		name: "tabsize",
+		desc: "columns of a tab stop",
+		get:  func(*State) string { return strconv.Itoa(tabSize) },
+		set: func(_ *State, value string) error {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 16 {
+				return errors.New("want 1 to 16")
+			}
+			tabSize = n
+			return nil
+		},
+	},
+	{
+		name: "scrollfactor",
+		desc: "speed of scrolling by the mouse wheel",
+		get:  func(*State) string { return strconv.FormatFloat(float64(scrollFactor), 'g', -1, 32) },
+		set: func(_ *State, value string) error {
+			f, err := strconv.ParseFloat(value, 32)
+			if err != nil || f <= 0 || f > 10 {
+				return errors.New("want a number above 0 up to 10")
+			}
+			scrollFactor = float32(f)
+			return nil
+		},
+	},
+	{
+		name: "theme",
+		desc: "color theme, light or dark, defaults to the background of the terminal; [colors] apply over it",
+		get:  func(*State) string { return themeName },
+		set:  func(_ *State, value string) error { return applyTheme(value) },
+	},
+	widthSetting("ambiwidth", "width of East Asian ambiguous characters", &ambiguousWidth),
+	widthSetting("emojiwidth", "width of emoji", &emojiWidth),
+}
+
+// colorStyle is a style whose color is set by the [colors] section of the settings.
+type colorStyle struct {
+	name  string
+	style *tcell.Style
+}
+
+var colorStyles = []colorStyle{
+	{"keyword", &styleKeyword},
+	{"string", &styleString},
+	{"comment", &styleComment},
+	{"number", &styleNumber},
+}
+
+// setColor sets the foreground of the style of the name to the color, a name like "navy"
+// or a hex like "#8700af".
+func setColor(name, value string) error {
+	i := slices.IndexFunc(colorStyles, func(c colorStyle) bool { return c.name == name })
+	if i < 0 {
+		return errors.New("unknown color " + name)
+	}
+	color := tcell.GetColor(value)
+	if color == tcell.ColorDefault && value != "default" {
+		return fmt.Errorf("%s: want a color name or #rrggbb", name)
+	}
+	*colorStyles[i].style = colorStyles[i].style.Foreground(color)
+	return nil
+}
+
+// setWordChars sets the characters besides letters and digits that form a word in the files
+// of the type of the name, like "go" or "text" for unregistered files.
+func setWordChars(name, value string) error {
+	for _, ft := range append(fileTypes, plainText) {
+		if ft.name == name {
+			ft.wordChars = value
+			return nil
+		}
+	}
+	return errors.New("unknown file type " + name)
+}
+
+// colorName returns the name of the color as accepted by setColor.
+func colorName(color tcell.Color) string {
+	if color == tcell.ColorDefault {
+		return "default"
+	}
+	if name := color.Name(); name != "" {
+		return name
+	}
+	return fmt.Sprintf("#%06x", color.Hex())
+}
+
+// bindKey binds the key sequence to the console command, like the chords, or unbinds it
+// if the command is empty. A sequence may not start another bound one.
+func bindKey(keys, cmd string) error {
+	keys = strings.Join(strings.Fields(keys), " ")
+	if keys == "" {
+		return errors.New("want the keys")
+	}
+	if cmd == "" {
+		delete(chords, keys)
+		return nil
+	}
+	if len(cmd) < 2 || !strings.ContainsRune("#:>='@", rune(cmd[0])) {
+		return fmt.Errorf("%s: want a console command like >save", keys)
+	}
+	for seq := range chords {
+		if seq != keys && (strings.HasPrefix(seq, keys+" ") || strings.HasPrefix(keys, seq+" ")) {
+			return fmt.Errorf("%s: conflicts with %s", keys, seq)
+		}
+	}
+	chords[keys] = cmd
+	return nil
+}
+
+// settingsText returns the current settings as "name = value" lines,
+// then the colors, the word characters and the key bindings in their sections.
+func (st *State) settingsText() string {
+	var b strings.Builder
+	b.WriteString("# Edit the values and save to apply.\n")
+	for _, s := range settings {
+		fmt.Fprintf(&b, "\n# %s\n%s = %s\n", s.desc, s.name, s.get(st))
+	}
+	b.WriteString("\n# colors of the syntax, names like navy or hex like #8700af\n[colors]\n")
+	for _, c := range colorStyles {
+		fg, _, _ := c.style.Decompose()
+		if fg == themes[themeName].syntax[c.name] {
+			// the color of the theme, which follows it
+			b.WriteString("# ")
+		}
+		fmt.Fprintf(&b, "%s = %q\n", c.name, colorName(fg))
+	}
+	b.WriteString("\n# characters besides letters and digits that form a word, by file type\n[wordchars]\n")
+	for _, ft := range append(fileTypes, plainText) {
+		fmt.Fprintf(&b, "%s = %q\n", ft.name, ft.wordChars)
+	}
+	b.WriteString("\n# key sequences bound to console commands, an empty command unbinds\n[keys]\n")
+	keys := slices.Sorted(maps.Keys(chords))
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%q = %q\n", k, chords[k])
+	}
+	return b.String()
+}
+
+// applySettings applies the "name = value" lines of the text, ignoring blank lines and
+// comments starting with '#'. Lines after "[colors]" set colors, those after "[wordchars]"
+// the word characters of file types and those after "[keys]" bind keys, see setColor,
+// setWordChars and bindKey. It returns the errors of invalid lines by row.
+func (st *State) applySettings(text string) map[int]string {
+	errs := make(map[int]string)
+	section := ""
+	for row, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section != "colors" && section != "wordchars" && section != "keys" {
+				errs[row] = "unknown section " + section
+			}
+			continue
+		}
+		// a quoted name may have "=", like the key "alt-="
+		var name, value string
+		ok := false
+		if strings.HasPrefix(line, `"`) {
+			if quoted, err := strconv.QuotedPrefix(line); err == nil {
+				name, _ = strconv.Unquote(quoted)
+				value, ok = strings.CutPrefix(strings.TrimSpace(line[len(quoted):]), "=")
+			}
+		} else {
+			name, value, ok = strings.Cut(line, "=")
+		}
+		if !ok {
+			errs[row] = "want name = value"
+			continue
+		}
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		}
+		if section != "" {
+			var err error
+			switch section {
+			case "colors":
+				err = setColor(name, value)
+			case "wordchars":
+				err = setWordChars(name, value)
+			case "keys":
+				err = bindKey(name, value)
+			default:
+				continue // in the unknown section reported
+			}
+			if err != nil {
+				errs[row] = err.Error()
+			}
+			continue
+		}
+		i := slices.IndexFunc(settings, func(s setting) bool { return s.name == name })
+		if i < 0 {
+			errs[row] = "unknown setting " + name
+			continue
+		}
+		if err := settings[i].set(st, value); err != nil {
+			errs[row] = name + ": " + err.Error()
+		}
+	}
+	return errs
+}
+
+// firstError returns the first row of the errors.
+func firstError(errs map[int]string) int {
+	rows := make([]int, 0, len(errs))
+	for row := range errs {
+		rows = append(rows, row)
+	}
+	return slices.Min(rows)
+}
+
+// projectConfig is the file of per-project settings in the workspace root,
+// it has "name = value" lines like the settings tab, a subset of TOML.
+const projectConfig = ".tinotext.toml"
+
+// userConfigPath returns the path of the settings of the user, applied before the
+// project config, like ~/.config/tinotext/config.
+func userConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "tinotext", "config")
+}
+
+// loadConfig applies the settings of the config file if it exists,
+// returning the first error of invalid lines.
+func (st *State) loadConfig(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if errs := st.applySettings(string(data)); len(errs) > 0 {
+		row := firstError(errs)
+		return fmt.Errorf("%s:%d: %s", path, row+1, errs[row])
+	}
+	return nil
+}
+
+// refreshSettings makes the screen reflect the settings.
+func (a *App) refreshSettings() {
+	updateWidthCondition()
+	if a.s.mouse {
+		screen.EnableMouse()
+	} else {
+		screen.DisableMouse()
+	}
+	a.updateCursorStyle()
+	// the styles of the views follow the theme
+	screen.SetStyle(styleBase)
+	a.resize()
+	a.jump(a.s.row, a.s.col)
+	a.draw()
+	screen.Sync()
+}
+
+// openSettings opens a tab of the current settings, saving it applies the settings.
+func (a *App) openSettings() {
+	for i, t := range a.s.tabs {
+		if t.onSave != nil && t.title == "settings" {
+			a.s.closeTab(i)
+			break
+		}
+	}
+	tab := &Tab{title: "settings", lines: newLineBuffer(), scratch: true}
+	tab.onSave = func(a *App, text string) {
+		errs := a.s.applySettings(text)
+		a.s.lineErrors = errs
+		a.refreshSettings()
+		if len(errs) == 0 {
+			a.showMessage("Settings applied")
+			return
+		}
+		row := firstError(errs)
+		a.showError(fmt.Sprintf("Line %d: %s", row+1, errs[row]))
+	}
+	a.s.tabs = append(a.s.tabs, tab)
+	a.s.switchTab(len(a.s.tabs) - 1)
+	a.s.focus = focusEditor
+	if err := a.s.loadSource(strings.NewReader(a.s.settingsText())); err != nil {
+		a.showError(err.Error())
+		return
+	}
+	a.draw()
+}
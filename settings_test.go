@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestApplySettingsSections(t *testing.T) {
+	defer func(c map[string]string, s tcell.Style, n int) {
+		chords, styleComment, tabSize = c, s, n
+	}(chords, styleComment, tabSize)
+	chords = map[string]string{"ctrl-k ctrl-l": ">linenumber", "ctrl-d": ">selectnext"}
+
+	st := &State{}
+	errs := st.applySettings(strings.Join([]string{
+		"tabsize = 8",
+		"[colors]",
+		`comment = "#00ff00"`,
+		"nothing = red",
+		"[keys]",
+		`"ctrl-k ctrl-q" = ">quit"`,
+		`"alt-=" = ">resizepane +2"`,
+		`"ctrl-d" = ""`,
+		`"ctrl-k" = ">save"`,
+		`f2 = "save"`,
+		"[other]",
+	}, "\n"))
+	if tabSize != 8 {
+		t.Errorf("tabSize = %d, want 8", tabSize)
+	}
+	if fg, _, _ := styleComment.Decompose(); fg != tcell.GetColor("#00ff00") {
+		t.Errorf("comment color = %v, want #00ff00", fg)
+	}
+	if chords["ctrl-k ctrl-q"] != ">quit" || chords["alt-="] != ">resizepane +2" {
+		t.Errorf("chords = %v, want ctrl-k ctrl-q and alt-= bound", chords)
+	}
+	if _, ok := chords["ctrl-d"]; ok {
+		t.Error("ctrl-d still bound")
+	}
+	for _, row := range []int{3, 8, 9, 10} {
+		if errs[row] == "" {
+			t.Errorf("no error on line %d", row+1)
+		}
+	}
+	if len(errs) != 4 {
+		t.Errorf("errors = %v, want 4", errs)
+	}
+}
+
+func TestSettingsTextApplies(t *testing.T) {
+	st := &State{indent: "\t"}
+	if errs := st.applySettings(st.settingsText()); len(errs) > 0 {
+		t.Errorf("errors applying the settings text: %v", errs)
+	}
+}
+
+func TestApplySettingsWordChars(t *testing.T) {
+	ft := fileTypeOf("x.go")
+	defer func(chars string) { ft.wordChars = chars }(ft.wordChars)
+
+	st := &State{}
+	errs := st.applySettings("[wordchars]\ngo = \"_$\"\nnothing = \"-\"")
+	if !ft.isWordChar('$') {
+		t.Error("$ is not a word character of Go after setting it")
+	}
+	if len(errs) != 1 || errs[2] == "" {
+		t.Errorf("errors = %v, want one on line 3", errs)
+	}
+}
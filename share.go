@@ -0,0 +1,486 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultShareAddr is where >share listens if no address is given.
+const defaultShareAddr = "127.0.0.1:7878"
+
+// charOp is an edit of a single rune at an offset in runes of the text,
+// inserting ins there, or deleting the rune there if ins is empty.
+// Edits of single runes transform simply and converge, see transformOp.
+type charOp struct {
+	Pos  int    `json:"p"`
+	Ins  string `json:"i,omitempty"`
+	noop bool   // an edit cancelled by another, like deleting a deleted rune
+}
+
+// transformOp returns x applied after y, both edits of the same text.
+// Inserts at the same offset are ordered by xFirst, which is the opposite
+// when transforming y by x so both orders end with the same text.
+func transformOp(x, y charOp, xFirst bool) charOp {
+	if x.noop || y.noop {
+		return x
+	}
+	switch {
+	case x.Ins != "" && y.Ins != "":
+		if x.Pos > y.Pos || x.Pos == y.Pos && !xFirst {
+			x.Pos++
+		}
+	case x.Ins != "":
+		if x.Pos > y.Pos {
+			x.Pos--
+		}
+	case y.Ins != "":
+		if x.Pos >= y.Pos {
+			x.Pos++
+		}
+	default:
+		switch {
+		case x.Pos > y.Pos:
+			x.Pos--
+		case x.Pos == y.Pos:
+			x.noop = true
+		}
+	}
+	return x
+}
+
+// transformOps returns the edits of a applied after b and b applied after a,
+// both sequences of edits of the same text.
+func transformOps(a, b []charOp, aFirst bool) ([]charOp, []charOp) {
+	a, b = slices.Clone(a), slices.Clone(b)
+	for i := range a {
+		for j := range b {
+			a[i], b[j] = transformOp(a[i], b[j], aFirst), transformOp(b[j], a[i], !aFirst)
+		}
+	}
+	return compactOps(a), compactOps(b)
+}
+
+// compactOps removes the cancelled edits.
+func compactOps(ops []charOp) []charOp {
+	return slices.DeleteFunc(ops, func(op charOp) bool { return op.noop })
+}
+
+// diffOps returns the edits turning the text a into b.
+func diffOps(a, b []rune) []charOp {
+	var ops []charOp
+	pos := 0
+	for _, d := range diff(a, b) {
+		switch d.kind {
+		case diffEqual:
+			pos++
+		case diffDelete:
+			ops = append(ops, charOp{Pos: pos})
+		case diffInsert:
+			ops = append(ops, charOp{Pos: pos, Ins: string(b[d.j])})
+			pos++
+		}
+	}
+	return ops
+}
+
+// offset returns the offset in runes of the position in the text of the tab.
+func (t *Tab) offset(row, col int) int {
+	off := 0
+	i := 0
+	for e := t.lines.Front(); e != nil && i < row; e = e.Next() {
+		off += len(e.Value) + 1
+		i++
+	}
+	return off + col
+}
+
+// position returns the position of the offset in runes in the text of the tab,
+// the end of the text if beyond.
+func (t *Tab) position(off int) (int, int) {
+	row := 0
+	for e := t.lines.Front(); e != nil; e = e.Next() {
+		n := len(e.Value)
+		if off <= n || e.Next() == nil {
+			return row, min(off, n)
+		}
+		off -= n + 1
+		row++
+	}
+	return 0, 0
+}
+
+// changeOps returns the edits of the change applied to the tab.
+func changeOps(t *Tab, c Change) []charOp {
+	off := t.offset(c.row, c.col)
+	var ops []charOp
+	if c.kind != editInsert {
+		for range []rune(c.oldText) {
+			ops = append(ops, charOp{Pos: off})
+		}
+	}
+	if c.kind != editDelete {
+		for i, r := range []rune(c.newText) {
+			ops = append(ops, charOp{Pos: off + i, Ins: string(r)})
+		}
+	}
+	return ops
+}
+
+// applyOps applies the edits of the other side of a share to the tab as undoable changes,
+// keeping the cursor at the text it was on.
+func (a *App) applyOps(tab *Tab, ops []charOp) {
+	st := a.s
+	if tab != a.s.Tab {
+		st = &State{Tab: tab, marks: a.s.marks}
+	}
+	// the edits are not of this side to send
+	session := tab.share
+	tab.share = nil
+	defer func() { tab.share = session }()
+	// neither coalesced with the edits of this side
+	st.lastChange = nil
+	defer func() { st.lastChange = nil }()
+
+	cursor := tab.offset(tab.row, tab.col)
+	for i := 0; i < len(ops); {
+		// runs of inserted runes and of deletes at the same offset are applied at once
+		j := i + 1
+		for j < len(ops) && (ops[i].Ins == "") == (ops[j].Ins == "") &&
+			(ops[i].Ins == "" && ops[j].Pos == ops[i].Pos || ops[i].Ins != "" && ops[j].Pos == ops[j-1].Pos+1) {
+			j++
+		}
+		pos := ops[i].Pos
+		row, col := tab.position(pos)
+		if ops[i].Ins != "" {
+			var text strings.Builder
+			for _, op := range ops[i:j] {
+				text.WriteString(op.Ins)
+			}
+			runes := []rune(text.String())
+			st.insertText(runes, row, col)
+			st.recordChange(Change{row: row, col: col, newText: string(runes), kind: editInsert})
+			if pos < cursor {
+				cursor += len(runes)
+			}
+		} else {
+			endRow, endCol := tab.position(pos + j - i)
+			deleted := st.deleteRange(row, col, endRow, endCol)
+			if deleted != "" {
+				st.recordChange(Change{row: row, col: col, oldText: deleted, kind: editDelete})
+			}
+			if pos < cursor {
+				cursor -= min(len([]rune(deleted)), cursor-pos)
+			}
+		}
+		i = j
+	}
+	tab.row, tab.col = tab.position(cursor)
+	if tab == a.s.Tab {
+		a.s.selection = nil
+		a.s.selecting = false
+		a.s.upDownCol = -1
+		a.drawEditor()
+		a.syncCursor()
+	} else {
+		a.drawTabs()
+	}
+}
+
+// shareSession is a share of a tab, hosted or joined.
+type shareSession interface {
+	// local sends the edits made on this side.
+	local(ops []charOp)
+	// stop ends the share.
+	stop()
+}
+
+// shareMessage is a line of JSON sent between the sides of a share.
+type shareMessage struct {
+	Type string   `json:"type"` // "init" with the text to the joined, "op" with edits, or "ack" of them
+	Rev  int      `json:"rev,omitempty"`
+	Name string   `json:"name,omitempty"`
+	Text string   `json:"text,omitempty"`
+	Ops  []charOp `json:"ops,omitempty"`
+}
+
+// sharePeer is a connection of a share, the messages are written by its own goroutine.
+type sharePeer struct {
+	conn net.Conn
+	out  chan shareMessage
+}
+
+func newSharePeer(conn net.Conn) *sharePeer {
+	p := &sharePeer{conn: conn, out: make(chan shareMessage, 256)}
+	go func() {
+		enc := json.NewEncoder(conn)
+		for m := range p.out {
+			if enc.Encode(m) != nil {
+				conn.Close()
+			}
+		}
+	}()
+	return p
+}
+
+// send queues the message, dropping the connection if it falls behind.
+func (p *sharePeer) send(m shareMessage) {
+	select {
+	case p.out <- m:
+	default:
+		p.conn.Close()
+	}
+}
+
+func (p *sharePeer) close() {
+	close(p.out)
+	p.conn.Close()
+}
+
+// read reads the messages of the peer from r, the connection or a reader of it,
+// handling each on the main goroutine until the connection ends.
+func (p *sharePeer) read(r io.Reader, handle func(shareMessage), done func(error)) {
+	go func() {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(nil, 64<<20)
+		for scanner.Scan() {
+			var m shareMessage
+			if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+				p.conn.Close()
+				postFunc(func() { done(err) })
+				return
+			}
+			postFunc(func() { handle(m) })
+		}
+		err := scanner.Err()
+		postFunc(func() { done(err) })
+	}()
+}
+
+// shareHost serves a tab to the joined peers. It orders the edits: the edits of a peer
+// based on an older revision are transformed by the edits since, then applied and sent
+// to the others, the peer gets an ack.
+type shareHost struct {
+	a       *App
+	tab     *Tab
+	ln      net.Listener
+	history [][]charOp // edits in order, the revision is the number of them
+	peers   []*sharePeer
+}
+
+func (h *shareHost) local(ops []charOp) {
+	if len(ops) == 0 {
+		return
+	}
+	h.history = append(h.history, ops)
+	for _, p := range h.peers {
+		p.send(shareMessage{Type: "op", Ops: ops})
+	}
+}
+
+func (h *shareHost) stop() {
+	h.ln.Close()
+	for _, p := range h.peers {
+		p.close()
+	}
+	h.peers = nil
+	if h.tab.share == h {
+		h.tab.share = nil
+	}
+}
+
+// accept adds the connection as a peer, sending it the text.
+func (h *shareHost) accept(conn net.Conn) {
+	if h.tab.share != h {
+		conn.Close()
+		return
+	}
+	p := newSharePeer(conn)
+	h.peers = append(h.peers, p)
+	p.send(shareMessage{Type: "init", Rev: len(h.history), Name: h.tab.name(), Text: h.tab.text()})
+	p.read(conn, func(m shareMessage) { h.receive(p, m) }, func(error) {
+		if i := slices.Index(h.peers, p); i >= 0 {
+			h.peers = slices.Delete(h.peers, i, i+1)
+			p.close()
+			h.a.showMessage(fmt.Sprintf("%s left the share of %s", conn.RemoteAddr(), h.tab.name()))
+		}
+	})
+	h.a.showMessage(fmt.Sprintf("%s joined the share of %s", conn.RemoteAddr(), h.tab.name()))
+}
+
+func (h *shareHost) receive(p *sharePeer, m shareMessage) {
+	if !slices.Contains(h.peers, p) || m.Type != "op" {
+		return
+	}
+	if m.Rev < 0 || m.Rev > len(h.history) {
+		p.conn.Close()
+		return
+	}
+	ops := m.Ops
+	for _, done := range h.history[m.Rev:] {
+		ops, _ = transformOps(ops, done, false)
+	}
+	h.a.applyOps(h.tab, ops)
+	// an edit cancelled is still a revision the peers count
+	h.history = append(h.history, ops)
+	for _, q := range h.peers {
+		if q == p {
+			q.send(shareMessage{Type: "ack"})
+		} else {
+			q.send(shareMessage{Type: "op", Ops: ops})
+		}
+	}
+}
+
+// shareClient is a tab joined to a share. It sends its edits one batch at a time,
+// transforming the edits of the host by the batch awaiting the ack and those not sent.
+type shareClient struct {
+	a       *App
+	tab     *Tab
+	peer    *sharePeer
+	rev     int      // revision of the host seen
+	waiting bool     // whether pending is sent and not acked
+	pending []charOp // edits sent
+	buffer  []charOp // edits not sent
+}
+
+func (c *shareClient) local(ops []charOp) {
+	c.buffer = append(c.buffer, ops...)
+	c.flush()
+}
+
+func (c *shareClient) flush() {
+	if c.waiting || len(c.buffer) == 0 {
+		return
+	}
+	c.pending, c.buffer = c.buffer, nil
+	c.waiting = true
+	c.peer.send(shareMessage{Type: "op", Rev: c.rev, Ops: c.pending})
+}
+
+func (c *shareClient) stop() {
+	c.peer.close()
+	if c.tab.share == c {
+		c.tab.share = nil
+		c.tab.onSave = nil
+	}
+}
+
+func (c *shareClient) receive(m shareMessage) {
+	if c.tab.share != c {
+		return
+	}
+	switch m.Type {
+	case "op":
+		c.rev++
+		ops := m.Ops
+		ops, c.pending = transformOps(ops, c.pending, true)
+		ops, c.buffer = transformOps(ops, c.buffer, true)
+		c.a.applyOps(c.tab, ops)
+	case "ack":
+		c.rev++
+		c.waiting = false
+		c.pending = nil
+		c.flush()
+	}
+}
+
+// shareNetwork returns the network of the address, a unix socket if a path.
+func shareNetwork(addr string) string {
+	if strings.Contains(addr, "/") {
+		return "unix"
+	}
+	return "tcp"
+}
+
+// toggleShare starts serving current tab at the address for other instances to join
+// and edit together, or ends the share of the tab.
+func (a *App) toggleShare(addr string) {
+	if a.s.share != nil {
+		a.s.share.stop()
+		a.showMessage("Share ended")
+		return
+	}
+	if a.s.readOnly {
+		a.showError("Cannot share a read-only tab")
+		return
+	}
+	if addr == "" {
+		addr = defaultShareAddr
+	}
+	ln, err := net.Listen(shareNetwork(addr), addr)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	h := &shareHost{a: a, tab: a.s.Tab, ln: ln}
+	a.s.share = h
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			postFunc(func() { h.accept(conn) })
+		}
+	}()
+	a.showMessage(fmt.Sprintf("Sharing %s, >join %s from another tinotext, >share again to end", a.s.name(), addr))
+}
+
+// joinShare opens a tab of the text shared at the address, edited together with the host.
+func (a *App) joinShare(addr string) {
+	if addr == "" {
+		addr = defaultShareAddr
+	}
+	conn, err := net.DialTimeout(shareNetwork(addr), addr, 5*time.Second)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	// the first message is the text, read before the rest are read by the peer
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	r := bufio.NewReader(conn)
+	line, err := r.ReadBytes('\n')
+	var init shareMessage
+	if err == nil {
+		err = json.Unmarshal(line, &init)
+	}
+	if err == nil && init.Type != "init" {
+		err = fmt.Errorf("unexpected message %q", init.Type)
+	}
+	if err != nil {
+		conn.Close()
+		a.showError("Failed to join: " + err.Error())
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+	postFunc(func() {
+		tab := &Tab{title: "shared " + strings.TrimSuffix(init.Name, "*"), scratch: true}
+		tab.setText(init.Text)
+		tab.onSave = func(a *App, _ string) {
+			a.showMessage("The host saves the shared file")
+		}
+		c := &shareClient{a: a, tab: tab, peer: newSharePeer(conn), rev: init.Rev}
+		tab.share = c
+		a.s.tabs = append(a.s.tabs, tab)
+		a.s.switchTab(len(a.s.tabs) - 1)
+		a.draw()
+		c.peer.read(r, c.receive, func(err error) {
+			if tab.share != c {
+				return
+			}
+			c.stop()
+			msg := "The share ended"
+			if err != nil {
+				msg += ": " + err.Error()
+			}
+			a.showError(msg)
+		})
+		a.showMessage("Joined the share of " + init.Name + " at " + addr)
+	})
+}
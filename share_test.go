@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func applyCharOps(text []rune, ops []charOp) []rune {
+	text = slices.Clone(text)
+	for _, op := range ops {
+		if op.Ins != "" {
+			text = slices.Insert(text, op.Pos, []rune(op.Ins)...)
+		} else {
+			text = slices.Delete(text, op.Pos, op.Pos+1)
+		}
+	}
+	return text
+}
+
+// randomOps returns n random edits applied in order to the text.
+func randomOps(r *rand.Rand, text []rune, n int) []charOp {
+	var ops []charOp
+	for range n {
+		var op charOp
+		if len(text) == 0 || r.Intn(2) == 0 {
+			op = charOp{Pos: r.Intn(len(text) + 1), Ins: string(rune('a' + r.Intn(3)))}
+		} else {
+			op = charOp{Pos: r.Intn(len(text))}
+		}
+		ops = append(ops, op)
+		text = applyCharOps(text, []charOp{op})
+	}
+	return ops
+}
+
+func TestTransformOpsConverge(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := range 2000 {
+		text := []rune("hello\nworld")
+		a := randomOps(r, text, r.Intn(5))
+		b := randomOps(r, text, r.Intn(5))
+		a2, b2 := transformOps(a, b, true)
+		ab := applyCharOps(applyCharOps(text, a), b2)
+		ba := applyCharOps(applyCharOps(text, b), a2)
+		if string(ab) != string(ba) {
+			t.Fatalf("case %d: a %v, b %v: a then b' %q, b then a' %q", i, a, b, string(ab), string(ba))
+		}
+	}
+}
+
+func TestTransformOpsTie(t *testing.T) {
+	a := []charOp{{Pos: 1, Ins: "x"}}
+	b := []charOp{{Pos: 1, Ins: "y"}}
+	a2, b2 := transformOps(a, b, true)
+	text := []rune("ab")
+	if got := string(applyCharOps(applyCharOps(text, a), b2)); got != "axyb" {
+		t.Errorf("a then b' = %q, want %q", got, "axyb")
+	}
+	if got := string(applyCharOps(applyCharOps(text, b), a2)); got != "axyb" {
+		t.Errorf("b then a' = %q, want %q", got, "axyb")
+	}
+}
+
+func TestDiffOps(t *testing.T) {
+	tests := []struct{ a, b string }{
+		{"", "abc"},
+		{"abc", ""},
+		{"func f(){}", "func f() {}"},
+		{"hello world", "help, world!"},
+	}
+	for _, tt := range tests {
+		if got := string(applyCharOps([]rune(tt.a), diffOps([]rune(tt.a), []rune(tt.b)))); got != tt.b {
+			t.Errorf("diffOps(%q, %q) applied = %q", tt.a, tt.b, got)
+		}
+	}
+}
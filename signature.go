@@ -0,0 +1,113 @@
+package main
+
+import (
+	"go/token"
+	"strings"
+)
+
+// signatureLines is how many lines before the cursor are searched for the call.
+const signatureLines = 10
+
+var styleActiveParam = styleBase.Bold(true).Underline(true)
+
+// callContext returns the function name of the call whose parentheses enclose
+// the column of the line, and the index of the argument at the column.
+func callContext(e *lineElem, col int) (name string, arg int, ok bool) {
+	depth := 0
+	for n := 0; e != nil && n < signatureLines; n++ {
+		line := e.Value
+		i := min(col, len(line)) - 1
+		var inString rune
+		for ; i >= 0; i-- {
+			c := line[i]
+			if inString != 0 {
+				if c == inString {
+					inString = 0
+				}
+				continue
+			}
+			switch c {
+			case '"', '\'', '`':
+				inString = c
+			case ')', ']', '}':
+				depth++
+			case '[', '{':
+				if depth == 0 {
+					return "", 0, false
+				}
+				depth--
+			case ',':
+				if depth == 0 {
+					arg++
+				}
+			case ';':
+				if depth == 0 {
+					return "", 0, false
+				}
+			case '(':
+				if depth > 0 {
+					depth--
+					continue
+				}
+				end := i
+				for i > 0 && isIdentRune(line[i-1]) {
+					i--
+				}
+				name = string(line[i:end])
+				if name == "" || token.IsKeyword(name) {
+					return "", 0, false
+				}
+				return name, arg, true
+			}
+		}
+		if e = e.Prev(); e != nil {
+			col = len(e.Value)
+		}
+	}
+	return "", 0, false
+}
+
+// isIdentRune reports whether the rune can be in a Go identifier.
+func isIdentRune(r rune) bool {
+	return r == '_' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r > 127
+}
+
+// signatureTexts returns the signature of the function, the active parameter highlighted.
+func signatureTexts(sym Symbol, arg int) []textStyle {
+	ts := []textStyle{{text: []rune(sym.Name + "(")}}
+	if n := len(sym.Params); n > 0 && arg >= n && strings.Contains(sym.Params[n-1], "...") {
+		arg = n - 1 // more variadic arguments
+	}
+	for i, p := range sym.Params {
+		if i > 0 {
+			ts = append(ts, textStyle{text: []rune(", ")})
+		}
+		if i == arg {
+			ts = append(ts, textStyle{text: []rune(p), style: styleActiveParam})
+		} else {
+			ts = append(ts, textStyle{text: []rune(p)})
+		}
+	}
+	return append(ts, textStyle{text: []rune(")")})
+}
+
+// signatureHelp returns the signature of the function called at the cursor, nil if none.
+func (st *State) signatureHelp() []textStyle {
+	if len(st.symbols) == 0 {
+		return nil
+	}
+	e := st.line(st.row)
+	if e == nil {
+		return nil
+	}
+	name, arg, ok := callContext(e, st.col)
+	if !ok {
+		return nil
+	}
+	for _, sym := range st.symbols[name] {
+		if sym.Kind == SymbolFunc {
+			return signatureTexts(sym, arg)
+		}
+	}
+	return nil
+}
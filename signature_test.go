@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCallContext(t *testing.T) {
+	tests := []struct {
+		text string // | marks the cursor
+		name string
+		arg  int
+		ok   bool
+	}{
+		{"f(|)", "f", 0, true},
+		{"a.jump(row, |", "jump", 1, true},
+		{"jump(g(x, y), |)", "jump", 1, true},
+		{`jump(",(", |)`, "jump", 1, true},
+		{"jump(\n\trow,\n\t|", "jump", 1, true},
+		{"jump(T{a, |})", "", 0, false},
+		{"(a + |)", "", 0, false},
+		{"if (|", "", 0, false},
+		{"f()|", "", 0, false},
+	}
+	for _, tt := range tests {
+		l := newLineBuffer()
+		var e *lineElem
+		var col int
+		for _, line := range strings.Split(tt.text, "\n") {
+			if i := strings.Index(line, "|"); i >= 0 {
+				line = line[:i] + line[i+1:]
+				e = l.PushBack([]rune(line))
+				col = len([]rune(line[:i]))
+				continue
+			}
+			l.PushBack([]rune(line))
+		}
+		name, arg, ok := callContext(e, col)
+		if name != tt.name || arg != tt.arg || ok != tt.ok {
+			t.Errorf("callContext(%q) = %q, %d, %v, want %q, %d, %v", tt.text, name, arg, ok, tt.name, tt.arg, tt.ok)
+		}
+	}
+}
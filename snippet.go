@@ -0,0 +1,39 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// timeLayouts are the named layouts of >date, other arguments are used as Go layouts.
+var timeLayouts = map[string]string{
+	"":         time.DateOnly,
+	"date":     time.DateOnly,
+	"time":     time.TimeOnly,
+	"datetime": time.DateTime,
+	"rfc3339":  time.RFC3339,
+	"rfc1123":  time.RFC1123,
+}
+
+// formatTime formats t in the named layout or the Go layout,
+// "unix" formats it as seconds since the epoch.
+func formatTime(t time.Time, layout string) string {
+	if layout == "unix" {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+	if l, ok := timeLayouts[layout]; ok {
+		layout = l
+	}
+	return t.Format(layout)
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // version 4
+	b[8] = b[8]&0x3f | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
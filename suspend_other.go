@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+func (a *App) watchSuspend() {}
+
+func (a *App) suspend() {
+	a.showMessage("Suspend is not supported on this system")
+}
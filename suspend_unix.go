@@ -0,0 +1,38 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchSuspend suspends the editor on SIGTSTP, sent by job control or "kill -TSTP".
+// Ctrl-Z is undo in raw mode, so >suspend does it from the keyboard.
+func (a *App) watchSuspend() {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, syscall.SIGTSTP)
+	go func() {
+		for range c {
+			postFunc(a.suspend)
+		}
+	}()
+}
+
+// suspend restores the terminal and stops the editor until it is resumed by fg,
+// then redraws the screen. It runs on the main goroutine.
+func (a *App) suspend() {
+	if err := screen.Suspend(); err != nil {
+		a.showError(err.Error())
+		return
+	}
+	// SIGTSTP is caught, stop like its default action
+	syscall.Kill(syscall.Getpid(), syscall.SIGSTOP)
+	if err := screen.Resume(); err != nil {
+		a.showError(err.Error())
+		return
+	}
+	screen.Sync()
+	a.draw()
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// colorRegexp matches the hex colors like #a1b2c3 and #abc, and the words of named colors.
+var colorRegexp = regexp.MustCompile(`#([0-9a-fA-F]{6}|[0-9a-fA-F]{3})\b|\b[a-z]+\b`)
+
+// swatch is the color of a value ending at the column of a line.
+type swatch struct {
+	col   int
+	color tcell.Color
+}
+
+// colorSwatches returns the colors of the hex values and the color names in the line.
+func colorSwatches(line []rune) []swatch {
+	s := string(line)
+	var swatches []swatch
+	for _, loc := range colorRegexp.FindAllStringSubmatchIndex(s, -1) {
+		var color tcell.Color
+		if loc[2] >= 0 {
+			hex := s[loc[2]:loc[3]]
+			if len(hex) == 3 {
+				hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+			}
+			v, err := strconv.ParseInt(hex, 16, 32)
+			if err != nil {
+				continue
+			}
+			color = tcell.NewHexColor(int32(v))
+		} else {
+			// a word in a longer name like "background-color" is no color
+			if loc[0] > 0 && (s[loc[0]-1] == '-' || s[loc[0]-1] == '_') ||
+				loc[1] < len(s) && (s[loc[1]] == '-' || s[loc[1]] == '_') {
+				continue
+			}
+			c, ok := tcell.ColorNames[s[loc[0]:loc[1]]]
+			if !ok {
+				continue
+			}
+			color = c.TrueColor()
+		}
+		swatches = append(swatches, swatch{col: len([]rune(s[:loc[1]])), color: color})
+	}
+	return swatches
+}
+
+// overlaySwatches inserts a cell of the color after each color value of the line.
+// The cursor line has no swatches so the cursor stays where the text is.
+func (a *App) overlaySwatches(texts []textStyle, row int, line []rune) []textStyle {
+	if row == a.s.row {
+		return texts
+	}
+	swatches := colorSwatches(line)
+	if len(swatches) == 0 {
+		return texts
+	}
+	var runes []textStyle
+	for _, ts := range texts {
+		for _, r := range ts.text {
+			runes = append(runes, textStyle{text: []rune{r}, style: ts.style})
+		}
+	}
+	// insert from the end so the columns before stay valid
+	for i := len(swatches) - 1; i >= 0; i-- {
+		col := columnToVisual(line, swatches[i].col) - a.s.left
+		if col < 0 || col > len(runes) {
+			continue
+		}
+		runes = slices.Insert(runes, col, textStyle{text: []rune{' '}, style: styleBase.Background(swatches[i].color)})
+	}
+	return runes
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestColorSwatches(t *testing.T) {
+	tests := []struct {
+		line string
+		want []swatch
+	}{
+		{"color: #A1B2C3;", []swatch{{14, tcell.NewHexColor(0xa1b2c3)}}},
+		{"border: 1px solid red", []swatch{{21, tcell.ColorRed.TrueColor()}}},
+		{"fg = '#fff' # bg black", []swatch{{10, tcell.NewHexColor(0xffffff)}, {22, tcell.ColorBlack.TrueColor()}}},
+		{"background-color: #12345", nil},
+		{"id: #abcdefg", nil},
+		{"Red alert", nil},
+	}
+	for _, tt := range tests {
+		if got := colorSwatches([]rune(tt.line)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("colorSwatches(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
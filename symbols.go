@@ -0,0 +1,124 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// symbolKinds are the kinds filtering the @ picker by a prefix, like "@func:Name".
+var symbolKinds = map[string]SymbolKind{
+	"func":  SymbolFunc,
+	"type":  SymbolType,
+	"var":   SymbolVar,
+	"const": SymbolConst,
+	"field": SymbolField,
+}
+
+// symbolIndicators are the one-character kind indicators before the options of the @ picker.
+var symbolIndicators = map[SymbolKind]textStyle{
+	SymbolFunc:  {text: []rune("f"), style: styleBase.Foreground(tcell.ColorRebeccaPurple).Bold(true)},
+	SymbolType:  {text: []rune("t"), style: styleBase.Foreground(tcell.ColorTeal).Bold(true)},
+	SymbolVar:   {text: []rune("v"), style: styleBase.Foreground(tcell.ColorDarkGoldenrod).Bold(true)},
+	SymbolConst: {text: []rune("c"), style: styleBase.Foreground(tcell.ColorBrown).Bold(true)},
+	SymbolField: {text: []rune("."), style: styleBase.Foreground(tcell.ColorGray).Bold(true)},
+}
+
+// parseSymbolQuery splits the query of the @ picker into the kind, empty if not filtered, and the keyword.
+func parseSymbolQuery(query string) (SymbolKind, string) {
+	if prefix, keyword, ok := strings.Cut(query, ":"); ok {
+		if kind, ok := symbolKinds[prefix]; ok {
+			return kind, keyword
+		}
+	}
+	return "", query
+}
+
+// symbolName returns the name of the symbol in the options, prefixed by the receiver if any.
+func symbolName(sym Symbol) string {
+	if sym.Receiver != "" {
+		return sym.Receiver + "." + sym.Name
+	}
+	return sym.Name
+}
+
+// filterSymbols returns the names of symbols fuzzy matching the query of the @ picker,
+// the best matches first.
+func (st *State) filterSymbols(query string) []string {
+	kind, keyword := parseSymbolQuery(query)
+	type match struct {
+		name  string
+		score int
+	}
+	var matches []match
+	for _, v := range st.symbols {
+		for _, sym := range v {
+			if kind != "" && sym.Kind != kind {
+				continue
+			}
+			if score, ok := symbolScore(keyword, sym); ok {
+				matches = append(matches, match{symbolName(sym), score})
+			}
+		}
+	}
+	slices.SortFunc(matches, func(a, b match) int {
+		return cmp.Or(b.score-a.score, strings.Compare(a.name, b.name))
+	})
+	var filter []string
+	for _, m := range matches {
+		filter = append(filter, m.name)
+	}
+	return filter
+}
+
+// symbolKindOf returns the kind of the symbol named as in the options.
+func (st *State) symbolKindOf(option string) SymbolKind {
+	receiver, name, ok := strings.Cut(option, ".")
+	if !ok {
+		receiver, name = "", option
+	}
+	for _, sym := range st.symbols[name] {
+		if sym.Receiver == receiver {
+			return sym.Kind
+		}
+	}
+	return ""
+}
+
+// colorFlash is the background of the identifier gone to, until the next key.
+var colorFlash = tcell.ColorGold
+
+// flashRange is the identifier highlighted after going to its definition.
+type flashRange struct {
+	row        int
+	start, end int
+}
+
+// goToSymbol moves the cursor to the name of the symbol and flashes it, so the eye lands
+// on the name rather than the keyword before it, like "func".
+func (a *App) goToSymbol(sym Symbol) {
+	a.recordPositon(a.s.row, a.s.col)
+	row, col := sym.Line-1, sym.Column-1
+	a.s.flash = nil
+	if e := a.s.line(row); e != nil {
+		line := e.Value
+		if i := strings.Index(string(line[min(col, len(line)):]), sym.Name); i >= 0 {
+			col += utf8.RuneCountInString(string(line[col:])[:i])
+			a.s.flash = &flashRange{row: row, start: col, end: col + utf8.RuneCountInString(sym.Name)}
+		}
+	}
+	a.jump(row, col)
+	a.drawEditor()
+}
+
+// clearFlash stops highlighting the identifier gone to.
+func (a *App) clearFlash() {
+	f := a.s.flash
+	a.s.flash = nil
+	if e := a.s.line(f.row); e != nil {
+		a.drawEditorLine(f.row, e.Value)
+	}
+}
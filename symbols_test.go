@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterSymbols(t *testing.T) {
+	src := `package p
+
+type Reader struct{ buf []byte }
+
+func (r *Reader) Read() {}
+
+func NewReader() *Reader { return nil }
+
+var readers int
+
+const maxRead = 1
+`
+	symbols, err := ParseSymbol("p.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := &State{Tab: &Tab{symbols: symbols}}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"read", []string{"Reader.Read", "Reader", "maxRead", "readers", "NewReader", "Reader.buf"}},
+		{"r.rd", []string{"Reader.Read"}},
+		{"field:", []string{"Reader.buf"}},
+		{"func:read", []string{"Reader.Read", "NewReader"}},
+		{"type:", []string{"Reader"}},
+		{"const:", []string{"maxRead"}},
+		{"bogus:x", nil},
+	}
+	for _, tt := range tests {
+		if got := st.filterSymbols(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("filterSymbols(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+	if got := st.symbolKindOf("Reader.Read"); got != SymbolFunc {
+		t.Errorf("symbolKindOf(Reader.Read) = %q, want func", got)
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// tailInterval is how often a tailed file is checked for growth.
+const tailInterval = 500 * time.Millisecond
+
+// toggleTail starts or stops following the file of current tab like "tail -f":
+// the lines appended to the file are appended to the tab, scrolled into view
+// while the cursor stays on the last line.
+func (a *App) toggleTail() {
+	tab := a.s.Tab
+	if tab.tailStop != nil {
+		tab.tailStop()
+		tab.tailStop = nil
+		a.showMessage("Tail stopped")
+		return
+	}
+	if tab.filename == "" {
+		a.showMessage("No file to tail")
+		return
+	}
+	if tab.dirty() {
+		a.showError("Save the changes before tailing")
+		return
+	}
+	f, err := os.Open(tab.filename)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	offset, err := f.Seek(0, io.SeekEnd)
+	if err == nil && offset > 0 {
+		// the tab ends with an empty line even if the file does not end with a newline,
+		// drop it so the rest of the last line joins the line
+		last := make([]byte, 1)
+		if _, err := f.ReadAt(last, offset-1); err == nil && last[0] != '\n' &&
+			tab.lines.Len() > 1 && len(tab.lines.Back().Value) == 0 {
+			tab.lines.Remove(tab.lines.Back())
+		}
+	}
+	if err != nil {
+		f.Close()
+		a.showError(err.Error())
+		return
+	}
+	tab.clearHistory()
+	ctx, cancel := context.WithCancel(context.Background())
+	tab.tailStop = cancel
+	a.showMessage("Tailing " + tab.filename + ", >tail again to stop")
+	go func() {
+		defer f.Close()
+		ticker := time.NewTicker(tailInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			info, err := f.Stat()
+			if err != nil || info.Size() == offset {
+				continue
+			}
+			truncated := info.Size() < offset
+			if truncated {
+				offset = 0
+			}
+			buf := make([]byte, info.Size()-offset)
+			n, err := f.ReadAt(buf, offset)
+			if err != nil && err != io.EOF {
+				continue
+			}
+			offset += int64(n)
+			text := string(buf[:n])
+			postFunc(func() {
+				// stopped or the tab closed
+				if ctx.Err() != nil {
+					return
+				}
+				a.appendTail(tab, text, truncated)
+			})
+		}
+	}()
+}
+
+// clearHistory drops the undo history of the tab, as its text changes without
+// recording the changes, and takes the text as saved.
+func (t *Tab) clearHistory() {
+	t.changes = nil
+	t.changeIndex = 0
+	t.markSaved()
+}
+
+// appendTail appends the text read from the tailed file to the tab, replacing the lines
+// if the file was truncated. The undo history is dropped then, the rows of its changes
+// being gone.
+func (a *App) appendTail(tab *Tab, text string, truncated bool) {
+	var before string
+	if tab.share != nil {
+		before = tab.text()
+	}
+	lastRow := tab.lines.Len() - 1
+	follow := tab.row == lastRow && tab.top+len(a.editor) > lastRow
+	if truncated {
+		tab.lines.Init()
+		tab.lines.PushBack([]rune{})
+		tab.row, tab.col, tab.top = 0, 0, 0
+		tab.clearHistory()
+		follow = true
+	}
+	newLines := strings.Split(text, "\n")
+	back := tab.lines.Back()
+	back.Value = append(back.Value, []rune(newLines[0])...)
+	for _, line := range newLines[1:] {
+		tab.lines.PushBack([]rune(line))
+	}
+	tab.edits++
+	if tab.share != nil {
+		tab.share.local(diffOps([]rune(before), []rune(tab.text())))
+	}
+	if a.s.lsp != nil {
+		a.s.lsp.open(tab)
+	}
+	if a.s.ansiColors && isLogFile(tab.filename) {
+		tab.interpretANSI(lastRow)
+	}
+	if follow {
+		tab.row = tab.lines.Len() - 1
+		tab.col = 0
+	}
+	if tab != a.s.Tab {
+		return
+	}
+	a.drawEditor()
+	a.syncCursor()
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAppendTailTruncated(t *testing.T) {
+	tab := &Tab{}
+	a := &App{s: &State{Tab: tab, tabs: []*Tab{tab}}}
+	a.s.loadSource(strings.NewReader("one\ntwo\nthree\n"))
+	tab.clearHistory() // as >tail starts
+	a.s.insertText([]rune("x"), 2, 0)
+	a.s.recordChange(Change{row: 2, col: 0, newText: "x", kind: editInsert})
+	a.s.Tab = &Tab{lines: newLineBuffer()} // another tab in front, not to draw
+
+	a.appendTail(tab, "four\n", false)
+	if got := tab.text(); got != "one\ntwo\nxthree\nfour\n" {
+		t.Errorf("text = %q after appending", got)
+	}
+	a.appendTail(tab, "a\n", true)
+	if got := tab.text(); got != "a\n" {
+		t.Errorf("text = %q after truncating", got)
+	}
+	if len(tab.changes) != 0 || tab.dirty() {
+		t.Errorf("changes = %v, dirty %v after truncating, want none", tab.changes, tab.dirty())
+	}
+	a.s.Tab = tab
+	a.s.undo() // nothing to undo, the rows of the change are gone
+	if got := tab.text(); got != "a\n" {
+		t.Errorf("text = %q after undo", got)
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// task is a long-running operation working outside the main goroutine.
+// Its progress is shown in the status bar, and Escape cancels it.
+type task struct {
+	name   string
+	total  int64 // total units of work, 0 if unknown
+	done   atomic.Int64
+	ctx    context.Context
+	cancel context.CancelFunc
+	tasks  *tasks
+}
+
+// add reports n more units of work done.
+func (t *task) add(n int64) {
+	t.done.Add(n)
+}
+
+// finish removes the task from the status bar.
+func (t *task) finish() {
+	t.cancel()
+	t.tasks.remove(t)
+}
+
+// tasks are the running tasks.
+type tasks struct {
+	mu   sync.Mutex
+	list []*task
+}
+
+// progressEvent is posted as interrupt data periodically while tasks are running.
+type progressEvent struct{}
+
+// progressInterval is the interval to refresh the progress.
+const progressInterval = 100 * time.Millisecond
+
+// start registers a new task, total is the units of work or 0 if unknown.
+func (ts *tasks) start(name string, total int64) *task {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &task{name: name, total: total, ctx: ctx, cancel: cancel, tasks: ts}
+	ts.mu.Lock()
+	ts.list = append(ts.list, t)
+	first := len(ts.list) == 1
+	ts.mu.Unlock()
+	if first {
+		go ts.tick()
+	}
+	return t
+}
+
+func (ts *tasks) remove(t *task) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for i, v := range ts.list {
+		if v == t {
+			ts.list = append(ts.list[:i], ts.list[i+1:]...)
+			return
+		}
+	}
+}
+
+// current returns the most recently started task, or nil.
+func (ts *tasks) current() *task {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if len(ts.list) == 0 {
+		return nil
+	}
+	return ts.list[len(ts.list)-1]
+}
+
+// cancelAll cancels the running tasks, it returns false if there is none.
+func (ts *tasks) cancelAll() bool {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for _, t := range ts.list {
+		t.cancel()
+	}
+	return len(ts.list) > 0
+}
+
+// tick posts progress events until no task is running.
+func (ts *tasks) tick() {
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if screen != nil {
+			screen.PostEvent(tcell.NewEventInterrupt(progressEvent{}))
+		}
+		if ts.current() == nil {
+			return
+		}
+	}
+}
+
+var spinner = []rune(`|/-\`)
+
+// drawProgress shows the progress of the current task in the status bar,
+// or restores the status bar when all tasks finish.
+func (a *App) drawProgress() {
+	t := a.s.tasks.current()
+	if t == nil {
+		a.syncCursor()
+		return
+	}
+	frame := spinner[time.Now().UnixMilli()/progressInterval.Milliseconds()%int64(len(spinner))]
+	text := fmt.Sprintf("%c %s", frame, t.name)
+	if t.total > 0 {
+		text += fmt.Sprintf(" %d%%", t.done.Load()*100/t.total)
+	} else if n := t.done.Load(); n > 0 {
+		text += fmt.Sprintf(" %d", n)
+	}
+	a.status.draw([]rune(text + " (esc to cancel)"))
+}
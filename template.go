@@ -0,0 +1,114 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// packageName returns the package of the Go files in the dir, or one derived from
+// the dir name if there is none. A test file may take the external test package.
+func packageName(dir string, test bool) string {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	var testPkg string
+	for _, file := range files {
+		f, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.PackageClauseOnly)
+		if err != nil {
+			continue
+		}
+		name := f.Name.Name
+		if !strings.HasSuffix(name, "_test") {
+			return name
+		}
+		testPkg = name
+	}
+	if test && testPkg != "" {
+		return testPkg
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "main"
+	}
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, filepath.Base(abs))
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		return "main"
+	}
+	return name
+}
+
+// licenseHeader returns the content of the license file as line comments, "" if unset or missing.
+func licenseHeader(path string) string {
+	if path == "" {
+		return ""
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		if !strings.HasPrefix(line, "//") {
+			line = strings.TrimRight("// "+line, " ")
+		}
+		b.WriteString(line + "\n")
+	}
+	return b.String()
+}
+
+// newFileText returns the initial text of a new file, the license header and
+// package clause of a Go file, or "" for other files.
+func newFileText(filename, licenseFile string) string {
+	if filepath.Ext(filename) != ".go" {
+		return ""
+	}
+	text := licenseHeader(licenseFile)
+	if text != "" {
+		text += "\n"
+	}
+	return text + "package " + packageName(filepath.Dir(filename), strings.HasSuffix(filename, "_test.go")) + "\n\n"
+}
+
+// loadNewFile fills current tab of a file not existing yet with its initial text,
+// putting the cursor at the end.
+func (st *State) loadNewFile() error {
+	text := st.expandVariables(newFileText(st.filename, st.licenseFile))
+	if text == "" {
+		return nil
+	}
+	if err := st.loadSource(strings.NewReader(text)); err != nil {
+		return err
+	}
+	st.row = st.lines.Len() - 1
+	return nil
+}
+
+// altFile returns the test file of the Go file, or the file tested by the test file.
+func altFile(filename string) (string, bool) {
+	if base, ok := strings.CutSuffix(filename, "_test.go"); ok {
+		return base + ".go", true
+	}
+	if base, ok := strings.CutSuffix(filename, ".go"); ok {
+		return base + "_test.go", true
+	}
+	return "", false
+}
+
+// openAlt switches between the Go file and its test, a missing file is created on save
+// starting with the package clause.
+func (a *App) openAlt() {
+	alt, ok := altFile(a.s.filename)
+	if !ok {
+		a.showMessage("Not a Go file")
+		return
+	}
+	a.handleCommand(">open " + alt)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewFileText(t *testing.T) {
+	dir := t.TempDir()
+	pkg := filepath.Join(dir, "my-pkg")
+	if err := os.Mkdir(pkg, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	license := filepath.Join(dir, "LICENSE_HEADER")
+	os.WriteFile(license, []byte("Copyright 2026 The Authors.\n\nUse of this source code is governed by a MIT license.\n"), 0o644)
+
+	if got, want := newFileText(filepath.Join(pkg, "a.go"), ""), "package mypkg\n\n"; got != want {
+		t.Errorf("empty dir: got %q, want %q", got, want)
+	}
+	os.WriteFile(filepath.Join(pkg, "b_test.go"), []byte("package foo_test\n"), 0o644)
+	if got, want := newFileText(filepath.Join(pkg, "c_test.go"), ""), "package foo_test\n\n"; got != want {
+		t.Errorf("test package: got %q, want %q", got, want)
+	}
+	os.WriteFile(filepath.Join(pkg, "foo.go"), []byte("// Package foo\npackage foo\n"), 0o644)
+	want := "// Copyright 2026 The Authors.\n//\n// Use of this source code is governed by a MIT license.\n\npackage foo\n\n"
+	if got := newFileText(filepath.Join(pkg, "d.go"), license); got != want {
+		t.Errorf("with license: got %q, want %q", got, want)
+	}
+	if got := newFileText(filepath.Join(pkg, "e.txt"), license); got != "" {
+		t.Errorf("text file: got %q, want empty", got)
+	}
+}
+
+func TestAltFile(t *testing.T) {
+	for filename, want := range map[string]string{
+		"main.go":         "main_test.go",
+		"dir/foo_test.go": "dir/foo.go",
+		"readme.md":       "",
+	} {
+		if got, _ := altFile(filename); got != want {
+			t.Errorf("altFile(%q) = %q, want %q", filename, got, want)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// funcAt returns the declaration of the function at the row of the Go source.
+func funcAt(fset *token.FileSet, f *ast.File, row int) *ast.FuncDecl {
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if ok && fset.Position(fn.Pos()).Line-1 <= row && row <= fset.Position(fn.End()).Line-1 {
+			return fn
+		}
+	}
+	return nil
+}
+
+// testName returns the name of the test of the function, like TestParse or TestTab_line.
+func testName(fn *ast.FuncDecl) string {
+	name := fn.Name.Name
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		name = receiverTypeName(fn.Recv.List[0].Type) + "_" + name
+	}
+	r, size := utf8.DecodeRuneInString(name)
+	return "Test" + string(unicode.ToUpper(r)) + name[size:]
+}
+
+// receiverTypeName returns the type name of the receiver, without the pointer and type parameters.
+func receiverTypeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexExpr:
+		return receiverTypeName(t.X)
+	case *ast.IndexListExpr:
+		return receiverTypeName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return types.ExprString(expr)
+}
+
+// testSkeleton returns a table-driven test of the function like gotests generates,
+// and the packages it imports.
+func testSkeleton(fn *ast.FuncDecl) (string, []string) {
+	imports := []string{"testing"}
+	var b strings.Builder
+	name := testName(fn)
+	fmt.Fprintf(&b, "func %s(t *testing.T) {\n", name)
+
+	// the arguments
+	type field struct{ name, typ string }
+	var args []field
+	var call []string
+	for _, p := range fn.Type.Params.List {
+		typ := types.ExprString(p.Type)
+		variadic := false
+		if ellipsis, ok := p.Type.(*ast.Ellipsis); ok {
+			typ = "[]" + types.ExprString(ellipsis.Elt)
+			variadic = true
+		}
+		names := p.Names
+		if len(names) == 0 {
+			names = []*ast.Ident{ast.NewIdent("")}
+		}
+		for _, n := range names {
+			argName := n.Name
+			if argName == "" || argName == "_" {
+				argName = fmt.Sprintf("arg%d", len(args))
+			}
+			args = append(args, field{argName, typ})
+			arg := "tt.args." + argName
+			if variadic {
+				arg += "..."
+			}
+			call = append(call, arg)
+		}
+	}
+	if len(args) > 0 {
+		b.WriteString("\ttype args struct {\n")
+		for _, a := range args {
+			fmt.Fprintf(&b, "\t\t%s %s\n", a.name, a.typ)
+		}
+		b.WriteString("\t}\n")
+	}
+
+	// the results, a last error is checked by wantErr
+	var wants []field
+	var wantErr bool
+	if fn.Type.Results != nil {
+		for _, r := range fn.Type.Results.List {
+			typ := types.ExprString(r.Type)
+			for range max(len(r.Names), 1) {
+				wants = append(wants, field{"", typ})
+			}
+		}
+	}
+	if n := len(wants); n > 0 && wants[n-1].typ == "error" {
+		wants = wants[:n-1]
+		wantErr = true
+	}
+	for i := range wants {
+		wants[i].name = "want"
+		if i > 0 {
+			wants[i].name += fmt.Sprint(i)
+		}
+	}
+
+	b.WriteString("\ttests := []struct {\n\t\tname string\n")
+	if len(args) > 0 {
+		b.WriteString("\t\targs args\n")
+	}
+	for _, w := range wants {
+		fmt.Fprintf(&b, "\t\t%s %s\n", w.name, w.typ)
+	}
+	if wantErr {
+		b.WriteString("\t\twantErr bool\n")
+	}
+	b.WriteString("\t}{\n\t\t// TODO: add test cases.\n\t}\n")
+	b.WriteString("\tfor _, tt := range tests {\n\t\tt.Run(tt.name, func(t *testing.T) {\n")
+
+	callee := fn.Name.Name
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		recv := fn.Recv.List[0].Type
+		if _, ok := recv.(*ast.StarExpr); ok {
+			fmt.Fprintf(&b, "\t\t\tr := &%s{}\n", receiverTypeName(recv))
+		} else {
+			fmt.Fprintf(&b, "\t\t\tvar r %s\n", receiverTypeName(recv))
+		}
+		callee = "r." + callee
+	}
+	var gots []string
+	for i := range wants {
+		gots = append(gots, strings.Replace(wants[i].name, "want", "got", 1))
+	}
+	if wantErr {
+		gots = append(gots, "err")
+	}
+	expr := fmt.Sprintf("%s(%s)", callee, strings.Join(call, ", "))
+	if len(gots) > 0 {
+		fmt.Fprintf(&b, "\t\t\t%s := %s\n", strings.Join(gots, ", "), expr)
+	} else {
+		fmt.Fprintf(&b, "\t\t\t%s\n", expr)
+	}
+	if wantErr {
+		fmt.Fprintf(&b, "\t\t\tif (err != nil) != tt.wantErr {\n\t\t\t\tt.Errorf(\"%s() error = %%v, wantErr %%v\", err, tt.wantErr)\n\t\t\t\treturn\n\t\t\t}\n", fn.Name.Name)
+	}
+	for i, w := range wants {
+		imports = append(imports, "reflect")
+		label := fn.Name.Name + "()"
+		if i > 0 {
+			label += " " + gots[i]
+		}
+		fmt.Fprintf(&b, "\t\t\tif !reflect.DeepEqual(%s, tt.%s) {\n\t\t\t\tt.Errorf(\"%s = %%v, want %%v\", %s, tt.%s)\n\t\t\t}\n",
+			gots[i], w.name, label, gots[i], w.name)
+	}
+	b.WriteString("\t\t})\n\t}\n}\n")
+	slices.Sort(imports)
+	return b.String(), slices.Compact(imports)
+}
+
+// generateTest generates a table-driven test of the function at the cursor in the test file,
+// see testSkeleton, and opens it at the test cases.
+func (a *App) generateTest() {
+	alt, ok := altFile(a.s.filename)
+	if !ok || strings.HasSuffix(a.s.filename, "_test.go") {
+		a.showMessage("Not a Go file to test")
+		return
+	}
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, a.s.filename, a.s.text(), 0)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	fn := funcAt(fset, f, a.s.row)
+	if fn == nil {
+		a.showMessage("No function at the cursor")
+		return
+	}
+	code, imports := testSkeleton(fn)
+	name := testName(fn)
+
+	a.handleCommand(">open " + alt)
+	if a.s.filename != alt {
+		return
+	}
+	text := a.s.text()
+	if i := strings.Index(text, "func "+name+"("); i >= 0 {
+		row, col := offsetPosition(text, i)
+		a.jump(row, col)
+		a.showMessage(name + " exists")
+		return
+	}
+	testFile, err := parser.ParseFile(fset, alt, text, parser.ImportsOnly)
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	a.s.beginGroup()
+	defer a.s.endGroup()
+
+	// the test at the end, inserted first to keep the rows of the imports
+	last := a.s.lines.Len() - 1
+	prefix := "\n"
+	if last > 0 && len(a.s.line(last).Value) == 0 && len(a.s.line(last-1).Value) == 0 {
+		prefix = ""
+	}
+	a.jump(last, len(a.s.line(last).Value))
+	a.paste(prefix + code)
+
+	// the missing imports
+	var missing []string
+	for _, path := range imports {
+		if !slices.ContainsFunc(testFile.Imports, func(spec *ast.ImportSpec) bool { return spec.Path.Value == `"`+path+`"` }) {
+			missing = append(missing, path)
+		}
+	}
+	if len(missing) > 0 {
+		var decl *ast.GenDecl
+		if len(testFile.Decls) > 0 {
+			decl, _ = testFile.Decls[0].(*ast.GenDecl)
+		}
+		var b strings.Builder
+		switch {
+		case decl != nil && decl.Tok == token.IMPORT && decl.Lparen.IsValid():
+			for _, path := range missing {
+				fmt.Fprintf(&b, "\t%q\n", path)
+			}
+			a.jump(fset.Position(decl.Lparen).Line, 0)
+		case decl != nil && decl.Tok == token.IMPORT:
+			for _, path := range missing {
+				fmt.Fprintf(&b, "import %q\n", path)
+			}
+			a.jump(fset.Position(decl.End()).Line, 0)
+		default:
+			b.WriteString("\nimport (\n")
+			for _, path := range missing {
+				fmt.Fprintf(&b, "\t%q\n", path)
+			}
+			b.WriteString(")\n")
+			a.jump(fset.Position(testFile.Name.End()).Line, 0)
+		}
+		a.paste(b.String())
+	}
+
+	// at the test cases
+	text = a.s.text()
+	i := strings.Index(text, "func "+name+"(")
+	row, _ := offsetPosition(text, i)
+	for e := a.s.line(row); e != nil; e, row = e.Next(), row+1 {
+		if strings.Contains(string(e.Value), "// TODO: add test cases.") {
+			break
+		}
+	}
+	a.jump(row, len(a.s.line(row).Value))
+	a.drawEditor()
+	a.showMessage(name + " generated")
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"go/format"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"testing"
+)
+
+func TestTestSkeleton(t *testing.T) {
+	src := `package p
+
+func (t *Tab) split(s string, n int) ([]string, int, error) {
+	return nil, 0, nil
+}
+`
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "p.go", src, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := funcAt(fset, f, 3)
+	if fn == nil {
+		t.Fatal("no function at row 3")
+	}
+	code, imports := testSkeleton(fn)
+	if !reflect.DeepEqual(imports, []string{"reflect", "testing"}) {
+		t.Errorf("imports = %q", imports)
+	}
+	got, err := format.Source([]byte(code))
+	if err != nil {
+		t.Fatalf("invalid test %s: %v", code, err)
+	}
+	want := `func TestTab_split(t *testing.T) {
+	type args struct {
+		s string
+		n int
+	}
+	tests := []struct {
+		name    string
+		args    args
+		want    []string
+		want1   int
+		wantErr bool
+	}{
+		// TODO: add test cases.
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Tab{}
+			got, got1, err := r.split(tt.args.s, tt.args.n)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("split() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("split() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(got1, tt.want1) {
+				t.Errorf("split() got1 = %v, want %v", got1, tt.want1)
+			}
+		})
+	}
+}
+`
+	if string(got) != want {
+		t.Errorf("test =\n%s\nwant\n%s", got, want)
+	}
+	if funcAt(fset, f, 0) != nil {
+		t.Error("function at the package clause")
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// theme is a palette of the editor. Hex colors fall back to the nearest of the
+// palette on terminals without truecolor.
+type theme struct {
+	fg, bg     tcell.Color
+	syntax     map[string]tcell.Color // foregrounds of colorStyles by name
+	selection  tcell.Color            // background of the selection and the highlighted option
+	lineNumber tcell.Color            // background of the line number of the cursor
+	cursor     tcell.Color
+	debugLine  tcell.Color
+	ruler      tcell.Color
+	flash      tcell.Color
+	wordDelete tcell.Color
+	wordInsert tcell.Color
+}
+
+var themes = map[string]theme{
+	"light": {
+		fg: tcell.ColorBlack,
+		bg: tcell.ColorWhite,
+		syntax: map[string]tcell.Color{
+			"keyword": tcell.ColorRebeccaPurple,
+			"string":  tcell.ColorDarkRed,
+			"comment": tcell.ColorGray,
+			"number":  tcell.ColorBrown,
+		},
+		selection:  tcell.ColorLightSteelBlue,
+		lineNumber: tcell.ColorLightGray,
+		cursor:     tcell.ColorBlack,
+		debugLine:  tcell.ColorKhaki,
+		ruler:      tcell.ColorWhiteSmoke,
+		flash:      tcell.ColorGold,
+		wordDelete: tcell.ColorLightPink,
+		wordInsert: tcell.ColorPaleGreen,
+	},
+	"dark": {
+		fg: tcell.NewHexColor(0xd4d4d4),
+		bg: tcell.NewHexColor(0x1e1e1e),
+		syntax: map[string]tcell.Color{
+			"keyword": tcell.NewHexColor(0xc586c0),
+			"string":  tcell.NewHexColor(0xce9178),
+			"comment": tcell.NewHexColor(0x808080),
+			"number":  tcell.NewHexColor(0xb5cea8),
+		},
+		selection:  tcell.NewHexColor(0x264f78),
+		lineNumber: tcell.NewHexColor(0x3a3a3a),
+		cursor:     tcell.ColorWhite,
+		debugLine:  tcell.NewHexColor(0x4b4b18),
+		ruler:      tcell.NewHexColor(0x2a2a2a),
+		flash:      tcell.NewHexColor(0x6b5b00),
+		wordDelete: tcell.NewHexColor(0x6f1d1d),
+		wordInsert: tcell.NewHexColor(0x1d5a1d),
+	},
+}
+
+// themeName is the name of the theme applied, see applyTheme.
+var themeName = "light"
+
+// derivedStyles are the styles built on styleBase, which follow it to another theme.
+var derivedStyles = []*tcell.Style{
+	&styleError,
+	&styleBreakpoint,
+	&styleDropdown,
+	&styleHint,
+	&styleMisspelled,
+	&styleTemplateAction,
+	&styleErrorGutter,
+	&styleMarkGutter,
+	&styleInlay,
+	&styleHunkHeader,
+	&styleDeleted,
+	&styleInserted,
+	&styleDropped,
+	&styleQuickfixGutter,
+	&styleActiveParam,
+}
+
+// rebase moves the style from the base colors to the new ones, keeping its own colors.
+func rebase(style tcell.Style, oldFg, oldBg, fg, bg tcell.Color) tcell.Style {
+	sfg, sbg, _ := style.Decompose()
+	if sfg == oldFg {
+		style = style.Foreground(fg)
+	}
+	if sbg == oldBg {
+		style = style.Background(bg)
+	}
+	return style
+}
+
+// applyTheme switches the styles to the theme of the name. The syntax colors of the theme
+// replace those set by the [colors] section of the settings.
+func applyTheme(name string) error {
+	th, ok := themes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme %s, want %s", name, strings.Join(themeNames(), " or "))
+	}
+	oldFg, oldBg, _ := styleBase.Decompose()
+	move := func(s tcell.Style) tcell.Style { return rebase(s, oldFg, oldBg, th.fg, th.bg) }
+	styleBase = tcell.StyleDefault.Foreground(th.fg).Background(th.bg)
+	for _, c := range colorStyles {
+		*c.style = move(*c.style).Foreground(th.syntax[c.name])
+	}
+	for _, s := range derivedStyles {
+		*s = move(*s)
+	}
+	for kind, ind := range symbolIndicators {
+		ind.style = move(ind.style)
+		symbolIndicators[kind] = ind
+	}
+	styleHighlight = styleBase.Background(th.selection)
+	colorSelection = th.selection
+	colorLineNumber = th.lineNumber
+	cursorColor = th.cursor
+	colorDebugLine = th.debugLine
+	colorRuler = th.ruler
+	colorFlash = th.flash
+	colorWordDelete = th.wordDelete
+	colorWordInsert = th.wordInsert
+	themeName = name
+	return nil
+}
+
+// themeNames returns the names of the themes in order.
+func themeNames() []string {
+	return slices.Sorted(maps.Keys(themes))
+}
+
+// defaultTheme returns the theme matching the background of the terminal told by the value
+// of $COLORFGBG, like "15;0" set by some terminals, light if it is unknown.
+func defaultTheme(colorfgbg string) string {
+	bg, err := strconv.Atoi(colorfgbg[strings.LastIndexByte(colorfgbg, ';')+1:])
+	// black, the dark colors and dark gray of the 16 colors
+	if err == nil && bg >= 0 && (bg <= 6 || bg == 8) {
+		return "dark"
+	}
+	return "light"
+}
+
+// setTheme applies the theme of the name and redraws, or tells the themes without name.
+func (a *App) setTheme(name string) {
+	a.s.focus = focusEditor
+	if name == "" {
+		a.showMessage(fmt.Sprintf("Theme %s, >theme %s", themeName, strings.Join(themeNames(), "|")))
+		return
+	}
+	if err := applyTheme(name); err != nil {
+		a.showError(err.Error())
+		return
+	}
+	a.refreshSettings()
+	a.showMessage("Theme " + name)
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+func TestApplyTheme(t *testing.T) {
+	base, keyword, hint, inserted := styleBase, styleKeyword, styleHint, styleInserted
+	defer applyTheme("light")
+
+	if err := applyTheme("dark"); err != nil {
+		t.Fatal(err)
+	}
+	fg, bg, _ := styleBase.Decompose()
+	if fg != themes["dark"].fg || bg != themes["dark"].bg {
+		t.Errorf("base = %v on %v, want the dark colors", fg, bg)
+	}
+	if fg, bg, attr := styleKeyword.Decompose(); fg != themes["dark"].syntax["keyword"] || bg != themes["dark"].bg || attr&tcell.AttrBold == 0 {
+		t.Errorf("keyword = %v on %v with %v, want bold dark keyword", fg, bg, attr)
+	}
+	if fg, bg, _ := styleInserted.Decompose(); fg != tcell.ColorDarkGreen || bg != themes["dark"].bg {
+		t.Errorf("inserted = %v on %v, want its own foreground on the dark background", fg, bg)
+	}
+	if err := applyTheme("nothing"); err == nil {
+		t.Error("no error for an unknown theme")
+	}
+
+	if err := applyTheme("light"); err != nil {
+		t.Fatal(err)
+	}
+	if styleBase != base || styleKeyword != keyword || styleHint != hint || styleInserted != inserted {
+		t.Error("styles not restored by the light theme")
+	}
+}
+
+func TestDefaultTheme(t *testing.T) {
+	tests := []struct {
+		colorfgbg string
+		want      string
+	}{
+		{"", "light"},
+		{"15;0", "dark"},
+		{"0;15", "light"},
+		{"12;default;8", "dark"},
+		{"0;7", "light"},
+		{"default;default", "light"},
+	}
+	for _, tt := range tests {
+		if got := defaultTheme(tt.colorfgbg); got != tt.want {
+			t.Errorf("defaultTheme(%q) = %s, want %s", tt.colorfgbg, got, tt.want)
+		}
+	}
+}
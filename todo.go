@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+var colorTodo = tcell.ColorDarkOrange
+
+// todoRegexp matches the markers of the work left in comments.
+var todoRegexp = regexp.MustCompile(`\b(TODO|FIXME|HACK)\b`)
+
+// commentOpeners start the comments of the languages the marker is looked for after,
+// a leading "*" continues a block comment.
+var commentOpeners = []string{"//", "/*", "#", "--", ";", "<!--"}
+
+// todoMarkers returns the rune ranges of the markers in the comment of the line.
+func todoMarkers(line []rune) [][2]int {
+	s := string(line)
+	loc := todoRegexp.FindAllStringIndex(s, -1)
+	if loc == nil {
+		return nil
+	}
+	comment := -1
+	if strings.HasPrefix(strings.TrimSpace(s), "*") {
+		comment = 0
+	}
+	for _, opener := range commentOpeners {
+		if i := strings.Index(s, opener); i >= 0 && (comment < 0 || i < comment) {
+			comment = i
+		}
+	}
+	if comment < 0 {
+		return nil
+	}
+	var markers [][2]int
+	for _, l := range loc {
+		if l[0] < comment {
+			continue
+		}
+		start := len([]rune(s[:l[0]]))
+		markers = append(markers, [2]int{start, start + len([]rune(s[l[0]:l[1]]))})
+	}
+	return markers
+}
+
+// highlightTodos styles the TODO, FIXME and HACK markers in the comment of the line.
+func (a *App) highlightTodos(texts []textStyle, line []rune) []textStyle {
+	markers := todoMarkers(line)
+	if markers == nil {
+		return texts
+	}
+	var runes []textStyle
+	for _, ts := range texts {
+		for _, r := range ts.text {
+			runes = append(runes, textStyle{text: []rune{r}, style: ts.style})
+		}
+	}
+	for _, m := range markers {
+		start := max(columnToVisual(line, m[0])-a.s.left, 0)
+		end := min(columnToVisual(line, m[1])-a.s.left, len(runes))
+		for i := start; i < end; i++ {
+			runes[i].style = runes[i].style.Foreground(colorTodo).Bold(true)
+		}
+	}
+	return runes
+}
+
+// maxTodoFileSize is the size of files beyond which >todos does not read.
+const maxTodoFileSize = 1 << 20
+
+// scanTodos returns the markers in the text files under the root, skipping hidden
+// and excluded directories.
+func scanTodos(root string, exclude []string, t *task) ([]quickfixItem, error) {
+	var items []quickfixItem
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if t != nil {
+			if err := t.ctx.Err(); err != nil {
+				return err
+			}
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || slices.Contains(exclude, d.Name())) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || info.Size() > maxTodoFileSize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		if bytes.IndexByte(data[:min(len(data), 512)], 0) >= 0 {
+			// binary
+			return nil
+		}
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for row := 0; scanner.Scan(); row++ {
+			line := []rune(scanner.Text())
+			markers := todoMarkers(line)
+			if markers == nil {
+				continue
+			}
+			items = append(items, quickfixItem{
+				file: abs,
+				row:  row,
+				col:  markers[0][0],
+				text: strings.TrimSpace(string(line[markers[0][0]:])),
+			})
+		}
+		if t != nil {
+			t.add(1)
+		}
+		return nil
+	})
+	return items, err
+}
+
+// listTodos scans the workspace for the markers in the background, they become the
+// quickfix list and the text of the "todos" tab.
+func (a *App) listTodos() {
+	root, err := os.Getwd()
+	if err != nil {
+		a.showError(err.Error())
+		return
+	}
+	t := a.s.tasks.start("scanning todos", 0)
+	exclude := a.s.excludeDirs
+	go func() {
+		defer t.finish()
+		items, err := scanTodos(root, exclude, t)
+		if t.ctx.Err() != nil {
+			return
+		}
+		postFunc(func() {
+			if err != nil {
+				a.showError(err.Error())
+				return
+			}
+			a.s.quickfix = items
+			a.s.quickfixIdx = -1
+			var b strings.Builder
+			for _, item := range items {
+				rel, err := filepath.Rel(root, item.file)
+				if err != nil {
+					rel = item.file
+				}
+				fmt.Fprintf(&b, "%s:%d:%d: %s\n", rel, item.row+1, item.col+1, item.text)
+			}
+			a.updateBuffer("todos", b.String(), true)
+			if len(items) == 0 {
+				a.showMessage("No TODO, FIXME or HACK")
+				return
+			}
+			a.showMessage(fmt.Sprintf("%d todos, f4 to go to the next", len(items)))
+		})
+	}()
+}
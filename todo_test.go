@@ -0,0 +1,59 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTodoMarkers(t *testing.T) {
+	tests := []struct {
+		line string
+		want [][2]int
+	}{
+		{"x := 1 // TODO: remove", [][2]int{{10, 14}}},
+		{"# FIXME and HACK", [][2]int{{2, 7}, {12, 16}}},
+		{" * TODO(chen) block", [][2]int{{3, 7}}},
+		{`s := "TODO" // done`, nil},
+		{"// TODOS are not markers", nil},
+		{"TODO outside comments", nil},
+	}
+	for _, tt := range tests {
+		if got := todoMarkers([]rune(tt.line)); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("todoMarkers(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestScanTodos(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"main.go":       "package main\n\n// TODO: write main\nfunc main() {}\n",
+		"sub/run.sh":    "echo hi # FIXME quote\n",
+		"vendor/x.go":   "// TODO: excluded\n",
+		".git/config":   "# TODO: hidden\n",
+		"sub/data.bin":  "\x00 // TODO: binary\n",
+		"sub/notes.txt": "nothing to do\n",
+	}
+	for name, text := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	items, err := scanTodos(root, []string{"vendor"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []quickfixItem{
+		{file: filepath.Join(root, "main.go"), row: 2, col: 3, text: "TODO: write main"},
+		{file: filepath.Join(root, "sub/run.sh"), row: 0, col: 10, text: "FIXME quote"},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("scanTodos = %+v, want %+v", items, want)
+	}
+}
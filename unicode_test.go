@@ -12,3 +12,23 @@ func TestEastAsianChar(t *testing.T) {
 		t.Fatalf("want %d, got %d", lineCol, col)
 	}
 }
+
+func TestGraphemeCluster(t *testing.T) {
+	// family emoji joined by ZWJ, followed by "e" with a combining acute accent
+	line := []rune("👨‍👩‍👧éx")
+	if want, got := 3, len(graphemes(line)); got != want {
+		t.Fatalf("want %d clusters, got %d", want, got)
+	}
+	if want, got := 5, nextGrapheme(line, 0); got != want {
+		t.Fatalf("next: want %d, got %d", want, got)
+	}
+	if want, got := 5, prevGrapheme(line, 7); got != want {
+		t.Fatalf("prev: want %d, got %d", want, got)
+	}
+	if want, got := 3, columnToScreenWidth(line, 7); got != want {
+		t.Fatalf("width: want %d, got %d", want, got)
+	}
+	if want, got := 5, columnFromScreenWidth(line, 2); got != want {
+		t.Fatalf("column: want %d, got %d", want, got)
+	}
+}
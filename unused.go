@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// checkDelay is how long the typing pauses before checking the Go file for unused code.
+const checkDelay = 500 * time.Millisecond
+
+var colorUnused = tcell.ColorDarkGray
+
+// unusedRange is an unused import or variable on a line, dimmed in the editor.
+type unusedRange struct {
+	row        int
+	start, end int
+}
+
+var (
+	// majorVersion matches the major version element of import paths, like "v2"
+	majorVersion = regexp.MustCompile(`^v\d+$`)
+	// versionSuffix matches the version suffix of gopkg.in paths, like "yaml.v3"
+	versionSuffix = regexp.MustCompile(`\.v\d+$`)
+)
+
+// guessPackageName guesses the name of the imported package from its path,
+// e.g. "github.com/gdamore/tcell/v2" is tcell and "github.com/mattn/go-runewidth" is runewidth.
+func guessPackageName(importPath string) string {
+	dir, name := path.Split(importPath)
+	if majorVersion.MatchString(name) && dir != "" {
+		name = path.Base(dir)
+	}
+	name = versionSuffix.ReplaceAllString(name, "")
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "-go"), ".go")
+	return strings.NewReplacer("-", "", ".", "").Replace(name)
+}
+
+// emptyImporter imports every package as an empty one named by guessPackageName,
+// enough for the checker to tell whether an import is used without loading dependencies.
+type emptyImporter struct{}
+
+func (emptyImporter) Import(importPath string) (*types.Package, error) {
+	pkg := types.NewPackage(importPath, guessPackageName(importPath))
+	pkg.MarkComplete()
+	return pkg, nil
+}
+
+// findUnused type checks the Go source alone and returns the unused imports and variables.
+// Other errors are ignored since the rest of the package and the dependencies are not loaded.
+func findUnused(filename string, src []byte) []unusedRange {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, 0)
+	if err != nil {
+		return nil // syntax errors are reported by formatting
+	}
+	var unusedErrs []types.Error
+	conf := types.Config{
+		Importer:    emptyImporter{},
+		FakeImportC: true,
+		Error: func(err error) {
+			if e, ok := err.(types.Error); ok && e.Soft && strings.Contains(e.Msg, "not used") {
+				unusedErrs = append(unusedErrs, e)
+			}
+		},
+	}
+	conf.Check(f.Name.Name, fset, []*ast.File{f}, nil)
+
+	lines := bytes.Split(src, []byte("\n"))
+	// runeCol converts the position to the row and the rune column
+	runeCol := func(pos token.Pos) (int, int) {
+		p := fset.Position(pos)
+		line := lines[p.Line-1]
+		return p.Line - 1, utf8.RuneCount(line[:min(p.Column-1, len(line))])
+	}
+	var ranges []unusedRange
+	for _, e := range unusedErrs {
+		end := token.NoPos
+		ast.Inspect(f, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.ImportSpec:
+				if n.Pos() == e.Pos || n.Path.Pos() == e.Pos {
+					if n.Name != nil && n.Name.Name == "." {
+						return false // the empty package of a dot import is never used
+					}
+					end = n.End()
+				}
+			case *ast.Ident:
+				if n.Pos() == e.Pos {
+					end = n.End()
+				}
+			}
+			return end == token.NoPos
+		})
+		if end == token.NoPos {
+			continue
+		}
+		row, start := runeCol(e.Pos)
+		endRow, endCol := runeCol(end)
+		if endRow != row {
+			continue
+		}
+		ranges = append(ranges, unusedRange{row: row, start: start, end: endCol})
+	}
+	slices.SortFunc(ranges, func(a, b unusedRange) int { return cmp.Or(a.row-b.row, a.start-b.start) })
+	return ranges
+}
+
+// scheduleCheck checks the Go file of current tab for unused code when the typing pauses.
+func (a *App) scheduleCheck() {
+	tab := a.s.Tab
+	tab.checkedEdits = tab.edits
+	if !strings.HasSuffix(tab.filename, ".go") {
+		tab.unused = nil
+		return
+	}
+	if tab.checkTimer != nil {
+		tab.checkTimer.Stop()
+	}
+	tab.checkTimer = time.AfterFunc(checkDelay, func() {
+		postFunc(func() {
+			edits := tab.edits
+			src := []byte(tab.text())
+			go func() {
+				unused := findUnused(tab.filename, src)
+				postFunc(func() {
+					if tab.edits != edits {
+						return // checked again after the typing pauses
+					}
+					tab.unused = unused
+					if tab == a.s.Tab {
+						a.drawEditor()
+						a.syncCursor()
+					}
+				})
+			}()
+		})
+	})
+}
+
+// dimUnused sets the foreground of the unused code on the row of the styled texts.
+func (a *App) dimUnused(texts []textStyle, row int, line []rune) []textStyle {
+	var runes []textStyle
+	for _, u := range a.s.unused {
+		if u.row != row {
+			continue
+		}
+		if runes == nil {
+			for _, ts := range texts {
+				for _, r := range ts.text {
+					runes = append(runes, textStyle{text: []rune{r}, style: ts.style})
+				}
+			}
+		}
+		start := max(columnToVisual(line, u.start)-a.s.left, 0)
+		end := min(columnToVisual(line, u.end)-a.s.left, len(runes))
+		for i := start; i < end; i++ {
+			runes[i].style = runes[i].style.Foreground(colorUnused).Italic(true)
+		}
+	}
+	if runes == nil {
+		return texts
+	}
+	return runes
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGuessPackageName(t *testing.T) {
+	for path, want := range map[string]string{
+		"fmt":                           "fmt",
+		"go/ast":                        "ast",
+		"github.com/gdamore/tcell/v2":   "tcell",
+		"github.com/mattn/go-runewidth": "runewidth",
+		"gopkg.in/yaml.v3":              "yaml",
+	} {
+		if got := guessPackageName(path); got != want {
+			t.Errorf("guessPackageName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestFindUnused(t *testing.T) {
+	src := `package p
+
+import (
+	"fmt"
+	"os"
+	. "strings"
+	"github.com/gdamore/tcell/v2"
+)
+
+func f() {
+	n, ä := 1, 2
+	_ = tcell.KeyEnter
+	fmt.Println(n)
+}
+`
+	got := findUnused("p.go", []byte(src))
+	want := []unusedRange{{row: 4, start: 1, end: 5}, {row: 10, start: 4, end: 5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("findUnused = %+v, want %+v", got, want)
+	}
+	if got := findUnused("p.go", []byte("package p\nfunc (")); got != nil {
+		t.Errorf("findUnused of syntax error = %+v, want nil", got)
+	}
+}
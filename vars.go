@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// splitVarName returns the scope, "b" or "g", and the name of the variable, the scope
+// is empty without prefix.
+func splitVarName(name string) (scope, bare string) {
+	if s, n, ok := strings.Cut(name, ":"); ok && (s == "b" || s == "g") {
+		return s, n
+	}
+	return "", name
+}
+
+// validVarName matches the name of a variable without the scope.
+var validVarName = regexp.MustCompile(`^\w+$`)
+
+// variable returns the value of the variable, looked up in the tab and then globally
+// without a scope prefix.
+func (st *State) variable(name string) (string, bool) {
+	scope, name := splitVarName(name)
+	if scope != "g" {
+		if v, ok := st.Tab.vars[name]; ok {
+			return v, true
+		}
+	}
+	if scope != "b" {
+		v, ok := st.vars[name]
+		return v, ok
+	}
+	return "", false
+}
+
+// setVariable sets the variable, global without a scope prefix.
+func (st *State) setVariable(name, value string) error {
+	scope, bare := splitVarName(name)
+	if !validVarName.MatchString(bare) {
+		return fmt.Errorf("invalid variable name %q", name)
+	}
+	vars := &st.vars
+	if scope == "b" {
+		vars = &st.Tab.vars
+	}
+	if *vars == nil {
+		*vars = make(map[string]string)
+	}
+	(*vars)[bare] = value
+	return nil
+}
+
+// unsetVariable removes the variable, from the tab and globally without a scope prefix.
+func (st *State) unsetVariable(name string) bool {
+	scope, name := splitVarName(name)
+	_, found := st.variable(name)
+	if scope != "g" {
+		delete(st.Tab.vars, name)
+	}
+	if scope != "b" {
+		delete(st.vars, name)
+	}
+	return found
+}
+
+// boolVariable returns the boolean value of the variable, or def if unset or invalid.
+func (st *State) boolVariable(name string, def bool) bool {
+	v, ok := st.variable(name)
+	if !ok {
+		return def
+	}
+	b, err := parseBool(v)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// varRef matches a reference to a variable in a template, like ${author} or ${b:name}.
+var varRef = regexp.MustCompile(`\$\{((?:[bg]:)?\w+)\}`)
+
+// expandVariables replaces the references to the variables set in the text,
+// leaving the others.
+func (st *State) expandVariables(text string) string {
+	return varRef.ReplaceAllStringFunc(text, func(ref string) string {
+		if v, ok := st.variable(ref[2 : len(ref)-1]); ok {
+			return v
+		}
+		return ref
+	})
+}
+
+// parseLet parses the arguments of >let, the name and the value separated by spaces or "=".
+func parseLet(args string) (name, value string, hasValue bool) {
+	args = strings.TrimSpace(args)
+	i := strings.IndexAny(args, " =")
+	if i < 0 {
+		return args, "", false
+	}
+	name, value = args[:i], strings.TrimSpace(args[i:])
+	value = strings.TrimSpace(strings.TrimPrefix(value, "="))
+	return name, value, true
+}
+
+// let sets the variable of the arguments, shows it if no value is given,
+// or lists all the variables without arguments. Variables are global, or local to
+// the tab with the "b:" prefix; templates read them as ${name}, and some override
+// the settings for a tab, like b:formatonsave = false.
+func (a *App) let(args string) {
+	a.s.focus = focusEditor
+	name, value, hasValue := parseLet(args)
+	if name == "" {
+		a.listVariables()
+		return
+	}
+	if !hasValue {
+		v, ok := a.s.variable(name)
+		if !ok {
+			a.showMessage(name + " is not set")
+			return
+		}
+		a.showMessage(fmt.Sprintf("%s = %q", name, v))
+		return
+	}
+	if err := a.s.setVariable(name, value); err != nil {
+		a.showError(err.Error())
+		return
+	}
+	a.showMessage(fmt.Sprintf("%s = %q", name, value))
+}
+
+// listVariables shows the variables of the tab and the global ones in the "vars" tab.
+func (a *App) listVariables() {
+	var b strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(a.s.Tab.vars)) {
+		fmt.Fprintf(&b, "b:%s = %q\n", name, a.s.Tab.vars[name])
+	}
+	for _, name := range slices.Sorted(maps.Keys(a.s.vars)) {
+		fmt.Fprintf(&b, "g:%s = %q\n", name, a.s.vars[name])
+	}
+	if b.Len() == 0 {
+		a.showMessage("No variable, >let name value to set one")
+		return
+	}
+	a.updateBuffer("vars", b.String(), true)
+}
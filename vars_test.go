@@ -0,0 +1,50 @@
+package main
+
+import "testing"
+
+func TestParseLet(t *testing.T) {
+	tests := []struct {
+		args, name, value string
+		hasValue          bool
+	}{
+		{"", "", "", false},
+		{"author", "author", "", false},
+		{"author Jane Doe", "author", "Jane Doe", true},
+		{"b:fmt = false", "b:fmt", "false", true},
+		{"x=1", "x", "1", true},
+		{"x =", "x", "", true},
+	}
+	for _, tt := range tests {
+		name, value, hasValue := parseLet(tt.args)
+		if name != tt.name || value != tt.value || hasValue != tt.hasValue {
+			t.Errorf("parseLet(%q) = %q, %q, %v, want %q, %q, %v", tt.args, name, value, hasValue, tt.name, tt.value, tt.hasValue)
+		}
+	}
+}
+
+func TestVariables(t *testing.T) {
+	st := &State{Tab: &Tab{}}
+	st.setVariable("who", "global")
+	st.setVariable("b:who", "tab")
+	st.setVariable("fmt", "off")
+	if err := st.setVariable("b:no way", "x"); err == nil {
+		t.Error("want error for invalid name")
+	}
+	if got := st.expandVariables("${who} ${g:who} ${b:who} ${none} $who"); got != "tab global tab ${none} $who" {
+		t.Errorf("expandVariables = %q", got)
+	}
+	if st.boolVariable("fmt", true) || !st.boolVariable("other", true) {
+		t.Error("boolVariable does not follow the variable or the default")
+	}
+	other := &Tab{}
+	st.Tab = other
+	if v, _ := st.variable("who"); v != "global" {
+		t.Errorf("who in another tab = %q, want global", v)
+	}
+	if !st.unsetVariable("who") {
+		t.Error("unsetVariable(who) = false")
+	}
+	if _, ok := st.variable("who"); ok {
+		t.Error("who still set")
+	}
+}
@@ -0,0 +1,528 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// Modes of the vim-style modal editing
+const (
+	vimNormal = iota
+	vimInsert
+	vimVisual
+)
+
+var vimModeNames = []string{"NORMAL", "INSERT", "VISUAL"}
+
+// vimEvent handles the key event in vim-style modal editing.
+// Keys not handled by the current mode fall through to editorEvent,
+// except those typing or deleting text outside insert mode.
+func (a *App) vimEvent(ev *tcell.EventKey) {
+	if a.s.vimMode == vimInsert {
+		if ev.Key() != tcell.KeyEscape {
+			a.editorEvent(ev)
+			return
+		}
+		a.s.vimMode = vimNormal
+		a.updateCursorStyle()
+		if line := a.s.line(a.s.row); line != nil && a.s.col > 0 {
+			a.jump(a.s.row, prevGrapheme(line.Value, a.s.col))
+		}
+		a.syncCursor()
+		return
+	}
+
+	if ev.Key() != tcell.KeyRune || ev.Modifiers()&tcell.ModAlt != 0 {
+		a.s.vimPending = ""
+		a.s.vimCount = 0
+		a.s.vimOpCount = 0
+		if ev.Key() == tcell.KeyEscape && a.s.vimMode == vimVisual {
+			a.s.vimMode = vimNormal
+			a.unselect()
+			a.syncCursor()
+			return
+		}
+		if isEditKey(ev) && ev.Key() != tcell.KeyCtrlZ && ev.Key() != tcell.KeyCtrlY {
+			// text is typed, pasted or deleted in insert mode, undo and redo stay
+			return
+		}
+		a.editorEvent(ev)
+		return
+	}
+
+	defer func() {
+		a.vimClamp()
+		a.syncCursor()
+	}()
+	r := ev.Rune()
+	if p := a.s.vimPending; p == "m" || p == "'" || p == "`" {
+		a.s.vimPending = ""
+		if p == "m" {
+			a.setMark(r)
+		} else {
+			a.cmdCh <- "'" + string(r)
+		}
+		return
+	}
+	if unicode.IsDigit(r) && (r != '0' || a.s.vimCount > 0) {
+		a.s.vimCount = a.s.vimCount*10 + int(r-'0')
+		return
+	}
+	if r == 'g' && !strings.HasSuffix(a.s.vimPending, "g") {
+		a.s.vimPending += "g"
+		return
+	}
+	pending := a.s.vimPending
+	counted := a.s.vimCount > 0 || a.s.vimOpCount > 0
+	count := max(1, a.s.vimCount) * max(1, a.s.vimOpCount)
+	a.s.vimPending = ""
+	a.s.vimCount = 0
+	a.s.vimOpCount = 0
+	if strings.ContainsRune("dcxDCiaIAoOpPu", r) && !a.editable() {
+		return
+	}
+
+	motion := string(r)
+	if strings.HasSuffix(pending, "g") {
+		if r != 'g' {
+			return
+		}
+		motion = "gg"
+		pending = strings.TrimSuffix(pending, "g")
+	}
+
+	// operator
+	if strings.ContainsRune("dcy", r) {
+		if a.s.vimMode == vimVisual {
+			if a.s.selectingLines() {
+				startRow, endRow := a.s.selectedLines()
+				a.vimOperate(r, startRow, 0, endRow, 0, true)
+			} else if sel := a.s.selected(); sel != nil {
+				a.vimOperate(r, sel.startRow, sel.startCol, sel.endRow, sel.endCol, false)
+			}
+			if r != 'c' {
+				a.s.vimMode = vimNormal
+			}
+			return
+		}
+		if pending == "" {
+			a.s.vimPending = string(r)
+			a.s.vimOpCount = count
+			return
+		}
+		if pending == string(r) {
+			// dd, cc, yy operate on lines
+			endRow := min(a.s.row+count-1, a.s.lines.Len()-1)
+			a.vimOperate(r, a.s.row, 0, endRow, 0, true)
+		}
+		return
+	}
+
+	if pending == "c" && motion == "w" {
+		motion = "e" // like vim, cw changes to the end of the word
+	}
+	if row, col, linewise, inclusive, ok := a.vimMotion(motion, count, counted); ok {
+		if pending == "" {
+			a.jump(row, col)
+			if a.s.vimMode == vimVisual {
+				a.vimUpdateVisual()
+			}
+			return
+		}
+		startRow, startCol, endRow, endCol := a.s.row, a.s.col, row, col
+		if endRow < startRow || (endRow == startRow && endCol < startCol) {
+			startRow, startCol, endRow, endCol = endRow, endCol, startRow, startCol
+		}
+		if inclusive {
+			if line := a.s.line(endRow); line != nil {
+				endCol = nextGrapheme(line.Value, endCol)
+			}
+		}
+		a.vimOperate(rune(pending[0]), startRow, startCol, endRow, endCol, linewise)
+		return
+	}
+	if pending != "" {
+		return
+	}
+
+	line := a.s.line(a.s.row)
+	if line == nil {
+		line = a.s.lines.PushBack([]rune{})
+	}
+	text := line.Value
+	switch r {
+	case 'v', 'V':
+		if a.s.vimMode == vimVisual && a.s.lineSelect == (r == 'V') {
+			a.s.vimMode = vimNormal
+			a.unselect()
+			return
+		}
+		if a.s.vimMode != vimVisual {
+			a.s.vimMode = vimVisual
+			a.s.vimAnchorRow, a.s.vimAnchorCol = a.s.row, a.s.col
+		}
+		// switching between character-wise and line-wise keeps the anchor
+		a.s.lineSelect = r == 'V'
+		a.vimUpdateVisual()
+	case 'x':
+		if a.s.vimMode == vimVisual {
+			if a.s.selectingLines() {
+				startRow, endRow := a.s.selectedLines()
+				a.vimOperate('d', startRow, 0, endRow, 0, true)
+			} else if sel := a.s.selected(); sel != nil {
+				a.vimOperate('d', sel.startRow, sel.startCol, sel.endRow, sel.endCol, false)
+			}
+			a.s.vimMode = vimNormal
+			return
+		}
+		end := a.s.col
+		for range count {
+			end = nextGrapheme(text, end)
+		}
+		if end > a.s.col {
+			a.vimOperate('d', a.s.row, a.s.col, a.s.row, end, false)
+		}
+	case 'D', 'C':
+		a.vimOperate(unicode.ToLower(r), a.s.row, a.s.col, a.s.row, len(text), false)
+	case 'i':
+		a.vimInsert(a.s.row, a.s.col)
+	case 'a':
+		a.vimInsert(a.s.row, nextGrapheme(text, a.s.col))
+	case 'I':
+		a.vimInsert(a.s.row, leadingWhitespaces(text))
+	case 'A':
+		a.vimInsert(a.s.row, len(text))
+	case 'o':
+		a.vimInsert(a.s.row, len(text))
+		a.editorEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+	case 'O':
+		row := a.s.row
+		indent := text[:leadingWhitespaces(text)]
+		a.s.beginGroup()
+		defer a.s.endGroup()
+		a.vimInsert(row, 0)
+		a.editorEvent(tcell.NewEventKey(tcell.KeyEnter, 0, tcell.ModNone))
+		a.s.insertText(indent, row, 0)
+		a.s.recordChange(Change{row: row, col: 0, newText: string(indent), kind: editInsert})
+		a.jump(row, len(indent))
+		a.drawEditor()
+	case 'p', 'P':
+		a.vimPut(r == 'p', count)
+	case 'm', '\'', '`':
+		a.s.vimPending = string(r)
+	case 'u':
+		for range count {
+			a.s.undo()
+		}
+		a.jump(a.s.row, a.s.col)
+		a.drawEditor()
+	case '/':
+		a.s.focus = focusConsole
+		a.setConsole("#", "find")
+	case 'n':
+		if len(a.s.lastSearch) > 0 {
+			a.cmdCh <- "#" + string(a.s.lastSearch)
+		}
+	case ':':
+		a.s.focus = focusConsole
+		a.setConsole(":", "line[:column], +/-lines from the cursor")
+	}
+}
+
+// vimMotion returns the position the motion moves the cursor to, count times.
+// linewise reports whether an operator applies to whole lines,
+// inclusive reports whether it applies to the character at the position.
+func (a *App) vimMotion(motion string, count int, counted bool) (row, col int, linewise, inclusive, ok bool) {
+	row, col = a.s.row, a.s.col
+	line := a.s.line(row)
+	if line == nil {
+		return 0, 0, false, false, false
+	}
+	text := line.Value
+	last := a.s.lines.Len() - 1
+	switch motion {
+	case "h":
+		for range count {
+			if col > 0 {
+				col = prevGrapheme(text, col)
+			}
+		}
+	case "l":
+		for range count {
+			if col < len(text) {
+				col = nextGrapheme(text, col)
+			}
+		}
+	case "j", "k":
+		if a.s.upDownCol < 0 {
+			a.s.upDownCol = columnToScreenWidth(text, col)
+		}
+		upDownCol := a.s.upDownCol
+		defer func() { a.s.upDownCol = upDownCol }()
+		if motion == "j" {
+			row = min(row+count, last)
+		} else {
+			row = max(row-count, 0)
+		}
+		col = a.s.columnAt(a.s.line(row).Value, upDownCol)
+		return row, col, true, false, true
+	case "w":
+		for range count {
+			row, col = a.s.wordForward(row, col)
+		}
+	case "b":
+		for range count {
+			row, col = a.s.wordBackward(row, col)
+		}
+	case "e":
+		for range count {
+			row, col = a.s.wordEnd(row, col)
+		}
+		return row, col, false, true, true
+	case "{", "}":
+		dir := 1
+		if motion == "{" {
+			dir = -1
+		}
+		for range count {
+			row = a.s.paragraphRow(row, dir)
+		}
+		col = 0
+	case "0":
+		col = 0
+	case "^":
+		col = leadingWhitespaces(text)
+	case "$":
+		col = len(text)
+	case "G", "gg":
+		row = last
+		if motion == "gg" {
+			row = 0
+		}
+		if counted {
+			row = min(max(count-1, 0), last)
+		}
+		col = leadingWhitespaces(a.s.line(row).Value)
+		return row, col, true, false, true
+	default:
+		return 0, 0, false, false, false
+	}
+	a.s.upDownCol = -1
+	return row, col, false, false, true
+}
+
+// vimOperate deletes, changes or yanks the text in the range.
+// For linewise operation, the range covers whole lines from startRow to endRow.
+func (a *App) vimOperate(op rune, startRow, startCol, endRow, endCol int, linewise bool) {
+	if linewise {
+		startCol = 0
+		endCol = len(a.s.line(endRow).Value)
+	}
+	text := a.s.textIn(startRow, startCol, endRow, endCol)
+	if linewise {
+		text += "\n"
+	}
+	a.s.clipboard = text
+	a.s.vimLinewise = linewise
+	screen.SetClipboard([]byte(text))
+	a.s.selection = nil
+	if op == 'y' {
+		a.jump(startRow, startCol)
+		a.drawEditor()
+		return
+	}
+
+	if linewise && op == 'd' {
+		// take the line break as well
+		if endRow < a.s.lines.Len()-1 {
+			endRow, endCol = endRow+1, 0
+		} else if startRow > 0 {
+			startRow, startCol = startRow-1, len(a.s.line(startRow-1).Value)
+		}
+	}
+	deleted := a.s.deleteRange(startRow, startCol, endRow, endCol)
+	if deleted != "" {
+		a.s.recordChange(Change{row: startRow, col: startCol, oldText: deleted, kind: editDelete})
+	}
+	if op == 'c' {
+		a.vimInsert(startRow, startCol)
+	} else if linewise {
+		row := min(startRow+1, a.s.lines.Len()-1)
+		if startRow == 0 {
+			row = 0
+		}
+		a.jump(row, leadingWhitespaces(a.s.line(row).Value))
+	} else {
+		a.jump(startRow, startCol)
+	}
+	a.drawEditor()
+}
+
+// vimPut puts the yanked text after or before the cursor, count times.
+// Lines yanked as a whole are put below or above the current line.
+func (a *App) vimPut(after bool, count int) {
+	if a.s.clipboard == "" {
+		return
+	}
+	text := strings.Repeat(a.s.clipboard, count)
+	row, col := a.s.row, a.s.col
+	line := a.s.line(row).Value
+	if a.s.vimLinewise {
+		if after {
+			col = len(line)
+			text = "\n" + strings.TrimSuffix(text, "\n")
+		} else {
+			col = 0
+		}
+	} else if after && len(line) > 0 {
+		col = nextGrapheme(line, col)
+	}
+	a.s.insertText([]rune(text), row, col)
+	a.s.recordChange(Change{row: row, col: col, newText: text, kind: editInsert})
+	if a.s.vimLinewise {
+		if after {
+			row++
+		}
+		a.jump(row, leadingWhitespaces(a.s.line(row).Value))
+	} else {
+		a.jump(a.s.row, prevGrapheme(a.s.line(a.s.row).Value, a.s.col))
+	}
+	a.drawEditor()
+}
+
+// vimInsert moves the cursor and enters insert mode.
+func (a *App) vimInsert(row, col int) {
+	if a.s.vimMode == vimVisual {
+		// typing must not replace the visual selection
+		a.unselect()
+	}
+	a.s.vimMode = vimInsert
+	a.s.lastChange = nil
+	a.updateCursorStyle()
+	a.jump(row, col)
+}
+
+// vimUpdateVisual selects the text between the anchor and the cursor, both inclusive.
+func (a *App) vimUpdateVisual() {
+	if a.s.lineSelect {
+		a.selectLines(a.s.vimAnchorRow, a.s.row)
+		return
+	}
+	startRow, startCol := a.s.vimAnchorRow, a.s.vimAnchorCol
+	endRow, endCol := a.s.row, a.s.col
+	if endRow < startRow || (endRow == startRow && endCol < startCol) {
+		startRow, startCol, endRow, endCol = endRow, endCol, startRow, startCol
+	}
+	if line := a.s.line(endRow); line != nil {
+		endCol = nextGrapheme(line.Value, endCol)
+	}
+	a.s.selection = &Selection{startRow: startRow, startCol: startCol, endRow: endRow, endCol: endCol}
+	a.drawEditor()
+}
+
+// vimClamp keeps the cursor on a character in normal mode.
+func (a *App) vimClamp() {
+	if a.s.vimMode == vimInsert || a.s.virtualSpace {
+		return
+	}
+	line := a.s.line(a.s.row)
+	if line == nil {
+		return
+	}
+	if text := line.Value; len(text) > 0 && a.s.col >= len(text) {
+		a.jump(a.s.row, prevGrapheme(text, len(text)))
+	}
+}
+
+// charClass classifies the rune for word motions:
+// 0 for blanks, 1 for word characters, 2 for other punctuations.
+func (st *State) charClass(r rune) int {
+	switch {
+	case unicode.IsSpace(r):
+		return 0
+	case fileTypeOf(st.filename).isWordChar(r):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// wordForward returns the start of the next word, an empty line counts as a word.
+func (st *State) wordForward(row, col int) (int, int) {
+	line := st.line(row).Value
+	if col < len(line) {
+		cls := st.charClass(line[col])
+		for col < len(line) && cls != 0 && st.charClass(line[col]) == cls {
+			col++
+		}
+	}
+	for {
+		for col < len(line) && st.charClass(line[col]) == 0 {
+			col++
+		}
+		if col < len(line) || row == st.lines.Len()-1 {
+			return row, col
+		}
+		row++
+		col = 0
+		line = st.line(row).Value
+		if len(line) == 0 {
+			return row, 0
+		}
+	}
+}
+
+// wordEnd returns the end of the current or next word.
+func (st *State) wordEnd(row, col int) (int, int) {
+	line := st.line(row).Value
+	col++
+	for {
+		for col < len(line) && st.charClass(line[col]) == 0 {
+			col++
+		}
+		if col < len(line) {
+			break
+		}
+		if row == st.lines.Len()-1 {
+			return row, max(len(line)-1, 0)
+		}
+		row++
+		col = 0
+		line = st.line(row).Value
+	}
+	cls := st.charClass(line[col])
+	for col+1 < len(line) && st.charClass(line[col+1]) == cls {
+		col++
+	}
+	return row, col
+}
+
+// wordBackward returns the start of the current or previous word.
+func (st *State) wordBackward(row, col int) (int, int) {
+	line := st.line(row).Value
+	col = min(col, len(line)) - 1
+	for {
+		for col >= 0 && st.charClass(line[col]) == 0 {
+			col--
+		}
+		if col >= 0 {
+			break
+		}
+		if row == 0 {
+			return 0, 0
+		}
+		row--
+		line = st.line(row).Value
+		if len(line) == 0 {
+			return row, 0
+		}
+		col = len(line) - 1
+	}
+	cls := st.charClass(line[col])
+	for col > 0 && st.charClass(line[col-1]) == cls {
+		col--
+	}
+	return row, col
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/gdamore/tcell/v2"
+)
+
+// newVimApp returns an app in vim normal mode editing the text on a simulation screen.
+func newVimApp(t *testing.T, text string) *App {
+	a := newTestApp(t, text)
+	a.s.vim = true
+	return a
+}
+
+// vimKeys sends the runes of keys as key events, \x1b as escape.
+func (a *App) vimKeys(keys string) {
+	for _, r := range keys {
+		if r == '\x1b' {
+			a.vimEvent(tcell.NewEventKey(tcell.KeyEscape, 0, tcell.ModNone))
+			continue
+		}
+		a.vimEvent(tcell.NewEventKey(tcell.KeyRune, r, tcell.ModNone))
+	}
+}
+
+func TestVimMotions(t *testing.T) {
+	text := "one two three\n  four five\n\nsix\n"
+	tests := []struct {
+		keys     string
+		row, col int
+	}{
+		{"w", 0, 4},
+		{"2w", 0, 8},
+		{"e", 0, 2},
+		{"wb", 0, 0},
+		{"$", 0, 12}, // on the last character
+		{"j^", 1, 2},
+		{"jl0", 1, 0},
+		{"G", 4, 0},
+		{"Ggg", 0, 0},
+		{"2G", 1, 2},
+		{"}", 2, 0},
+		{"3lh", 0, 2},
+	}
+	for _, tt := range tests {
+		a := newVimApp(t, text)
+		a.vimKeys(tt.keys)
+		if a.s.row != tt.row || a.s.col != tt.col {
+			t.Errorf("%q: cursor at %d:%d, want %d:%d", tt.keys, a.s.row, a.s.col, tt.row, tt.col)
+		}
+	}
+}
+
+func TestVimOperators(t *testing.T) {
+	text := "one two three\nfour five\nsix\n"
+	tests := []struct {
+		keys string
+		want string
+	}{
+		{"dw", "two three\nfour five\nsix\n"},
+		{"d2w", "three\nfour five\nsix\n"},
+		{"2dw", "three\nfour five\nsix\n"},
+		{"de", " two three\nfour five\nsix\n"},
+		{"d$", "\nfour five\nsix\n"},
+		{"dd", "four five\nsix\n"},
+		{"2dd", "six\n"},
+		{"dj", "six\n"},
+		{"x", "ne two three\nfour five\nsix\n"},
+		{"cwten\x1b", "ten two three\nfour five\nsix\n"},
+		{"yyp", "one two three\none two three\nfour five\nsix\n"},
+		{"ywP", "one one two three\nfour five\nsix\n"},
+		{"ddu", "one two three\nfour five\nsix\n"},
+		{"vlld", " two three\nfour five\nsix\n"},
+		{"jVd", "one two three\nsix\n"},
+	}
+	for _, tt := range tests {
+		a := newVimApp(t, text)
+		a.vimKeys(tt.keys)
+		if got := a.s.text(); got != tt.want {
+			t.Errorf("%q: text = %q, want %q", tt.keys, got, tt.want)
+		}
+	}
+}
+
+func TestVimNormalSwallowsEditingKeys(t *testing.T) {
+	text := "one\ntwo\n"
+	keys := []tcell.Key{tcell.KeyEnter, tcell.KeyTab, tcell.KeyBacktab, tcell.KeyBackspace, tcell.KeyBackspace2,
+		tcell.KeyDelete, tcell.KeyCtrlU, tcell.KeyCtrlX, tcell.KeyCtrlV}
+	for _, visual := range []string{"", "vl"} {
+		for _, key := range keys {
+			a := newVimApp(t, text)
+			a.s.clipboard = "pasted"
+			a.vimKeys("jl" + visual)
+			a.vimEvent(tcell.NewEventKey(key, 0, tcell.ModNone))
+			if got := a.s.text(); got != text {
+				t.Errorf("%q then key %v: text = %q, want unchanged", visual, key, got)
+			}
+		}
+		a := newVimApp(t, text)
+		a.s.lastEdit = &Change{row: 0, col: 0, newText: "x", kind: editInsert}
+		a.vimKeys("jl" + visual)
+		a.vimEvent(tcell.NewEventKey(tcell.KeyRune, '.', tcell.ModAlt))
+		if got := a.s.text(); got != text {
+			t.Errorf("%q then alt-.: text = %q, want unchanged", visual, got)
+		}
+	}
+}
+
+func TestVimVisualInsertDropsSelection(t *testing.T) {
+	text := "one two\n"
+	tests := []struct {
+		keys string
+		want string
+	}{
+		{"wvlix", "one txwo\n"},
+		{"wvlax", "one twxo\n"},
+		{"wvlIx", "xone two\n"},
+		{"wvlAx", "one twox\n"},
+		{"wvlox", "one two\nx\n"},
+		{"vOx", "x\none two\n"},
+	}
+	for _, tt := range tests {
+		a := newVimApp(t, text)
+		a.vimKeys(tt.keys)
+		if got := a.s.text(); got != tt.want {
+			t.Errorf("%q: text = %q, want %q", tt.keys, got, tt.want)
+		}
+		if a.s.selected() != nil || a.s.vimMode != vimInsert {
+			t.Errorf("%q: selection %v in mode %s, want none in INSERT", tt.keys, a.s.selection, vimModeNames[a.s.vimMode])
+		}
+	}
+}